@@ -4,14 +4,21 @@ import (
 	"log"
 
 	"workshop4-backend/internal/app"
+	"workshop4-backend/internal/config"
 )
 
 func main() {
-	// Initialize database
-	db := app.InitDatabase()
-	defer db.Close()
+	// Load configuration
+	cfg, err := config.Load("configs/app.yaml")
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	// Open the database chosen by database.driver
+	storage := app.OpenStorage(cfg)
+	defer storage.Close()
 
 	// Setup and start server
-	server := app.SetupServer(db)
-	log.Fatal(server.Listen(":3000"))
+	server := app.SetupServer(storage, cfg)
+	log.Fatal(server.Listen(app.Address(cfg)))
 }
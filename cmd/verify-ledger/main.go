@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"workshop4-backend/internal/app"
+	"workshop4-backend/internal/config"
+	"workshop4-backend/internal/service"
+)
+
+// verify-ledger walks the point_ledger hash chain of the database chosen by
+// database.driver and exits non-zero when a broken link is found. The
+// database is opened read-only and is never migrated or backfilled.
+func main() {
+	configPath := flag.String("config", "configs/app.yaml", "path to the configuration file")
+	dbPath := flag.String("db", "", "path to the SQLite database (default: database.path)")
+	userID := flag.Int("user", 0, "verify a single user's chain (default: all users)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+	if *dbPath != "" {
+		cfg.Database.Path = *dbPath
+	}
+
+	ledgerRepo, closeDB, err := app.OpenLedgerReadOnly(cfg)
+	if err != nil {
+		log.Fatal("Failed to open ledger:", err)
+	}
+	defer closeDB()
+
+	ledgerService := service.NewLedgerService(ledgerRepo, nil)
+
+	var target *int
+	if *userID > 0 {
+		target = userID
+	}
+
+	result, err := ledgerService.VerifyChain(target)
+	if err != nil {
+		log.Fatal("Failed to verify ledger:", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Fatal("Failed to write result:", err)
+	}
+
+	if !result.Valid {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,53 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// HTTPSMSNotifier sends SMS through an HTTP gateway by POSTing
+// {"to", "from", "text"} as JSON, with the API key as a bearer token.
+type HTTPSMSNotifier struct {
+	client *http.Client
+	url    string
+	apiKey string
+	sender string
+}
+
+func NewHTTPSMSNotifier(url, apiKey, sender string, timeout time.Duration) port.Notifier {
+	return &HTTPSMSNotifier{client: &http.Client{Timeout: timeout}, url: url, apiKey: apiKey, sender: sender}
+}
+
+func (n *HTTPSMSNotifier) Notify(ctx context.Context, notification domain.Notification) error {
+	body, err := json.Marshal(map[string]string{"to": notification.To, "from": n.sender, "text": notification.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+n.apiKey)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"workshop4-backend/internal/domain"
+)
+
+// LogEventSink writes each published event to the process log as JSON.
+type LogEventSink struct{}
+
+func (LogEventSink) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	log.Printf("Event %s", data)
+	return nil
+}
@@ -0,0 +1,21 @@
+package adapter
+
+import (
+	"context"
+	"log"
+
+	"workshop4-backend/internal/domain"
+)
+
+// LogNotifier writes notifications to the process log instead of sending
+// them. It stands in for the email and SMS providers in development.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, notification domain.Notification) error {
+	if notification.Subject != "" {
+		log.Printf("Notification %s to %s: %s: %s", notification.Channel, notification.To, notification.Subject, notification.Body)
+		return nil
+	}
+	log.Printf("Notification %s to %s: %s", notification.Channel, notification.To, notification.Body)
+	return nil
+}
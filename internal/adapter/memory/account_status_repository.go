@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type AccountStatusRepository struct {
+	db conn
+}
+
+func NewAccountStatusRepository(store *Store) port.AccountStatusRepository {
+	return &AccountStatusRepository{db: store}
+}
+
+func (r *AccountStatusRepository) CreateHistory(change *domain.AccountStatusChange) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	change.ID = d.nextID("account_status_history")
+	d.statusHistory = append(d.statusHistory, *change)
+	return nil
+}
+
+// GetHistoryByUserID returns the user's status changes, newest first.
+func (r *AccountStatusRepository) GetHistoryByUserID(userID int) ([]domain.AccountStatusChange, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	history := []domain.AccountStatusChange{}
+	for i := len(d.statusHistory) - 1; i >= 0; i-- {
+		if d.statusHistory[i].UserID == userID {
+			history = append(history, d.statusHistory[i])
+		}
+	}
+	return history, nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type AuditRepository struct {
+	db conn
+}
+
+func NewAuditRepository(store *Store) port.AuditRepository {
+	return &AuditRepository{db: store}
+}
+
+func (r *AuditRepository) Create(entry *domain.AuditEntry) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	entry.ID = d.nextID("audit_log")
+	d.audit = append(d.audit, *entry)
+	return nil
+}
+
+// List returns one page of the matching entries, newest first, and the
+// total number of matches.
+func (r *AuditRepository) List(filter port.AuditFilter) ([]domain.AuditEntry, int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var matches []domain.AuditEntry
+	for i := len(d.audit) - 1; i >= 0; i-- {
+		if matchesAuditFilter(d.audit[i], filter) {
+			matches = append(matches, d.audit[i])
+		}
+	}
+	return pageOf(matches, filter.Page, filter.PageSize), len(matches), nil
+}
+
+func matchesAuditFilter(entry domain.AuditEntry, filter port.AuditFilter) bool {
+	switch {
+	case filter.Actor != "" && entry.Actor != filter.Actor,
+		filter.Action != "" && entry.Action != filter.Action,
+		filter.EntityType != "" && entry.EntityType != filter.EntityType,
+		filter.EntityID != "" && entry.EntityID != filter.EntityID,
+		filter.From != nil && entry.CreatedAt.Before(*filter.From),
+		filter.To != nil && entry.CreatedAt.After(*filter.To):
+		return false
+	}
+	return true
+}
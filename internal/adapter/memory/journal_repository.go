@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"maps"
+	"slices"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type JournalRepository struct {
+	db conn
+}
+
+func NewJournalRepository(store *Store) port.JournalRepository {
+	return &JournalRepository{db: store}
+}
+
+// Create stores the entry and its postings, assigning IDs to both.
+func (r *JournalRepository) Create(entry *domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	d, release := r.db.acquire()
+	defer release()
+
+	entry.ID = d.nextID("journal_entries")
+	for i := range entry.Postings {
+		entry.Postings[i].ID = d.nextID("journal_postings")
+		entry.Postings[i].JournalEntryID = entry.ID
+	}
+	stored := *entry
+	stored.Postings = slices.Clone(entry.Postings)
+	d.journal = append(d.journal, stored)
+	return nil
+}
+
+func (r *JournalRepository) GetByTransferID(transferID int) ([]domain.JournalEntry, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var entries []domain.JournalEntry
+	for _, entry := range d.journal {
+		if entry.TransferID != nil && *entry.TransferID == transferID {
+			entry.Postings = slices.Clone(entry.Postings)
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (r *JournalRepository) HasPostings(account string) (bool, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	for _, entry := range d.journal {
+		for _, posting := range entry.Postings {
+			if posting.Account == account {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// GetAccountBalances sums the postings of every account, ordered by account.
+func (r *JournalRepository) GetAccountBalances() ([]domain.AccountBalance, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	sums := map[string]int{}
+	for _, entry := range d.journal {
+		for _, posting := range entry.Postings {
+			sums[posting.Account] += posting.Amount
+		}
+	}
+
+	var balances []domain.AccountBalance
+	for _, account := range slices.Sorted(maps.Keys(sums)) {
+		balances = append(balances, domain.AccountBalance{Account: account, Balance: sums[account]})
+	}
+	return balances, nil
+}
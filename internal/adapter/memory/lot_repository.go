@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type LotRepository struct {
+	db conn
+}
+
+func NewLotRepository(store *Store) port.LotRepository {
+	return &LotRepository{db: store}
+}
+
+func (r *LotRepository) Create(lot *domain.PointLot) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	lot.ID = d.nextID("point_lots")
+	d.lots[lot.ID] = *lot
+	return nil
+}
+
+func (r *LotRepository) GetOpenByUserID(userID int) ([]domain.PointLot, error) {
+	return r.find(func(lot domain.PointLot) bool { return lot.UserID == userID }, byExpiry)
+}
+
+func (r *LotRepository) GetExpired(asOf time.Time) ([]domain.PointLot, error) {
+	return r.find(func(lot domain.PointLot) bool { return !lot.ExpiresAt.After(asOf) }, func(a, b domain.PointLot) int {
+		return cmp.Or(cmp.Compare(a.UserID, b.UserID), byExpiry(a, b))
+	})
+}
+
+func (r *LotRepository) UpdateRemaining(id int, remaining int) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	if lot, ok := d.lots[id]; ok {
+		lot.Remaining = remaining
+		d.lots[id] = lot
+	}
+	return nil
+}
+
+// find returns the lots with points remaining that match, sorted by compare.
+func (r *LotRepository) find(match func(domain.PointLot) bool, compare func(a, b domain.PointLot) int) ([]domain.PointLot, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var lots []domain.PointLot
+	for _, lot := range d.lots {
+		if lot.Remaining > 0 && match(lot) {
+			lots = append(lots, lot)
+		}
+	}
+	slices.SortFunc(lots, compare)
+	return lots, nil
+}
+
+// byExpiry orders lots FIFO: earliest expiry first.
+func byExpiry(a, b domain.PointLot) int {
+	return cmp.Or(a.ExpiresAt.Compare(b.ExpiresAt), cmp.Compare(a.ID, b.ID))
+}
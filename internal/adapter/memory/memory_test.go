@@ -0,0 +1,33 @@
+package memory_test
+
+import (
+	"testing"
+
+	"workshop4-backend/internal/adapter/memory"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/port/porttest"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	porttest.RunRepositoryTests(t, func(t *testing.T) porttest.Backend {
+		store := memory.NewStore()
+		return porttest.Backend{
+			Repos: port.Repositories{
+				Users:         memory.NewUserRepository(store),
+				Transfers:     memory.NewTransferRepository(store),
+				Ledger:        memory.NewPointLedgerRepository(store),
+				Journal:       memory.NewJournalRepository(store),
+				Lots:          memory.NewLotRepository(store),
+				Tiers:         memory.NewTierRepository(store),
+				Sequences:     memory.NewSequenceRepository(store),
+				Confirmations: memory.NewTransferConfirmationRepository(store),
+				StatusHistory: memory.NewAccountStatusRepository(store),
+				Outbox:        memory.NewOutboxRepository(store),
+				Audit:         memory.NewAuditRepository(store),
+			},
+			Webhooks:      memory.NewWebhookRepository(store),
+			Notifications: memory.NewNotificationPreferenceRepository(store),
+			Transactor:    memory.NewTransactor(store),
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"slices"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type NotificationPreferenceRepository struct {
+	db conn
+}
+
+func NewNotificationPreferenceRepository(store *Store) port.NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: store}
+}
+
+func (r *NotificationPreferenceRepository) Get(userID int) (*domain.NotificationPreferences, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	preferences, ok := d.preferences[userID]
+	if !ok {
+		return nil, nil
+	}
+	preferences.Muted = slices.Clone(preferences.Muted)
+	return &preferences, nil
+}
+
+func (r *NotificationPreferenceRepository) Save(preferences *domain.NotificationPreferences) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	stored := *preferences
+	stored.Muted = slices.Clone(preferences.Muted)
+	d.preferences[stored.UserID] = stored
+	return nil
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"slices"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type OutboxRepository struct {
+	db conn
+}
+
+func NewOutboxRepository(store *Store) port.OutboxRepository {
+	return &OutboxRepository{db: store}
+}
+
+func (r *OutboxRepository) Create(event *domain.OutboxEvent) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	event.ID = d.nextID("outbox_events")
+	stored := *event
+	stored.Payload = slices.Clone(event.Payload)
+	stored.PublishedTo = slices.Clone(event.PublishedTo)
+	d.outbox = append(d.outbox, stored)
+	return nil
+}
+
+func (r *OutboxRepository) GetByID(id int) (*domain.OutboxEvent, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	if i, ok := findEvent(d.outbox, id); ok {
+		event := copyEvent(d.outbox[i])
+		return &event, nil
+	}
+	return nil, nil
+}
+
+func (r *OutboxRepository) List(filter port.OutboxFilter) ([]domain.OutboxEvent, int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var matches []domain.OutboxEvent
+	for i := len(d.outbox) - 1; i >= 0; i-- {
+		if filter.Status != "" && d.outbox[i].Status != filter.Status {
+			continue
+		}
+		matches = append(matches, copyEvent(d.outbox[i]))
+	}
+	return pageOf(matches, filter.Page, filter.PageSize), len(matches), nil
+}
+
+func (r *OutboxRepository) ClaimDue(asOf, claimUntil time.Time, limit int) ([]domain.OutboxEvent, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var events []domain.OutboxEvent
+	for i := range d.outbox {
+		if len(events) == limit {
+			break
+		}
+		event := &d.outbox[i]
+		if event.Status == domain.OutboxEventPending && !event.NextAttemptAt.After(asOf) {
+			event.NextAttemptAt = claimUntil
+			events = append(events, copyEvent(*event))
+		}
+	}
+	return events, nil
+}
+
+func (r *OutboxRepository) MarkPublished(id int, at time.Time) error {
+	return r.update(id, func(event *domain.OutboxEvent) {
+		event.Status = domain.OutboxEventPublished
+		event.PublishedAt = &at
+	})
+}
+
+func (r *OutboxRepository) MarkFailed(id int, lastError string, nextAttemptAt time.Time, publishedTo []string) error {
+	return r.update(id, func(event *domain.OutboxEvent) {
+		event.Attempts++
+		event.LastError = &lastError
+		event.NextAttemptAt = nextAttemptAt
+		event.PublishedTo = slices.Clone(publishedTo)
+	})
+}
+
+func (r *OutboxRepository) MarkDead(id int, lastError string, publishedTo []string) error {
+	return r.update(id, func(event *domain.OutboxEvent) {
+		event.Status = domain.OutboxEventDead
+		event.Attempts++
+		event.LastError = &lastError
+		event.PublishedTo = slices.Clone(publishedTo)
+	})
+}
+
+func (r *OutboxRepository) Requeue(id int, at time.Time) error {
+	return r.update(id, func(event *domain.OutboxEvent) {
+		event.Status = domain.OutboxEventPending
+		event.Attempts = 0
+		event.NextAttemptAt = at
+	})
+}
+
+// update applies change to the stored event, if there is one.
+func (r *OutboxRepository) update(id int, change func(event *domain.OutboxEvent)) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	if i, ok := findEvent(d.outbox, id); ok {
+		change(&d.outbox[i])
+	}
+	return nil
+}
+
+// findEvent finds an event by ID. Events are appended in ID order, so it
+// can binary search.
+func findEvent(events []domain.OutboxEvent, id int) (int, bool) {
+	return slices.BinarySearchFunc(events, id, func(event domain.OutboxEvent, id int) int { return event.ID - id })
+}
+
+// copyEvent returns event with its own PublishedTo, so callers cannot change
+// the stored event through it.
+func copyEvent(event domain.OutboxEvent) domain.OutboxEvent {
+	event.PublishedTo = slices.Clone(event.PublishedTo)
+	return event
+}
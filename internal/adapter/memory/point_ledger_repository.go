@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PointLedgerRepository struct {
+	db conn
+}
+
+func NewPointLedgerRepository(store *Store) port.PointLedgerRepository {
+	return &PointLedgerRepository{db: store}
+}
+
+// Create appends entry to the user's hash chain.
+func (r *PointLedgerRepository) Create(entry *domain.PointLedger) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	entry.PrevHash = ""
+	for i := len(d.ledger) - 1; i >= 0; i-- {
+		if d.ledger[i].UserID == entry.UserID {
+			entry.PrevHash = d.ledger[i].Hash
+			break
+		}
+	}
+	entry.Hash = entry.ComputeHash(entry.PrevHash)
+	entry.ID = d.nextID("point_ledger")
+	d.ledger = append(d.ledger, *entry)
+	return nil
+}
+
+// GetByUserID returns the user's entries, newest first.
+func (r *PointLedgerRepository) GetByUserID(userID int) ([]domain.PointLedger, error) {
+	entries, err := r.GetChain(userID)
+	slices.SortFunc(entries, func(a, b domain.PointLedger) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return entries, err
+}
+
+// GetChain returns the user's entries in insertion order, which is the order
+// the hash chain was built in.
+func (r *PointLedgerRepository) GetChain(userID int) ([]domain.PointLedger, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var entries []domain.PointLedger
+	for _, entry := range d.ledger {
+		if entry.UserID == userID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (r *PointLedgerRepository) GetUserIDs() ([]int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var userIDs []int
+	for _, entry := range d.ledger {
+		userIDs = append(userIDs, entry.UserID)
+	}
+	slices.Sort(userIDs)
+	return slices.Compact(userIDs), nil
+}
+
+func (r *PointLedgerRepository) GetUserBalance(userID int) (int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var latest *domain.PointLedger
+	for i := range d.ledger {
+		entry := &d.ledger[i]
+		if entry.UserID == userID && (latest == nil || !entry.CreatedAt.Before(latest.CreatedAt)) {
+			latest = entry
+		}
+	}
+	if latest != nil {
+		return latest.BalanceAfter, nil
+	}
+
+	// No ledger entries, use the user's initial points
+	user, ok := d.users[userID]
+	if !ok {
+		return 0, fmt.Errorf("user %d not found", userID)
+	}
+	return user.Points, nil
+}
+
+func (r *PointLedgerRepository) GetEarnedSince(userID int, since time.Time) (int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	earned := 0
+	for _, entry := range d.ledger {
+		if entry.UserID == userID && entry.EventType == domain.EventTypeEarn && !entry.CreatedAt.Before(since) {
+			earned += entry.Change
+		}
+	}
+	return earned, nil
+}
@@ -0,0 +1,19 @@
+package memory
+
+import "workshop4-backend/internal/port"
+
+type SequenceRepository struct {
+	db conn
+}
+
+func NewSequenceRepository(store *Store) port.SequenceRepository {
+	return &SequenceRepository{db: store}
+}
+
+func (r *SequenceRepository) Next(name string) (int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	d.sequences[name]++
+	return d.sequences[name], nil
+}
@@ -0,0 +1,157 @@
+// Package memory implements the repository ports in memory. It is meant for
+// tests and demos: data lives only as long as the process and every
+// operation is serialized by a single lock.
+package memory
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// Store holds the data shared by the repositories created from it.
+type Store struct {
+	mu   sync.Mutex
+	data *data
+}
+
+func NewStore() *Store {
+	return &Store{data: newData()}
+}
+
+// data is one consistent snapshot of every table. Rows are stored by value
+// and copied on the way in and out, so callers never share them.
+type data struct {
+	users         map[int]domain.User
+	transfers     map[int]domain.Transfer
+	ledger        []domain.PointLedger
+	journal       []domain.JournalEntry
+	lots          map[int]domain.PointLot
+	tiers         []domain.TierHistory
+	statusHistory []domain.AccountStatusChange
+	audit         []domain.AuditEntry
+	outbox        []domain.OutboxEvent
+	webhooks      map[int]domain.WebhookSubscription
+	deliveries    []domain.WebhookDelivery
+	preferences   map[int]domain.NotificationPreferences
+	confirmations map[string]domain.TransferConfirmation
+	sequences     map[string]int
+	// lastIDs is the last ID handed out per table, like AUTOINCREMENT
+	lastIDs map[string]int
+}
+
+func newData() *data {
+	return &data{
+		users:         map[int]domain.User{},
+		transfers:     map[int]domain.Transfer{},
+		lots:          map[int]domain.PointLot{},
+		webhooks:      map[int]domain.WebhookSubscription{},
+		preferences:   map[int]domain.NotificationPreferences{},
+		confirmations: map[string]domain.TransferConfirmation{},
+		sequences:     map[string]int{},
+		lastIDs:       map[string]int{},
+	}
+}
+
+// clone copies d so a transaction can change the copy and throw it away on
+// rollback. Journal postings are never changed once stored, so entries can
+// share them.
+func (d *data) clone() *data {
+	return &data{
+		users:         maps.Clone(d.users),
+		transfers:     maps.Clone(d.transfers),
+		ledger:        slices.Clone(d.ledger),
+		journal:       slices.Clone(d.journal),
+		lots:          maps.Clone(d.lots),
+		tiers:         slices.Clone(d.tiers),
+		statusHistory: slices.Clone(d.statusHistory),
+		audit:         slices.Clone(d.audit),
+		outbox:        slices.Clone(d.outbox),
+		webhooks:      maps.Clone(d.webhooks),
+		deliveries:    slices.Clone(d.deliveries),
+		preferences:   maps.Clone(d.preferences),
+		confirmations: maps.Clone(d.confirmations),
+		sequences:     maps.Clone(d.sequences),
+		lastIDs:       maps.Clone(d.lastIDs),
+	}
+}
+
+func (d *data) nextID(table string) int {
+	d.lastIDs[table]++
+	return d.lastIDs[table]
+}
+
+// conn gives a repository access to the data: the store's, under its lock,
+// or a transaction's working copy, which its transactor already holds the
+// lock for.
+type conn interface {
+	acquire() (*data, func())
+}
+
+func (s *Store) acquire() (*data, func()) {
+	s.mu.Lock()
+	return s.data, s.mu.Unlock
+}
+
+type txConn struct {
+	data *data
+}
+
+func (c txConn) acquire() (*data, func()) {
+	return c.data, func() {}
+}
+
+// pageOf returns a copy of the rows on the given 1-based page.
+func pageOf[T any](rows []T, page, pageSize int) []T {
+	start := min(max(page-1, 0)*pageSize, len(rows))
+	end := min(start+pageSize, len(rows))
+	return append(make([]T, 0, end-start), rows[start:end]...)
+}
+
+// now is the time the store stamps rows with when the caller does not pass
+// one, at the precision the SQL adapters store.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+type Transactor struct {
+	store *Store
+}
+
+func NewTransactor(store *Store) port.Transactor {
+	return &Transactor{store: store}
+}
+
+// WithinTransaction holds the store's lock while fn runs, so transactions
+// are serialized. fn works on a copy of the data that replaces the store's
+// only if fn succeeds. Repositories created directly from the store must
+// not be used inside fn; they would wait for the lock fn's transaction holds.
+func (t *Transactor) WithinTransaction(fn func(repos port.Repositories) error) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	work := txConn{data: t.store.data.clone()}
+	repos := port.Repositories{
+		Users:         &UserRepository{db: work},
+		Transfers:     &TransferRepository{db: work},
+		Ledger:        &PointLedgerRepository{db: work},
+		Journal:       &JournalRepository{db: work},
+		Lots:          &LotRepository{db: work},
+		Tiers:         &TierRepository{db: work},
+		Sequences:     &SequenceRepository{db: work},
+		Confirmations: &TransferConfirmationRepository{db: work},
+		StatusHistory: &AccountStatusRepository{db: work},
+		Outbox:        &OutboxRepository{db: work},
+		Audit:         &AuditRepository{db: work},
+	}
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	t.store.data = work.data
+	return nil
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type TierRepository struct {
+	db conn
+}
+
+func NewTierRepository(store *Store) port.TierRepository {
+	return &TierRepository{db: store}
+}
+
+func (r *TierRepository) CreateHistory(history *domain.TierHistory) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	history.ID = d.nextID("tier_history")
+	d.tiers = append(d.tiers, *history)
+	return nil
+}
+
+// GetHistoryByUserID returns the user's tier changes, newest first.
+func (r *TierRepository) GetHistoryByUserID(userID int) ([]domain.TierHistory, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	history := []domain.TierHistory{}
+	for i := len(d.tiers) - 1; i >= 0; i-- {
+		if d.tiers[i].UserID == userID {
+			history = append(history, d.tiers[i])
+		}
+	}
+	return history, nil
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type TransferConfirmationRepository struct {
+	db conn
+}
+
+func NewTransferConfirmationRepository(store *Store) port.TransferConfirmationRepository {
+	return &TransferConfirmationRepository{db: store}
+}
+
+// Create stores the confirmation. Like the SQL adapters, it keeps the
+// resolved recipient but not the identifier it was looked up by.
+func (r *TransferConfirmationRepository) Create(confirmation *domain.TransferConfirmation) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	if _, ok := d.confirmations[confirmation.ID]; ok {
+		return fmt.Errorf("transfer confirmation %q already exists", confirmation.ID)
+	}
+	stored := *confirmation
+	stored.Recipient = domain.RecipientIdentifier{}
+	stored.ConsumedAt = nil
+	d.confirmations[stored.ID] = stored
+	return nil
+}
+
+func (r *TransferConfirmationRepository) GetByID(id string) (*domain.TransferConfirmation, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	confirmation, ok := d.confirmations[id]
+	if !ok {
+		return nil, nil
+	}
+	return &confirmation, nil
+}
+
+func (r *TransferConfirmationRepository) MarkConsumed(id string, at time.Time) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	confirmation, ok := d.confirmations[id]
+	if !ok || confirmation.ConsumedAt != nil {
+		return port.ErrConfirmationConsumed
+	}
+	confirmation.ConsumedAt = &at
+	d.confirmations[id] = confirmation
+	return nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type TransferRepository struct {
+	db conn
+}
+
+func NewTransferRepository(store *Store) port.TransferRepository {
+	return &TransferRepository{db: store}
+}
+
+func (r *TransferRepository) Create(transfer *domain.Transfer) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	for _, existing := range d.transfers {
+		if existing.IdempotencyKey == transfer.IdempotencyKey {
+			return fmt.Errorf("idempotency key %q already exists", transfer.IdempotencyKey)
+		}
+	}
+	transfer.ID = d.nextID("transfers")
+	d.transfers[transfer.ID] = *transfer
+	return nil
+}
+
+func (r *TransferRepository) GetByIdempotencyKey(key string) (*domain.Transfer, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	for _, transfer := range d.transfers {
+		if transfer.IdempotencyKey == key {
+			return &transfer, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetByUserID returns the user's sent and received transfers, newest first.
+func (r *TransferRepository) GetByUserID(userID int, page, pageSize int) ([]domain.Transfer, int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var transfers []domain.Transfer
+	for _, transfer := range d.transfers {
+		if transfer.FromUserID == userID || transfer.ToUserID == userID {
+			transfers = append(transfers, transfer)
+		}
+	}
+	slices.SortFunc(transfers, func(a, b domain.Transfer) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return pageOf(transfers, page, pageSize), len(transfers), nil
+}
+
+func (r *TransferRepository) UpdateStatus(id int, status domain.TransferStatus, completedAt *time.Time, failReason *string) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	transfer, ok := d.transfers[id]
+	if !ok {
+		return nil
+	}
+	transfer.Status = status
+	transfer.UpdatedAt = now()
+	transfer.CompletedAt = completedAt
+	transfer.FailReason = failReason
+	d.transfers[id] = transfer
+	return nil
+}
@@ -0,0 +1,274 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type UserRepository struct {
+	db conn
+}
+
+func NewUserRepository(store *Store) port.UserRepositoryWithBalance {
+	return &UserRepository{db: store}
+}
+
+func (r *UserRepository) GetAll() ([]domain.User, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var users []domain.User
+	for _, user := range sortedUsers(d) {
+		if user.DeletedAt == nil {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// userSortKeys compares two users by each port.UserSort.
+var userSortKeys = map[port.UserSort]func(a, b domain.User) int{
+	port.UserSortID:          func(a, b domain.User) int { return 0 },
+	port.UserSortName:        func(a, b domain.User) int { return strings.Compare(a.Name, b.Name) },
+	port.UserSortPoints:      func(a, b domain.User) int { return cmp.Compare(a.Points, b.Points) },
+	port.UserSortMemberSince: func(a, b domain.User) int { return strings.Compare(a.MemberSince, b.MemberSince) },
+	port.UserSortCreatedAt:   func(a, b domain.User) int { return a.CreatedAt.Compare(b.CreatedAt) },
+}
+
+func (r *UserRepository) List(filter port.UserFilter) ([]domain.User, int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var matches []domain.User
+	for _, user := range d.users {
+		if user.DeletedAt == nil && matchesUserFilter(user, filter) {
+			matches = append(matches, user)
+		}
+	}
+
+	compare, ok := userSortKeys[filter.Sort]
+	if !ok {
+		compare = userSortKeys[port.UserSortID]
+	}
+	slices.SortFunc(matches, func(a, b domain.User) int {
+		c := cmp.Or(compare(a, b), cmp.Compare(a.ID, b.ID))
+		if filter.Descending {
+			return -c
+		}
+		return c
+	})
+
+	return pageOf(matches, filter.Page, filter.PageSize), len(matches), nil
+}
+
+// matchesUserFilter applies filter the way the SQL adapters do: the name
+// prefix and level ignore case, and member_since compares as an ISO date.
+func matchesUserFilter(user domain.User, filter port.UserFilter) bool {
+	if filter.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(user.Name), strings.ToLower(filter.NamePrefix)) {
+		return false
+	}
+	if filter.MembershipLevel != "" && !strings.EqualFold(user.MembershipLevel, filter.MembershipLevel) {
+		return false
+	}
+	if filter.MemberSinceFrom != nil && user.MemberSince < filter.MemberSinceFrom.Format(domain.DateLayout) {
+		return false
+	}
+	if filter.MemberSinceTo != nil && user.MemberSince > filter.MemberSinceTo.Format(domain.DateLayout) {
+		return false
+	}
+	if filter.MinPoints != nil && user.Points < *filter.MinPoints {
+		return false
+	}
+	if filter.MaxPoints != nil && user.Points > *filter.MaxPoints {
+		return false
+	}
+	return true
+}
+
+// Search matches every term anywhere in the name, email, phone or member ID,
+// ignoring case. There is no relevance ranking; results are in ID order.
+func (r *UserRepository) Search(terms []string, limit int) ([]domain.User, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	users := []domain.User{}
+	for _, user := range sortedUsers(d) {
+		if len(users) == limit {
+			break
+		}
+		if user.DeletedAt == nil && matchesAllTerms(user, terms) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func matchesAllTerms(user domain.User, terms []string) bool {
+	haystack := strings.ToLower(strings.Join([]string{user.Name, user.Email, user.Phone, user.MemberID}, "\x00"))
+	for _, term := range terms {
+		if !strings.Contains(haystack, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *UserRepository) GetByID(id int) (*domain.User, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	user, ok := d.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) GetByMemberID(memberID string) (*domain.User, error) {
+	return r.getOne(func(user domain.User) bool { return user.MemberID == memberID })
+}
+
+func (r *UserRepository) GetByPhone(phone string) (*domain.User, error) {
+	return r.getOne(func(user domain.User) bool { return user.Phone == phone && user.DeletedAt == nil })
+}
+
+func (r *UserRepository) GetByEmail(email string) (*domain.User, error) {
+	return r.getOne(func(user domain.User) bool { return user.Email == email && user.DeletedAt == nil })
+}
+
+// getOne returns the user with the lowest ID that matches.
+func (r *UserRepository) getOne(match func(domain.User) bool) (*domain.User, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	for _, user := range sortedUsers(d) {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *UserRepository) Create(user *domain.User) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	for _, existing := range d.users {
+		if existing.MemberID == user.MemberID {
+			return fmt.Errorf("member_id %q already exists", user.MemberID)
+		}
+	}
+	if err := checkContacts(d, user); err != nil {
+		return err
+	}
+	if user.Status == "" {
+		user.Status = domain.AccountStatusActive
+	}
+	user.ID = d.nextID("users")
+	user.Version = 1
+	d.users[user.ID] = *user
+	return nil
+}
+
+func (r *UserRepository) Update(user *domain.User) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	stored, ok := d.users[user.ID]
+	if !ok || stored.Version != user.Version {
+		return port.ErrVersionConflict
+	}
+	if err := checkContacts(d, user); err != nil {
+		return err
+	}
+	// member_id is assigned once at creation and never changes
+	stored.Name = user.Name
+	stored.Phone = user.Phone
+	stored.Email = user.Email
+	stored.MemberSince = user.MemberSince
+	stored.MembershipLevel = user.MembershipLevel
+	stored.Points = user.Points
+	stored.UpdatedAt = user.UpdatedAt
+	stored.Version++
+	d.users[user.ID] = stored
+	user.Version++
+	return nil
+}
+
+// checkContacts enforces what the SQL stores' unique indexes do: no two
+// open accounts share a phone or email.
+func checkContacts(d *data, user *domain.User) error {
+	for _, existing := range d.users {
+		if existing.ID == user.ID || existing.DeletedAt != nil {
+			continue
+		}
+		if existing.Phone == user.Phone {
+			return port.ErrPhoneTaken
+		}
+		if existing.Email == user.Email {
+			return port.ErrEmailTaken
+		}
+	}
+	return nil
+}
+
+func (r *UserRepository) UpdateStatus(id int, status domain.AccountStatus) error {
+	return r.update(id, func(user *domain.User) {
+		user.Status = status
+		user.DeletedAt = nil
+		if status == domain.AccountStatusClosed {
+			closedAt := user.UpdatedAt
+			user.DeletedAt = &closedAt
+		}
+	})
+}
+
+func (r *UserRepository) UpdatePoints(userID int, newBalance int) error {
+	return r.update(userID, func(user *domain.User) { user.Points = newBalance })
+}
+
+func (r *UserRepository) UpdateMembershipLevel(userID int, level string) error {
+	return r.update(userID, func(user *domain.User) { user.MembershipLevel = level })
+}
+
+// update applies change to a stored user and bumps its version. Like an SQL
+// UPDATE, it does nothing if there is no such user.
+func (r *UserRepository) update(id int, change func(user *domain.User)) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	user, ok := d.users[id]
+	if !ok {
+		return nil
+	}
+	user.UpdatedAt = now()
+	change(&user)
+	user.Version++
+	d.users[id] = user
+	return nil
+}
+
+func (r *UserRepository) GetUserBalance(userID int) (int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	user, ok := d.users[userID]
+	if !ok {
+		return 0, fmt.Errorf("user %d not found", userID)
+	}
+	return user.Points, nil
+}
+
+func sortedUsers(d *data) []domain.User {
+	users := make([]domain.User, 0, len(d.users))
+	for _, user := range d.users {
+		users = append(users, user)
+	}
+	slices.SortFunc(users, func(a, b domain.User) int { return cmp.Compare(a.ID, b.ID) })
+	return users
+}
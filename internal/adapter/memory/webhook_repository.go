@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type WebhookRepository struct {
+	db conn
+}
+
+func NewWebhookRepository(store *Store) port.WebhookRepository {
+	return &WebhookRepository{db: store}
+}
+
+func (r *WebhookRepository) CreateSubscription(subscription *domain.WebhookSubscription) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	subscription.ID = d.nextID("webhook_subscriptions")
+	stored := *subscription
+	stored.EventTypes = slices.Clone(subscription.EventTypes)
+	stored.UserIDs = slices.Clone(subscription.UserIDs)
+	d.webhooks[stored.ID] = stored
+	return nil
+}
+
+func (r *WebhookRepository) GetSubscription(id int) (*domain.WebhookSubscription, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	subscription, ok := d.webhooks[id]
+	if !ok {
+		return nil, nil
+	}
+	subscription.EventTypes = slices.Clone(subscription.EventTypes)
+	subscription.UserIDs = slices.Clone(subscription.UserIDs)
+	return &subscription, nil
+}
+
+func (r *WebhookRepository) ListSubscriptions() ([]domain.WebhookSubscription, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	subscriptions := []domain.WebhookSubscription{}
+	for _, id := range slices.Sorted(maps.Keys(d.webhooks)) {
+		subscription := d.webhooks[id]
+		subscription.EventTypes = slices.Clone(subscription.EventTypes)
+		subscription.UserIDs = slices.Clone(subscription.UserIDs)
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(id int) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	delete(d.webhooks, id)
+	d.deliveries = slices.DeleteFunc(d.deliveries, func(delivery domain.WebhookDelivery) bool {
+		return delivery.SubscriptionID == id
+	})
+	return nil
+}
+
+func (r *WebhookRepository) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	for _, existing := range d.deliveries {
+		if existing.SubscriptionID == delivery.SubscriptionID && existing.EventID == delivery.EventID {
+			return nil
+		}
+	}
+	delivery.ID = d.nextID("webhook_deliveries")
+	stored := *delivery
+	stored.Body = slices.Clone(delivery.Body)
+	d.deliveries = append(d.deliveries, stored)
+	return nil
+}
+
+func (r *WebhookRepository) GetDelivery(id int) (*domain.WebhookDelivery, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	if i, ok := findDelivery(d.deliveries, id); ok {
+		delivery := d.deliveries[i]
+		return &delivery, nil
+	}
+	return nil, nil
+}
+
+func (r *WebhookRepository) GetDueDeliveries(asOf time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var deliveries []domain.WebhookDelivery
+	for _, delivery := range d.deliveries {
+		if len(deliveries) == limit {
+			break
+		}
+		if delivery.Status == domain.WebhookDeliveryPending && !delivery.NextAttemptAt.After(asOf) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	d, release := r.db.acquire()
+	defer release()
+
+	if i, ok := findDelivery(d.deliveries, delivery.ID); ok {
+		stored := &d.deliveries[i]
+		stored.Status = delivery.Status
+		stored.Attempts = delivery.Attempts
+		stored.ResponseStatus = delivery.ResponseStatus
+		stored.LastError = delivery.LastError
+		stored.NextAttemptAt = delivery.NextAttemptAt
+		stored.DeliveredAt = delivery.DeliveredAt
+	}
+	return nil
+}
+
+// ListDeliveries returns one page of the matching deliveries, newest first,
+// and the total number of matches.
+func (r *WebhookRepository) ListDeliveries(filter port.WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error) {
+	d, release := r.db.acquire()
+	defer release()
+
+	var matches []domain.WebhookDelivery
+	for i := len(d.deliveries) - 1; i >= 0; i-- {
+		delivery := d.deliveries[i]
+		switch {
+		case filter.SubscriptionID != 0 && delivery.SubscriptionID != filter.SubscriptionID,
+			filter.Status != "" && delivery.Status != filter.Status:
+			continue
+		}
+		matches = append(matches, delivery)
+	}
+	return pageOf(matches, filter.Page, filter.PageSize), len(matches), nil
+}
+
+// findDelivery finds a delivery by ID. Deliveries are appended in ID order,
+// so it can binary search.
+func findDelivery(deliveries []domain.WebhookDelivery, id int) (int, bool) {
+	return slices.BinarySearchFunc(deliveries, id, func(delivery domain.WebhookDelivery, id int) int { return delivery.ID - id })
+}
@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"database/sql"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresAccountStatusRepository struct {
+	db dbtx
+}
+
+func NewPostgresAccountStatusRepository(db *sql.DB) port.AccountStatusRepository {
+	return &PostgresAccountStatusRepository{db: db}
+}
+
+func (r *PostgresAccountStatusRepository) CreateHistory(change *domain.AccountStatusChange) error {
+	return r.db.QueryRow(`
+		INSERT INTO account_status_history (user_id, from_status, to_status, reason, actor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		change.UserID,
+		change.FromStatus,
+		change.ToStatus,
+		change.Reason,
+		change.Actor,
+		change.CreatedAt).
+		Scan(&change.ID)
+}
+
+func (r *PostgresAccountStatusRepository) GetHistoryByUserID(userID int) ([]domain.AccountStatusChange, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, from_status, to_status, reason, actor, created_at
+		FROM account_status_history
+		WHERE user_id = $1
+		ORDER BY id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []domain.AccountStatusChange{}
+	for rows.Next() {
+		var c domain.AccountStatusChange
+		if err := rows.Scan(&c.ID, &c.UserID, &c.FromStatus, &c.ToStatus, &c.Reason, &c.Actor, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.CreatedAt = c.CreatedAt.UTC()
+		history = append(history, c)
+	}
+
+	return history, rows.Err()
+}
@@ -0,0 +1,119 @@
+package adapter
+
+import (
+	"database/sql"
+	"strings"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresAuditRepository struct {
+	db dbtx
+}
+
+func NewPostgresAuditRepository(db *sql.DB) port.AuditRepository {
+	return &PostgresAuditRepository{db: db}
+}
+
+func (r *PostgresAuditRepository) Create(entry *domain.AuditEntry) error {
+	return r.db.QueryRow(`
+		INSERT INTO audit_log (actor, action, entity_type, entity_id, before_json, after_json, diff_json, request_id, ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		entry.Actor,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Before,
+		entry.After,
+		entry.Diff,
+		entry.RequestID,
+		entry.IP,
+		entry.CreatedAt).
+		Scan(&entry.ID)
+}
+
+func (r *PostgresAuditRepository) List(filter port.AuditFilter) ([]domain.AuditEntry, int, error) {
+	var conditions []string
+	var args pgArgs
+
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = "+args.add(filter.Actor))
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = "+args.add(filter.Action))
+	}
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = "+args.add(filter.EntityType))
+	}
+	if filter.EntityID != "" {
+		conditions = append(conditions, "entity_id = "+args.add(filter.EntityID))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= "+args.add(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at <= "+args.add(*filter.To))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM audit_log `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, actor, action, entity_type, entity_id, before_json, after_json, diff_json, request_id, ip, created_at
+		FROM audit_log ` + where + `
+		ORDER BY id DESC
+		LIMIT ` + args.add(filter.PageSize) + ` OFFSET ` + args.add((filter.Page-1)*filter.PageSize)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var before, after, diff, requestID, ip sql.NullString
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&before,
+			&after,
+			&diff,
+			&requestID,
+			&ip,
+			&entry.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		if before.Valid {
+			entry.Before = &before.String
+		}
+		if after.Valid {
+			entry.After = &after.String
+		}
+		if diff.Valid {
+			entry.Diff = &diff.String
+		}
+		entry.RequestID = requestID.String
+		entry.IP = ip.String
+
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
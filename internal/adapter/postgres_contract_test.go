@@ -0,0 +1,56 @@
+package adapter_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"workshop4-backend/internal/adapter"
+	"workshop4-backend/internal/app"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/port/porttest"
+)
+
+// TestPostgresRepositoryContract runs against the database named by
+// POSTGRES_TEST_DSN (see make test-postgres) and is skipped without it. It
+// empties every table, so never point it at data you want to keep.
+func TestPostgresRepositoryContract(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, app.CreatePostgresSchema(db))
+
+	porttest.RunRepositoryTests(t, func(t *testing.T) porttest.Backend {
+		_, err := db.Exec(`TRUNCATE journal_postings, journal_entries, point_lots, point_ledger, transfers,
+			transfer_confirmations, tier_history, account_status_history, audit_log, outbox_events,
+			webhook_deliveries, webhook_subscriptions, notification_preferences, sequences, users
+			RESTART IDENTITY CASCADE`)
+		require.NoError(t, err)
+
+		return porttest.Backend{
+			Repos: port.Repositories{
+				Users:         adapter.NewPostgresUserRepository(db).(port.UserRepositoryWithBalance),
+				Transfers:     adapter.NewPostgresTransferRepository(db),
+				Ledger:        adapter.NewPostgresPointLedgerRepository(db),
+				Journal:       adapter.NewPostgresJournalRepository(db),
+				Lots:          adapter.NewPostgresLotRepository(db),
+				Tiers:         adapter.NewPostgresTierRepository(db),
+				Sequences:     adapter.NewPostgresSequenceRepository(db),
+				Confirmations: adapter.NewPostgresTransferConfirmationRepository(db),
+				StatusHistory: adapter.NewPostgresAccountStatusRepository(db),
+				Outbox:        adapter.NewPostgresOutboxRepository(db),
+				Audit:         adapter.NewPostgresAuditRepository(db),
+			},
+			Webhooks:      adapter.NewPostgresWebhookRepository(db),
+			Notifications: adapter.NewPostgresNotificationPreferenceRepository(db),
+			Transactor:    adapter.NewPostgresTransactor(db),
+		}
+	})
+}
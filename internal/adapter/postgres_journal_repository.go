@@ -0,0 +1,136 @@
+package adapter
+
+import (
+	"database/sql"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresJournalRepository struct {
+	db dbtx
+}
+
+func NewPostgresJournalRepository(db *sql.DB) port.JournalRepository {
+	return &PostgresJournalRepository{db: db}
+}
+
+// Create inserts the entry and its postings. It must run inside the caller's
+// transaction so that an entry is never stored without all of its postings.
+func (r *PostgresJournalRepository) Create(entry *domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	err := r.db.QueryRow(`
+		INSERT INTO journal_entries (entry_type, transfer_id, reference, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		entry.EntryType,
+		entry.TransferID,
+		entry.Reference,
+		entry.CreatedAt).
+		Scan(&entry.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range entry.Postings {
+		posting := &entry.Postings[i]
+		posting.JournalEntryID = entry.ID
+		err := r.db.QueryRow(`
+			INSERT INTO journal_postings (journal_entry_id, account, amount)
+			VALUES ($1, $2, $3)
+			RETURNING id`,
+			posting.JournalEntryID, posting.Account, posting.Amount).
+			Scan(&posting.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PostgresJournalRepository) GetByTransferID(transferID int) ([]domain.JournalEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT e.id, e.entry_type, e.transfer_id, e.reference, e.created_at, p.id, p.account, p.amount
+		FROM journal_entries e
+		JOIN journal_postings p ON p.journal_entry_id = e.id
+		WHERE e.transfer_id = $1
+		ORDER BY e.id, p.id`, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.JournalEntry
+	for rows.Next() {
+		var entry domain.JournalEntry
+		var posting domain.Posting
+		var tid sql.NullInt64
+		var reference sql.NullString
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.EntryType,
+			&tid,
+			&reference,
+			&entry.CreatedAt,
+			&posting.ID,
+			&posting.Account,
+			&posting.Amount)
+		if err != nil {
+			return nil, err
+		}
+		posting.JournalEntryID = entry.ID
+
+		// Append to the current entry while rows belong to it
+		if n := len(entries); n > 0 && entries[n-1].ID == entry.ID {
+			entries[n-1].Postings = append(entries[n-1].Postings, posting)
+			continue
+		}
+
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		if tid.Valid {
+			id := int(tid.Int64)
+			entry.TransferID = &id
+		}
+		if reference.Valid {
+			entry.Reference = &reference.String
+		}
+		entry.Postings = []domain.Posting{posting}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *PostgresJournalRepository) HasPostings(account string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM journal_postings WHERE account = $1)`, account).Scan(&exists)
+	return exists, err
+}
+
+// GetAccountBalances orders accounts by code point, as SQLite does, rather
+// than by the database's locale.
+func (r *PostgresJournalRepository) GetAccountBalances() ([]domain.AccountBalance, error) {
+	rows, err := r.db.Query(`
+		SELECT account, SUM(amount) FROM journal_postings
+		GROUP BY account
+		ORDER BY account COLLATE "C"`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []domain.AccountBalance
+	for rows.Next() {
+		var balance domain.AccountBalance
+		if err := rows.Scan(&balance.Account, &balance.Balance); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
@@ -0,0 +1,87 @@
+package adapter
+
+import (
+	"database/sql"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresLotRepository struct {
+	db dbtx
+}
+
+func NewPostgresLotRepository(db *sql.DB) port.LotRepository {
+	return &PostgresLotRepository{db: db}
+}
+
+const lotColumns = `id, user_id, ledger_entry_id, amount, remaining, earned_at, expires_at`
+
+func (r *PostgresLotRepository) Create(lot *domain.PointLot) error {
+	return r.db.QueryRow(`
+		INSERT INTO point_lots (user_id, ledger_entry_id, amount, remaining, earned_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		lot.UserID,
+		lot.LedgerEntryID,
+		lot.Amount,
+		lot.Remaining,
+		lot.EarnedAt,
+		lot.ExpiresAt).
+		Scan(&lot.ID)
+}
+
+func (r *PostgresLotRepository) GetOpenByUserID(userID int) ([]domain.PointLot, error) {
+	return r.query(`
+		SELECT `+lotColumns+`
+		FROM point_lots
+		WHERE user_id = $1 AND remaining > 0
+		ORDER BY expires_at ASC, id ASC`, userID)
+}
+
+func (r *PostgresLotRepository) GetExpired(asOf time.Time) ([]domain.PointLot, error) {
+	return r.query(`
+		SELECT `+lotColumns+`
+		FROM point_lots
+		WHERE remaining > 0 AND expires_at <= $1
+		ORDER BY user_id ASC, expires_at ASC, id ASC`, asOf)
+}
+
+func (r *PostgresLotRepository) UpdateRemaining(id int, remaining int) error {
+	_, err := r.db.Exec(`UPDATE point_lots SET remaining = $1 WHERE id = $2`, remaining, id)
+	return err
+}
+
+func (r *PostgresLotRepository) query(query string, args ...interface{}) ([]domain.PointLot, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lots []domain.PointLot
+	for rows.Next() {
+		var lot domain.PointLot
+		var ledgerEntryID sql.NullInt64
+		err := rows.Scan(
+			&lot.ID,
+			&lot.UserID,
+			&ledgerEntryID,
+			&lot.Amount,
+			&lot.Remaining,
+			&lot.EarnedAt,
+			&lot.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		lot.EarnedAt = lot.EarnedAt.UTC()
+		lot.ExpiresAt = lot.ExpiresAt.UTC()
+		if ledgerEntryID.Valid {
+			id := int(ledgerEntryID.Int64)
+			lot.LedgerEntryID = &id
+		}
+		lots = append(lots, lot)
+	}
+	return lots, rows.Err()
+}
@@ -0,0 +1,63 @@
+package adapter
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresNotificationPreferenceRepository struct {
+	db dbtx
+}
+
+func NewPostgresNotificationPreferenceRepository(db *sql.DB) port.NotificationPreferenceRepository {
+	return &PostgresNotificationPreferenceRepository{db: db}
+}
+
+func (r *PostgresNotificationPreferenceRepository) Get(userID int) (*domain.NotificationPreferences, error) {
+	p := domain.NotificationPreferences{UserID: userID}
+	var muted string
+
+	err := r.db.QueryRow(`
+		SELECT email_enabled, sms_enabled, language, muted, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1`, userID).
+		Scan(&p.Email, &p.SMS, &p.Language, &muted, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(muted), &p.Muted); err != nil {
+		return nil, err
+	}
+	p.UpdatedAt = p.UpdatedAt.UTC()
+	return &p, nil
+}
+
+func (r *PostgresNotificationPreferenceRepository) Save(preferences *domain.NotificationPreferences) error {
+	muted, err := json.Marshal(preferences.Muted)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO notification_preferences (user_id, email_enabled, sms_enabled, language, muted, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email_enabled = excluded.email_enabled,
+			sms_enabled = excluded.sms_enabled,
+			language = excluded.language,
+			muted = excluded.muted,
+			updated_at = excluded.updated_at`,
+		preferences.UserID,
+		preferences.Email,
+		preferences.SMS,
+		preferences.Language,
+		string(muted),
+		preferences.UpdatedAt)
+	return err
+}
@@ -0,0 +1,176 @@
+package adapter
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresOutboxRepository struct {
+	db dbtx
+}
+
+func NewPostgresOutboxRepository(db *sql.DB) port.OutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+func (r *PostgresOutboxRepository) Create(event *domain.OutboxEvent) error {
+	return r.db.QueryRow(`
+		INSERT INTO outbox_events (event_name, aggregate_type, aggregate_id, payload, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		event.Name,
+		event.AggregateType,
+		event.AggregateID,
+		string(event.Payload),
+		event.Status,
+		event.Attempts,
+		event.NextAttemptAt,
+		event.CreatedAt).
+		Scan(&event.ID)
+}
+
+func (r *PostgresOutboxRepository) GetByID(id int) (*domain.OutboxEvent, error) {
+	events, err := r.queryEvents(`SELECT `+outboxEventColumns+` FROM outbox_events WHERE id = $1`, id)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return &events[0], nil
+}
+
+func (r *PostgresOutboxRepository) List(filter port.OutboxFilter) ([]domain.OutboxEvent, int, error) {
+	var conditions []string
+	var args pgArgs
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+args.add(filter.Status))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM outbox_events `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ` + outboxEventColumns + `
+		FROM outbox_events ` + where + `
+		ORDER BY id DESC
+		LIMIT ` + args.add(filter.PageSize) + ` OFFSET ` + args.add((filter.Page-1)*filter.PageSize)
+	events, err := r.queryEvents(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+func (r *PostgresOutboxRepository) ClaimDue(asOf, claimUntil time.Time, limit int) ([]domain.OutboxEvent, error) {
+	// SKIP LOCKED passes over events another dispatcher is claiming right
+	// now instead of waiting for it and then claiming them again
+	events, err := r.queryEvents(`
+		UPDATE outbox_events SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY id ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED)
+		RETURNING `+outboxEventColumns,
+		claimUntil, domain.OutboxEventPending, asOf, limit)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING does not follow the subquery's order
+	slices.SortFunc(events, func(a, b domain.OutboxEvent) int { return a.ID - b.ID })
+	return events, nil
+}
+
+func (r *PostgresOutboxRepository) MarkPublished(id int, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE outbox_events SET status = $1, published_at = $2 WHERE id = $3`,
+		domain.OutboxEventPublished, at, id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) MarkFailed(id int, lastError string, nextAttemptAt time.Time, publishedTo []string) error {
+	sinks, err := json.Marshal(publishedTo)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		UPDATE outbox_events SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2, published_to = $3
+		WHERE id = $4`, lastError, nextAttemptAt, string(sinks), id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) MarkDead(id int, lastError string, publishedTo []string) error {
+	sinks, err := json.Marshal(publishedTo)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		UPDATE outbox_events SET status = $1, attempts = attempts + 1, last_error = $2, published_to = $3
+		WHERE id = $4`, domain.OutboxEventDead, lastError, string(sinks), id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) Requeue(id int, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE outbox_events SET status = $1, attempts = 0, next_attempt_at = $2 WHERE id = $3`,
+		domain.OutboxEventPending, at, id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) queryEvents(query string, args ...interface{}) ([]domain.OutboxEvent, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.OutboxEvent{}
+	for rows.Next() {
+		var event domain.OutboxEvent
+		var payload string
+		var lastError, publishedTo sql.NullString
+		var publishedAt sql.NullTime
+		err := rows.Scan(
+			&event.ID,
+			&event.Name,
+			&event.AggregateType,
+			&event.AggregateID,
+			&payload,
+			&event.Status,
+			&event.Attempts,
+			&lastError,
+			&event.NextAttemptAt,
+			&publishedAt,
+			&event.CreatedAt,
+			&publishedTo)
+		if err != nil {
+			return nil, err
+		}
+
+		event.Payload = []byte(payload)
+		event.NextAttemptAt = event.NextAttemptAt.UTC()
+		event.CreatedAt = event.CreatedAt.UTC()
+		event.PublishedAt = utcPtr(publishedAt)
+		if lastError.Valid {
+			event.LastError = &lastError.String
+		}
+		if publishedTo.Valid {
+			if err := json.Unmarshal([]byte(publishedTo.String), &event.PublishedTo); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
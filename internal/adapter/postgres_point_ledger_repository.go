@@ -0,0 +1,171 @@
+package adapter
+
+import (
+	"database/sql"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresPointLedgerRepository struct {
+	db dbtx
+	// lock is forUpdate inside a transaction: reading a user's balance or
+	// chain head there locks the user's row until commit.
+	lock string
+}
+
+func NewPostgresPointLedgerRepository(db *sql.DB) port.PointLedgerRepository {
+	return &PostgresPointLedgerRepository{db: db}
+}
+
+const ledgerColumns = `id, user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at, prev_hash, hash`
+
+// lockUser locks the user's row inside a transaction, serializing writers
+// of the user's ledger.
+func (r *PostgresPointLedgerRepository) lockUser(userID int) error {
+	if r.lock == "" {
+		return nil
+	}
+	var id int
+	err := r.db.QueryRow(`SELECT id FROM users WHERE id = $1`+r.lock, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// Create appends entry to the user's hash chain.
+func (r *PostgresPointLedgerRepository) Create(entry *domain.PointLedger) error {
+	if err := r.lockUser(entry.UserID); err != nil {
+		return err
+	}
+	var prevHash sql.NullString
+	err := r.db.QueryRow(`SELECT hash FROM point_ledger WHERE user_id = $1 ORDER BY id DESC LIMIT 1`, entry.UserID).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	entry.PrevHash = prevHash.String
+	entry.Hash = entry.ComputeHash(entry.PrevHash)
+
+	return r.db.QueryRow(`
+		INSERT INTO point_ledger (user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		entry.UserID,
+		entry.Change,
+		entry.BalanceAfter,
+		entry.EventType,
+		entry.TransferID,
+		entry.Reference,
+		entry.Metadata,
+		entry.CreatedAt,
+		entry.PrevHash,
+		entry.Hash).
+		Scan(&entry.ID)
+}
+
+func (r *PostgresPointLedgerRepository) GetByUserID(userID int) ([]domain.PointLedger, error) {
+	return r.query(`SELECT `+ledgerColumns+` FROM point_ledger WHERE user_id = $1 ORDER BY created_at DESC, id DESC`, userID)
+}
+
+// GetChain returns the user's entries in insertion order, which is the order
+// the hash chain was built in.
+func (r *PostgresPointLedgerRepository) GetChain(userID int) ([]domain.PointLedger, error) {
+	return r.query(`SELECT `+ledgerColumns+` FROM point_ledger WHERE user_id = $1 ORDER BY id ASC`, userID)
+}
+
+func (r *PostgresPointLedgerRepository) GetUserIDs() ([]int, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT user_id FROM point_ledger ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func (r *PostgresPointLedgerRepository) GetEarnedSince(userID int, since time.Time) (int, error) {
+	var earned int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(change), 0) FROM point_ledger
+		WHERE user_id = $1 AND event_type = $2 AND created_at >= $3`,
+		userID, domain.EventTypeEarn, since).Scan(&earned)
+	return earned, err
+}
+
+// GetUserBalance reads the balance after the user's latest entry, falling
+// back to the user's starting points. Inside a transaction it locks the
+// user's row first, so a transfer's balance check holds until it commits.
+func (r *PostgresPointLedgerRepository) GetUserBalance(userID int) (int, error) {
+	if err := r.lockUser(userID); err != nil {
+		return 0, err
+	}
+	var balance int
+	err := r.db.QueryRow(`
+		SELECT balance_after FROM point_ledger
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`, userID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		// No ledger entries, check user table for initial points
+		err = r.db.QueryRow(`SELECT points FROM users WHERE id = $1`, userID).Scan(&balance)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+func (r *PostgresPointLedgerRepository) query(query string, args ...interface{}) ([]domain.PointLedger, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.PointLedger
+	for rows.Next() {
+		var entry domain.PointLedger
+		var transferID sql.NullInt64
+		var reference, metadata, prevHash, hash sql.NullString
+		err := rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.Change,
+			&entry.BalanceAfter,
+			&entry.EventType,
+			&transferID,
+			&reference,
+			&metadata,
+			&entry.CreatedAt,
+			&prevHash,
+			&hash)
+		if err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		if transferID.Valid {
+			tid := int(transferID.Int64)
+			entry.TransferID = &tid
+		}
+		if reference.Valid {
+			entry.Reference = &reference.String
+		}
+		if metadata.Valid {
+			entry.Metadata = &metadata.String
+		}
+		entry.PrevHash = prevHash.String
+		entry.Hash = hash.String
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,24 @@
+package adapter
+
+import (
+	"database/sql"
+
+	"workshop4-backend/internal/port"
+)
+
+type PostgresSequenceRepository struct {
+	db dbtx
+}
+
+func NewPostgresSequenceRepository(db *sql.DB) port.SequenceRepository {
+	return &PostgresSequenceRepository{db: db}
+}
+
+func (r *PostgresSequenceRepository) Next(name string) (int, error) {
+	var value int
+	err := r.db.QueryRow(`
+		INSERT INTO sequences (name, value) VALUES ($1, 1)
+		ON CONFLICT (name) DO UPDATE SET value = sequences.value + 1
+		RETURNING value`, name).Scan(&value)
+	return value, err
+}
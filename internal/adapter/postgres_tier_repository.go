@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"database/sql"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresTierRepository struct {
+	db dbtx
+}
+
+func NewPostgresTierRepository(db *sql.DB) port.TierRepository {
+	return &PostgresTierRepository{db: db}
+}
+
+func (r *PostgresTierRepository) CreateHistory(history *domain.TierHistory) error {
+	return r.db.QueryRow(`
+		INSERT INTO tier_history (user_id, from_level, to_level, earned_in_window, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		history.UserID,
+		history.FromLevel,
+		history.ToLevel,
+		history.EarnedInWindow,
+		history.Reason,
+		history.CreatedAt).
+		Scan(&history.ID)
+}
+
+func (r *PostgresTierRepository) GetHistoryByUserID(userID int) ([]domain.TierHistory, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, from_level, to_level, earned_in_window, reason, created_at
+		FROM tier_history
+		WHERE user_id = $1
+		ORDER BY id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []domain.TierHistory{}
+	for rows.Next() {
+		var h domain.TierHistory
+		if err := rows.Scan(&h.ID, &h.UserID, &h.FromLevel, &h.ToLevel, &h.EarnedInWindow, &h.Reason, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		h.CreatedAt = h.CreatedAt.UTC()
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
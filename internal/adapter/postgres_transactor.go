@@ -0,0 +1,75 @@
+package adapter
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"workshop4-backend/internal/port"
+)
+
+// pgNow is the current time at the precision timestamps are stored in.
+const pgNow = `date_trunc('second', now())`
+
+// forUpdate is appended to reads inside a transaction that must lock the
+// rows they return until commit.
+const forUpdate = ` FOR UPDATE`
+
+type PostgresTransactor struct {
+	db *sql.DB
+}
+
+func NewPostgresTransactor(db *sql.DB) port.Transactor {
+	return &PostgresTransactor{db: db}
+}
+
+// WithinTransaction runs fn in a READ COMMITTED transaction. Balance reads
+// inside it lock the user's row, so concurrent transfers from one account
+// queue behind each other instead of spending the same points.
+func (t *PostgresTransactor) WithinTransaction(fn func(repos port.Repositories) error) error {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	repos := port.Repositories{
+		Users:         &PostgresUserRepository{db: tx, lock: forUpdate},
+		Transfers:     &PostgresTransferRepository{db: tx},
+		Ledger:        &PostgresPointLedgerRepository{db: tx, lock: forUpdate},
+		Journal:       &PostgresJournalRepository{db: tx},
+		Lots:          &PostgresLotRepository{db: tx},
+		Tiers:         &PostgresTierRepository{db: tx},
+		Sequences:     &PostgresSequenceRepository{db: tx},
+		Confirmations: &PostgresTransferConfirmationRepository{db: tx},
+		StatusHistory: &PostgresAccountStatusRepository{db: tx},
+		Outbox:        &PostgresOutboxRepository{db: tx},
+		Audit:         &PostgresAuditRepository{db: tx},
+	}
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// pgArgs collects query arguments and hands out their $n placeholders.
+type pgArgs []interface{}
+
+func (a *pgArgs) add(value interface{}) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// utcPtr returns t in UTC, or nil when the column was NULL.
+func utcPtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
@@ -0,0 +1,74 @@
+package adapter
+
+import (
+	"database/sql"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresTransferConfirmationRepository struct {
+	db dbtx
+}
+
+func NewPostgresTransferConfirmationRepository(db *sql.DB) port.TransferConfirmationRepository {
+	return &PostgresTransferConfirmationRepository{db: db}
+}
+
+func (r *PostgresTransferConfirmationRepository) Create(confirmation *domain.TransferConfirmation) error {
+	_, err := r.db.Exec(`
+		INSERT INTO transfer_confirmations (id, from_user_id, to_user_id, recipient_name, amount, note, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		confirmation.ID,
+		confirmation.FromUserID,
+		confirmation.ToUserID,
+		confirmation.RecipientName,
+		confirmation.Amount,
+		confirmation.Note,
+		confirmation.CreatedAt,
+		confirmation.ExpiresAt)
+	return err
+}
+
+func (r *PostgresTransferConfirmationRepository) GetByID(id string) (*domain.TransferConfirmation, error) {
+	var c domain.TransferConfirmation
+	var note sql.NullString
+	var consumedAt sql.NullTime
+
+	err := r.db.QueryRow(`
+		SELECT id, from_user_id, to_user_id, recipient_name, amount, note, created_at, expires_at, consumed_at
+		FROM transfer_confirmations
+		WHERE id = $1`, id).
+		Scan(&c.ID, &c.FromUserID, &c.ToUserID, &c.RecipientName, &c.Amount, &note, &c.CreatedAt, &c.ExpiresAt, &consumedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.CreatedAt = c.CreatedAt.UTC()
+	c.ExpiresAt = c.ExpiresAt.UTC()
+	c.ConsumedAt = utcPtr(consumedAt)
+	if note.Valid {
+		c.Note = &note.String
+	}
+
+	return &c, nil
+}
+
+func (r *PostgresTransferConfirmationRepository) MarkConsumed(id string, at time.Time) error {
+	result, err := r.db.Exec(`UPDATE transfer_confirmations SET consumed_at = $1 WHERE id = $2 AND consumed_at IS NULL`, at, id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return port.ErrConfirmationConsumed
+	}
+	return nil
+}
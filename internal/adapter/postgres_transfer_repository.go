@@ -0,0 +1,116 @@
+package adapter
+
+import (
+	"database/sql"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresTransferRepository struct {
+	db dbtx
+}
+
+func NewPostgresTransferRepository(db *sql.DB) port.TransferRepository {
+	return &PostgresTransferRepository{db: db}
+}
+
+const transferColumns = `id, from_user_id, to_user_id, amount, status, note, idempotency_key, created_at, updated_at, completed_at, fail_reason`
+
+func scanPostgresTransfer(row interface{ Scan(...interface{}) error }, transfer *domain.Transfer) error {
+	var note, failReason sql.NullString
+	var completedAt sql.NullTime
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.FromUserID,
+		&transfer.ToUserID,
+		&transfer.Amount,
+		&transfer.Status,
+		&note,
+		&transfer.IdempotencyKey,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+		&completedAt,
+		&failReason)
+	if err != nil {
+		return err
+	}
+	transfer.CreatedAt = transfer.CreatedAt.UTC()
+	transfer.UpdatedAt = transfer.UpdatedAt.UTC()
+	transfer.CompletedAt = utcPtr(completedAt)
+	if note.Valid {
+		transfer.Note = &note.String
+	}
+	if failReason.Valid {
+		transfer.FailReason = &failReason.String
+	}
+	return nil
+}
+
+func (r *PostgresTransferRepository) Create(transfer *domain.Transfer) error {
+	return r.db.QueryRow(`
+		INSERT INTO transfers (from_user_id, to_user_id, amount, status, note, idempotency_key, created_at, updated_at, completed_at, fail_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		transfer.FromUserID,
+		transfer.ToUserID,
+		transfer.Amount,
+		transfer.Status,
+		transfer.Note,
+		transfer.IdempotencyKey,
+		transfer.CreatedAt,
+		transfer.UpdatedAt,
+		transfer.CompletedAt,
+		transfer.FailReason).
+		Scan(&transfer.ID)
+}
+
+func (r *PostgresTransferRepository) GetByIdempotencyKey(key string) (*domain.Transfer, error) {
+	var transfer domain.Transfer
+	err := scanPostgresTransfer(r.db.QueryRow(`SELECT `+transferColumns+` FROM transfers WHERE idempotency_key = $1`, key), &transfer)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *PostgresTransferRepository) GetByUserID(userID int, page, pageSize int) ([]domain.Transfer, int, error) {
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM transfers WHERE from_user_id = $1 OR to_user_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+transferColumns+`
+		FROM transfers
+		WHERE from_user_id = $1 OR to_user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`, userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var transfers []domain.Transfer
+	for rows.Next() {
+		var transfer domain.Transfer
+		if err := scanPostgresTransfer(rows, &transfer); err != nil {
+			return nil, 0, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers, total, rows.Err()
+}
+
+func (r *PostgresTransferRepository) UpdateStatus(id int, status domain.TransferStatus, completedAt *time.Time, failReason *string) error {
+	_, err := r.db.Exec(`
+		UPDATE transfers
+		SET status = $1, updated_at = `+pgNow+`, completed_at = $2, fail_reason = $3
+		WHERE id = $4`, status, completedAt, failReason, id)
+	return err
+}
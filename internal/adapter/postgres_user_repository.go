@@ -0,0 +1,244 @@
+package adapter
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresUserRepository struct {
+	db dbtx
+	// lock is forUpdate inside a transaction: reading a user there locks
+	// the row until commit.
+	lock string
+}
+
+func NewPostgresUserRepository(db *sql.DB) port.UserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+func scanPostgresUser(row interface{ Scan(...interface{}) error }, user *domain.User) error {
+	var deletedAt sql.NullTime
+	if err := row.Scan(&user.ID, &user.Name, &user.Phone, &user.Email, &user.MemberSince, &user.MembershipLevel, &user.MemberID, &user.Points, &user.CreatedAt, &user.UpdatedAt, &user.Version, &user.Status, &deletedAt); err != nil {
+		return err
+	}
+	user.CreatedAt = user.CreatedAt.UTC()
+	user.UpdatedAt = user.UpdatedAt.UTC()
+	user.DeletedAt = utcPtr(deletedAt)
+	return nil
+}
+
+func (r *PostgresUserRepository) GetAll() ([]domain.User, error) {
+	return r.query(`SELECT ` + userColumns + ` FROM users WHERE deleted_at IS NULL ORDER BY id`)
+}
+
+// postgresUserSortColumns orders names by code point, as SQLite does,
+// rather than by the database's locale.
+var postgresUserSortColumns = map[port.UserSort]string{
+	port.UserSortID:          "id",
+	port.UserSortName:        `name COLLATE "C"`,
+	port.UserSortPoints:      "points",
+	port.UserSortMemberSince: "member_since",
+	port.UserSortCreatedAt:   "created_at",
+}
+
+func (r *PostgresUserRepository) List(filter port.UserFilter) ([]domain.User, int, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args pgArgs
+
+	if filter.NamePrefix != "" {
+		conditions = append(conditions, "name ILIKE "+args.add(likeEscaper.Replace(filter.NamePrefix)+"%"))
+	}
+	if filter.MembershipLevel != "" {
+		conditions = append(conditions, "lower(membership_level) = lower("+args.add(filter.MembershipLevel)+")")
+	}
+	if filter.MemberSinceFrom != nil {
+		conditions = append(conditions, "member_since >= "+args.add(filter.MemberSinceFrom.Format(domain.DateLayout)))
+	}
+	if filter.MemberSinceTo != nil {
+		conditions = append(conditions, "member_since <= "+args.add(filter.MemberSinceTo.Format(domain.DateLayout)))
+	}
+	if filter.MinPoints != nil {
+		conditions = append(conditions, "points >= "+args.add(*filter.MinPoints))
+	}
+	if filter.MaxPoints != nil {
+		conditions = append(conditions, "points <= "+args.add(*filter.MaxPoints))
+	}
+
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM users `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := postgresUserSortColumns[filter.Sort]
+	if !ok {
+		order = "id"
+	}
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users ` + where +
+		` ORDER BY ` + order + ` ` + direction + `, id ` + direction +
+		` LIMIT ` + args.add(filter.PageSize) + ` OFFSET ` + args.add((filter.Page-1)*filter.PageSize)
+	users, err := r.query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if users == nil {
+		users = []domain.User{}
+	}
+	return users, total, nil
+}
+
+// Search matches every term anywhere in the name, email, phone or member ID,
+// ignoring case. Results are in ID order.
+func (r *PostgresUserRepository) Search(terms []string, limit int) ([]domain.User, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args pgArgs
+	for _, term := range terms {
+		pattern := args.add("%" + likeEscaper.Replace(term) + "%")
+		conditions = append(conditions, "(name ILIKE "+pattern+" OR email ILIKE "+pattern+" OR phone ILIKE "+pattern+" OR member_id ILIKE "+pattern+")")
+	}
+
+	users, err := r.query(`SELECT `+userColumns+` FROM users WHERE `+strings.Join(conditions, " AND ")+` ORDER BY id LIMIT `+args.add(limit), args...)
+	if users == nil && err == nil {
+		users = []domain.User{}
+	}
+	return users, err
+}
+
+func (r *PostgresUserRepository) GetByID(id int) (*domain.User, error) {
+	return r.getOne(`id = $1`, id)
+}
+
+func (r *PostgresUserRepository) GetByMemberID(memberID string) (*domain.User, error) {
+	return r.getOne(`member_id = $1`, memberID)
+}
+
+func (r *PostgresUserRepository) GetByPhone(phone string) (*domain.User, error) {
+	return r.getOne(`phone = $1 AND deleted_at IS NULL`, phone)
+}
+
+func (r *PostgresUserRepository) GetByEmail(email string) (*domain.User, error) {
+	return r.getOne(`email = $1 AND deleted_at IS NULL`, email)
+}
+
+func (r *PostgresUserRepository) getOne(condition string, arg interface{}) (*domain.User, error) {
+	var user domain.User
+	err := scanPostgresUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE `+condition+` ORDER BY id LIMIT 1`+r.lock, arg), &user)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *PostgresUserRepository) query(query string, args ...interface{}) ([]domain.User, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		if err := scanPostgresUser(rows, &user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *PostgresUserRepository) Create(user *domain.User) error {
+	if user.Status == "" {
+		user.Status = domain.AccountStatusActive
+	}
+	err := r.db.QueryRow(`
+		INSERT INTO users (name, phone, email, member_since, membership_level, member_id, points, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		user.Name, user.Phone, user.Email, user.MemberSince, user.MembershipLevel, user.MemberID, user.Points, user.Status, user.CreatedAt, user.UpdatedAt).
+		Scan(&user.ID)
+	if err != nil {
+		return postgresContactConflict(err)
+	}
+	user.Version = 1
+	return nil
+}
+
+func (r *PostgresUserRepository) Update(user *domain.User) error {
+	// member_id is assigned once at creation and never changes
+	result, err := r.db.Exec(`
+		UPDATE users SET name = $1, phone = $2, email = $3, member_since = $4, membership_level = $5, points = $6, updated_at = $7, version = version + 1
+		WHERE id = $8 AND version = $9`,
+		user.Name, user.Phone, user.Email, user.MemberSince, user.MembershipLevel, user.Points, user.UpdatedAt, user.ID, user.Version)
+	if err != nil {
+		return postgresContactConflict(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return port.ErrVersionConflict
+	}
+	user.Version++
+	return nil
+}
+
+func (r *PostgresUserRepository) UpdateStatus(id int, status domain.AccountStatus) error {
+	_, err := r.db.Exec(`
+		UPDATE users SET status = $1, deleted_at = CASE WHEN $1::text = 'closed' THEN `+pgNow+` END, updated_at = `+pgNow+`, version = version + 1
+		WHERE id = $2`, status, id)
+	return err
+}
+
+func (r *PostgresUserRepository) UpdatePoints(userID int, newBalance int) error {
+	_, err := r.db.Exec(`UPDATE users SET points = $1, updated_at = `+pgNow+`, version = version + 1 WHERE id = $2`, newBalance, userID)
+	return err
+}
+
+func (r *PostgresUserRepository) UpdateMembershipLevel(userID int, level string) error {
+	_, err := r.db.Exec(`UPDATE users SET membership_level = $1, updated_at = `+pgNow+`, version = version + 1 WHERE id = $2`, level, userID)
+	return err
+}
+
+// GetUserBalance reads the user's points, locking the row inside a
+// transaction so the balance cannot change before the caller writes.
+func (r *PostgresUserRepository) GetUserBalance(userID int) (int, error) {
+	var balance int
+	err := r.db.QueryRow(`SELECT points FROM users WHERE id = $1`+r.lock, userID).Scan(&balance)
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// postgresContactConflict maps a violation of the open-account phone and
+// email indexes to port.ErrPhoneTaken or port.ErrEmailTaken.
+func postgresContactConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return err
+	}
+	switch pgErr.ConstraintName {
+	case "idx_users_open_phone":
+		return port.ErrPhoneTaken
+	case "idx_users_open_email":
+		return port.ErrEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,227 @@
+package adapter
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type PostgresWebhookRepository struct {
+	db dbtx
+}
+
+func NewPostgresWebhookRepository(db *sql.DB) port.WebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+func (r *PostgresWebhookRepository) CreateSubscription(subscription *domain.WebhookSubscription) error {
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return err
+	}
+	userIDs, err := json.Marshal(subscription.UserIDs)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRow(`
+		INSERT INTO webhook_subscriptions (url, event_types, user_ids, secret, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		subscription.URL,
+		string(eventTypes),
+		string(userIDs),
+		subscription.Secret,
+		subscription.CreatedAt).
+		Scan(&subscription.ID)
+}
+
+func (r *PostgresWebhookRepository) GetSubscription(id int) (*domain.WebhookSubscription, error) {
+	subscriptions, err := r.querySubscriptions(`WHERE id = $1`, id)
+	if err != nil || len(subscriptions) == 0 {
+		return nil, err
+	}
+	return &subscriptions[0], nil
+}
+
+func (r *PostgresWebhookRepository) ListSubscriptions() ([]domain.WebhookSubscription, error) {
+	return r.querySubscriptions("")
+}
+
+func (r *PostgresWebhookRepository) querySubscriptions(where string, args ...interface{}) ([]domain.WebhookSubscription, error) {
+	rows, err := r.db.Query(`
+		SELECT id, url, event_types, user_ids, secret, created_at
+		FROM webhook_subscriptions `+where+`
+		ORDER BY id ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []domain.WebhookSubscription{}
+	for rows.Next() {
+		var subscription domain.WebhookSubscription
+		var eventTypes string
+		var userIDs sql.NullString
+		if err := rows.Scan(&subscription.ID, &subscription.URL, &eventTypes, &userIDs, &subscription.Secret, &subscription.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(eventTypes), &subscription.EventTypes); err != nil {
+			return nil, err
+		}
+		if userIDs.Valid {
+			if err := json.Unmarshal([]byte(userIDs.String), &subscription.UserIDs); err != nil {
+				return nil, err
+			}
+		}
+		subscription.CreatedAt = subscription.CreatedAt.UTC()
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) DeleteSubscription(id int) error {
+	if _, err := r.db.Exec(`DELETE FROM webhook_deliveries WHERE subscription_id = $1`, id); err != nil {
+		return err
+	}
+	_, err := r.db.Exec(`DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresWebhookRepository) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	err := r.db.QueryRow(`
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_name, body, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+		RETURNING id`,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventName,
+		string(delivery.Body),
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt).
+		Scan(&delivery.ID)
+	// Already queued
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+func (r *PostgresWebhookRepository) GetDelivery(id int) (*domain.WebhookDelivery, error) {
+	deliveries, err := r.queryDeliveries(`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+func (r *PostgresWebhookRepository) GetDueDeliveries(asOf time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	return r.queryDeliveries(`
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY id ASC
+		LIMIT $3`, domain.WebhookDeliveryPending, asOf, limit)
+}
+
+func (r *PostgresWebhookRepository) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_status = $3, last_error = $4, next_attempt_at = $5, delivered_at = $6
+		WHERE id = $7`,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.DeliveredAt,
+		delivery.ID)
+	return err
+}
+
+func (r *PostgresWebhookRepository) ListDeliveries(filter port.WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error) {
+	var conditions []string
+	var args pgArgs
+
+	if filter.SubscriptionID != 0 {
+		conditions = append(conditions, "subscription_id = "+args.add(filter.SubscriptionID))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+args.add(filter.Status))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries ` + where + `
+		ORDER BY id DESC
+		LIMIT ` + args.add(filter.PageSize) + ` OFFSET ` + args.add((filter.Page-1)*filter.PageSize)
+	deliveries, err := r.queryDeliveries(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (r *PostgresWebhookRepository) queryDeliveries(query string, args ...interface{}) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []domain.WebhookDelivery{}
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		var body string
+		var responseStatus sql.NullInt64
+		var lastError sql.NullString
+		var deliveredAt sql.NullTime
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&delivery.EventName,
+			&body,
+			&delivery.Status,
+			&delivery.Attempts,
+			&responseStatus,
+			&lastError,
+			&delivery.NextAttemptAt,
+			&deliveredAt,
+			&delivery.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.Body = []byte(body)
+		if responseStatus.Valid {
+			status := int(responseStatus.Int64)
+			delivery.ResponseStatus = &status
+		}
+		if lastError.Valid {
+			delivery.LastError = &lastError.String
+		}
+		delivery.NextAttemptAt = delivery.NextAttemptAt.UTC()
+		delivery.DeliveredAt = utcPtr(deliveredAt)
+		delivery.CreatedAt = delivery.CreatedAt.UTC()
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
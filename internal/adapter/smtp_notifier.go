@@ -0,0 +1,57 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// SMTPNotifier sends notifications as plain-text UTF-8 email. It
+// authenticates only when a username is given.
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPNotifier(host string, port int, username, password, from string) port.Notifier {
+	notifier := &SMTPNotifier{addr: net.JoinHostPort(host, strconv.Itoa(port)), from: from}
+	if username != "" {
+		notifier.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return notifier
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, notification domain.Notification) error {
+	message := emailMessage(n.from, notification.To, notification.Subject, notification.Body, time.Now())
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{notification.To}, message)
+}
+
+// emailMessage formats an RFC 5322 message. The subject and body are
+// encoded so Thai text survives servers that only pass ASCII.
+func emailMessage(from, to, subject, body string, date time.Time) []byte {
+	var b bytes.Buffer
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.Bytes()
+}
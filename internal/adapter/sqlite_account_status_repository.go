@@ -0,0 +1,66 @@
+package adapter
+
+import (
+	"database/sql"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type SqliteAccountStatusRepository struct {
+	db dbtx
+}
+
+func NewSqliteAccountStatusRepository(db *sql.DB) port.AccountStatusRepository {
+	return &SqliteAccountStatusRepository{db: db}
+}
+
+func (r *SqliteAccountStatusRepository) CreateHistory(change *domain.AccountStatusChange) error {
+	result, err := r.db.Exec(`
+		INSERT INTO account_status_history (user_id, from_status, to_status, reason, actor, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		change.UserID,
+		change.FromStatus,
+		change.ToStatus,
+		change.Reason,
+		change.Actor,
+		formatTimestamp(change.CreatedAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	change.ID = int(id)
+	return nil
+}
+
+func (r *SqliteAccountStatusRepository) GetHistoryByUserID(userID int) ([]domain.AccountStatusChange, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, from_status, to_status, reason, actor, created_at
+		FROM account_status_history
+		WHERE user_id = ?
+		ORDER BY id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []domain.AccountStatusChange{}
+	for rows.Next() {
+		var c domain.AccountStatusChange
+		var createdAtStr string
+		if err := rows.Scan(&c.ID, &c.UserID, &c.FromStatus, &c.ToStatus, &c.Reason, &c.Actor, &createdAtStr); err != nil {
+			return nil, err
+		}
+		if err := parseTimestamp(createdAtStr, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+	}
+
+	return history, rows.Err()
+}
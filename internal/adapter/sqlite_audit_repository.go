@@ -0,0 +1,146 @@
+package adapter
+
+import (
+	"database/sql"
+	"strings"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type SqliteAuditRepository struct {
+	db dbtx
+}
+
+func NewSqliteAuditRepository(db *sql.DB) port.AuditRepository {
+	return &SqliteAuditRepository{db: db}
+}
+
+func (r *SqliteAuditRepository) Create(entry *domain.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor, action, entity_type, entity_id, before_json, after_json, diff_json, request_id, ip, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.Exec(query,
+		entry.Actor,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Before,
+		entry.After,
+		entry.Diff,
+		entry.RequestID,
+		entry.IP,
+		formatTimestamp(entry.CreatedAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	entry.ID = int(id)
+	return nil
+}
+
+func (r *SqliteAuditRepository) List(filter port.AuditFilter) ([]domain.AuditEntry, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	// julianday normalizes the stored offsets before comparing
+	if filter.From != nil {
+		conditions = append(conditions, "julianday(created_at) >= julianday(?)")
+		args = append(args, formatTimestamp(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "julianday(created_at) <= julianday(?)")
+		args = append(args, formatTimestamp(*filter.To))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Get total count
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM audit_log `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results
+	query := `
+		SELECT id, actor, action, entity_type, entity_id, before_json, after_json, diff_json, request_id, ip, created_at
+		FROM audit_log ` + where + `
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`
+	offset := (filter.Page - 1) * filter.PageSize
+	rows, err := r.db.Query(query, append(args, filter.PageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var createdAtStr string
+		var before, after, diff, requestID, ip sql.NullString
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&before,
+			&after,
+			&diff,
+			&requestID,
+			&ip,
+			&createdAtStr)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if err := parseTimestamp(createdAtStr, &entry.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+
+		// Handle nullable fields
+		if before.Valid {
+			entry.Before = &before.String
+		}
+		if after.Valid {
+			entry.After = &after.String
+		}
+		if diff.Valid {
+			entry.Diff = &diff.String
+		}
+		entry.RequestID = requestID.String
+		entry.IP = ip.String
+
+		entries = append(entries, entry)
+	}
+
+	return entries, total, nil
+}
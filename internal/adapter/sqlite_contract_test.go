@@ -0,0 +1,88 @@
+package adapter_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workshop4-backend/internal/adapter"
+	"workshop4-backend/internal/app"
+	"workshop4-backend/internal/config"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/port/porttest"
+)
+
+func TestSqliteRepositoryContract(t *testing.T) {
+	porttest.RunRepositoryTests(t, func(t *testing.T) porttest.Backend {
+		cfg := config.Default()
+		cfg.Database.Path = filepath.Join(t.TempDir(), "users.db")
+		db := app.InitDatabase(cfg)
+		t.Cleanup(func() { db.Close() })
+
+		// InitDatabase seeds sample users; the contract starts empty
+		for _, table := range []string{
+			"journal_postings", "journal_entries", "point_lots", "point_ledger", "transfers",
+			"transfer_confirmations", "tier_history", "account_status_history", "audit_log", "outbox_events",
+			"webhook_deliveries", "webhook_subscriptions", "notification_preferences", "sequences", "users",
+		} {
+			_, err := db.Exec("DELETE FROM " + table)
+			require.NoError(t, err)
+		}
+		_, err := adapter.RebuildUserSearchIndex(db)
+		require.NoError(t, err)
+
+		return porttest.Backend{
+			Repos: port.Repositories{
+				Users:         adapter.NewSqliteUserRepository(db).(port.UserRepositoryWithBalance),
+				Transfers:     adapter.NewSqliteTransferRepository(db),
+				Ledger:        adapter.NewSqlitePointLedgerRepository(db),
+				Journal:       adapter.NewSqliteJournalRepository(db),
+				Lots:          adapter.NewSqliteLotRepository(db),
+				Tiers:         adapter.NewSqliteTierRepository(db),
+				Sequences:     adapter.NewSqliteSequenceRepository(db),
+				Confirmations: adapter.NewSqliteTransferConfirmationRepository(db),
+				StatusHistory: adapter.NewSqliteAccountStatusRepository(db),
+				Outbox:        adapter.NewSqliteOutboxRepository(db),
+				Audit:         adapter.NewSqliteAuditRepository(db),
+			},
+			Webhooks:      adapter.NewSqliteWebhookRepository(db),
+			Notifications: adapter.NewSqliteNotificationPreferenceRepository(db),
+			Transactor:    adapter.NewSqliteTransactor(db),
+		}
+	})
+}
+
+func TestFindDuplicateContacts(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Path = filepath.Join(t.TempDir(), "users.db")
+	db := app.InitDatabase(cfg)
+
+	// Rows from before normalization can collide once normalized
+	_, err := db.Exec("DROP INDEX idx_users_open_phone")
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM users")
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO users (id, name, phone, email, deleted_at) VALUES
+		(1, 'A', '081-234-5678', 'a@example.com', NULL),
+		(2, 'B', '+66812345678', 'b@example.com', NULL),
+		(3, 'C', '0812345678', 'c@example.com', '2024-06-01T09:00:00Z'),
+		(4, 'D', NULL, 'd@example.com', NULL),
+		(5, 'E', NULL, 'e@example.com', NULL)`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// Reopening normalizes the phones and keeps going without the index
+	db = app.InitDatabase(cfg)
+	t.Cleanup(func() { db.Close() })
+
+	duplicates, err := adapter.FindDuplicateContacts(db)
+	require.NoError(t, err)
+	assert.Equal(t, []adapter.DuplicateContact{{Field: "phone", UserIDs: []int{1, 2}}}, duplicates)
+
+	var indexes int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'index' AND name IN ('idx_users_open_phone', 'idx_users_open_email')`).Scan(&indexes))
+	assert.Equal(t, 1, indexes)
+}
@@ -0,0 +1,203 @@
+package adapter
+
+import (
+	"database/sql"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type SqliteJournalRepository struct {
+	db dbtx
+}
+
+func NewSqliteJournalRepository(db *sql.DB) port.JournalRepository {
+	return &SqliteJournalRepository{db: db}
+}
+
+// Create inserts the entry and its postings. It must run inside the caller's
+// transaction so that an entry is never stored without all of its postings.
+func (r *SqliteJournalRepository) Create(entry *domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO journal_entries (entry_type, transfer_id, reference, created_at)
+		VALUES (?, ?, ?, ?)`,
+		entry.EntryType,
+		entry.TransferID,
+		entry.Reference,
+		formatTimestamp(entry.CreatedAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = int(id)
+
+	for i := range entry.Postings {
+		posting := &entry.Postings[i]
+		posting.JournalEntryID = entry.ID
+		result, err := r.db.Exec(`
+			INSERT INTO journal_postings (journal_entry_id, account, amount)
+			VALUES (?, ?, ?)`,
+			posting.JournalEntryID, posting.Account, posting.Amount)
+		if err != nil {
+			return err
+		}
+		postingID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		posting.ID = int(postingID)
+	}
+
+	return nil
+}
+
+func (r *SqliteJournalRepository) GetByTransferID(transferID int) ([]domain.JournalEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT e.id, e.entry_type, e.transfer_id, e.reference, e.created_at, p.id, p.account, p.amount
+		FROM journal_entries e
+		JOIN journal_postings p ON p.journal_entry_id = e.id
+		WHERE e.transfer_id = ?
+		ORDER BY e.id, p.id`, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.JournalEntry
+	for rows.Next() {
+		var entry domain.JournalEntry
+		var posting domain.Posting
+		var createdAtStr string
+		var tid sql.NullInt64
+		var reference sql.NullString
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.EntryType,
+			&tid,
+			&reference,
+			&createdAtStr,
+			&posting.ID,
+			&posting.Account,
+			&posting.Amount)
+		if err != nil {
+			return nil, err
+		}
+		posting.JournalEntryID = entry.ID
+
+		// Append to the current entry while rows belong to it
+		if n := len(entries); n > 0 && entries[n-1].ID == entry.ID {
+			entries[n-1].Postings = append(entries[n-1].Postings, posting)
+			continue
+		}
+
+		if err := parseTimestamp(createdAtStr, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		if tid.Valid {
+			id := int(tid.Int64)
+			entry.TransferID = &id
+		}
+		if reference.Valid {
+			entry.Reference = &reference.String
+		}
+		entry.Postings = []domain.Posting{posting}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *SqliteJournalRepository) HasPostings(account string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM journal_postings WHERE account = ?)`, account).Scan(&exists)
+	return exists, err
+}
+
+func (r *SqliteJournalRepository) GetAccountBalances() ([]domain.AccountBalance, error) {
+	rows, err := r.db.Query(`
+		SELECT account, SUM(amount) FROM journal_postings
+		GROUP BY account
+		ORDER BY account`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []domain.AccountBalance
+	for rows.Next() {
+		var balance domain.AccountBalance
+		if err := rows.Scan(&balance.Account, &balance.Balance); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// BackfillOpeningBalances books an opening journal entry for every member
+// whose points predate the journal, so member accounts reconcile with their
+// ledger balances. Members that already have postings are skipped.
+func BackfillOpeningBalances(db *sql.DB) (int, error) {
+	userIDs, err := queryUserIDs(db)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	err = NewSqliteTransactor(db).WithinTransaction(func(repos port.Repositories) error {
+		for _, userID := range userIDs {
+			account := domain.MemberAccount(userID)
+			hasPostings, err := repos.Journal.HasPostings(account)
+			if err != nil {
+				return err
+			}
+			if hasPostings {
+				continue
+			}
+
+			balance, err := repos.Ledger.GetUserBalance(userID)
+			if err != nil {
+				return err
+			}
+			if balance == 0 {
+				continue
+			}
+
+			entry := domain.NewJournalEntry(domain.JournalEntryOpening, domain.AccountIssuance, account, balance, time.Now())
+			if err := repos.Journal.Create(entry); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	return created, err
+}
+
+func queryUserIDs(db *sql.DB) ([]int, error) {
+	rows, err := db.Query(`SELECT id FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,156 @@
+package adapter
+
+import (
+	"database/sql"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type SqliteLotRepository struct {
+	db dbtx
+}
+
+func NewSqliteLotRepository(db *sql.DB) port.LotRepository {
+	return &SqliteLotRepository{db: db}
+}
+
+func (r *SqliteLotRepository) Create(lot *domain.PointLot) error {
+	result, err := r.db.Exec(`
+		INSERT INTO point_lots (user_id, ledger_entry_id, amount, remaining, earned_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		lot.UserID,
+		lot.LedgerEntryID,
+		lot.Amount,
+		lot.Remaining,
+		formatTimestamp(lot.EarnedAt),
+		formatTimestamp(lot.ExpiresAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	lot.ID = int(id)
+	return nil
+}
+
+func (r *SqliteLotRepository) GetOpenByUserID(userID int) ([]domain.PointLot, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, ledger_entry_id, amount, remaining, earned_at, expires_at
+		FROM point_lots
+		WHERE user_id = ? AND remaining > 0
+		ORDER BY expires_at ASC, id ASC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLots(rows)
+}
+
+func (r *SqliteLotRepository) GetExpired(asOf time.Time) ([]domain.PointLot, error) {
+	// expires_at is always stored in UTC so string comparison orders correctly
+	rows, err := r.db.Query(`
+		SELECT id, user_id, ledger_entry_id, amount, remaining, earned_at, expires_at
+		FROM point_lots
+		WHERE remaining > 0 AND expires_at <= ?
+		ORDER BY user_id ASC, expires_at ASC, id ASC`, formatTimestamp(asOf))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLots(rows)
+}
+
+func (r *SqliteLotRepository) UpdateRemaining(id int, remaining int) error {
+	_, err := r.db.Exec(`UPDATE point_lots SET remaining = ? WHERE id = ?`, remaining, id)
+	return err
+}
+
+func scanLots(rows *sql.Rows) ([]domain.PointLot, error) {
+	var lots []domain.PointLot
+	for rows.Next() {
+		var lot domain.PointLot
+		var ledgerEntryID sql.NullInt64
+		var earnedAtStr, expiresAtStr string
+
+		err := rows.Scan(
+			&lot.ID,
+			&lot.UserID,
+			&ledgerEntryID,
+			&lot.Amount,
+			&lot.Remaining,
+			&earnedAtStr,
+			&expiresAtStr)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := parseTimestamp(earnedAtStr, &lot.EarnedAt); err != nil {
+			return nil, err
+		}
+		if err := parseTimestamp(expiresAtStr, &lot.ExpiresAt); err != nil {
+			return nil, err
+		}
+		if ledgerEntryID.Valid {
+			id := int(ledgerEntryID.Int64)
+			lot.LedgerEntryID = &id
+		}
+
+		lots = append(lots, lot)
+	}
+	return lots, rows.Err()
+}
+
+// BackfillLots gives every member's untracked points (balance not covered by
+// open lots) a lot expiring under policy, so points that predate lot
+// tracking expire like newly earned ones.
+func BackfillLots(db *sql.DB, policy domain.ExpiryPolicy) (int, error) {
+	userIDs, err := queryUserIDs(db)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	err = NewSqliteTransactor(db).WithinTransaction(func(repos port.Repositories) error {
+		now := time.Now()
+		for _, userID := range userIDs {
+			balance, err := repos.Ledger.GetUserBalance(userID)
+			if err != nil {
+				return err
+			}
+			lots, err := repos.Lots.GetOpenByUserID(userID)
+			if err != nil {
+				return err
+			}
+
+			untracked := balance
+			for _, lot := range lots {
+				untracked -= lot.Remaining
+			}
+			if untracked <= 0 {
+				continue
+			}
+
+			lot := &domain.PointLot{
+				UserID:    userID,
+				Amount:    untracked,
+				Remaining: untracked,
+				EarnedAt:  now,
+				ExpiresAt: policy.ExpiresAt(now),
+			}
+			if err := repos.Lots.Create(lot); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	return created, err
+}
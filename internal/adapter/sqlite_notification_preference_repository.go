@@ -0,0 +1,65 @@
+package adapter
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type SqliteNotificationPreferenceRepository struct {
+	db dbtx
+}
+
+func NewSqliteNotificationPreferenceRepository(db *sql.DB) port.NotificationPreferenceRepository {
+	return &SqliteNotificationPreferenceRepository{db: db}
+}
+
+func (r *SqliteNotificationPreferenceRepository) Get(userID int) (*domain.NotificationPreferences, error) {
+	p := domain.NotificationPreferences{UserID: userID}
+	var muted, updatedAtStr string
+
+	err := r.db.QueryRow(`
+		SELECT email_enabled, sms_enabled, language, muted, updated_at
+		FROM notification_preferences
+		WHERE user_id = ?`, userID).
+		Scan(&p.Email, &p.SMS, &p.Language, &muted, &updatedAtStr)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(muted), &p.Muted); err != nil {
+		return nil, err
+	}
+	if err := parseTimestamp(updatedAtStr, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *SqliteNotificationPreferenceRepository) Save(preferences *domain.NotificationPreferences) error {
+	muted, err := json.Marshal(preferences.Muted)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO notification_preferences (user_id, email_enabled, sms_enabled, language, muted, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email_enabled = excluded.email_enabled,
+			sms_enabled = excluded.sms_enabled,
+			language = excluded.language,
+			muted = excluded.muted,
+			updated_at = excluded.updated_at`,
+		preferences.UserID,
+		preferences.Email,
+		preferences.SMS,
+		preferences.Language,
+		string(muted),
+		formatTimestamp(preferences.UpdatedAt))
+	return err
+}
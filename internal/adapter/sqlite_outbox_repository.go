@@ -0,0 +1,196 @@
+package adapter
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+const outboxEventColumns = `id, event_name, aggregate_type, aggregate_id, payload, status, attempts, last_error,
+	next_attempt_at, published_at, created_at, published_to`
+
+type SqliteOutboxRepository struct {
+	db dbtx
+}
+
+func NewSqliteOutboxRepository(db *sql.DB) port.OutboxRepository {
+	return &SqliteOutboxRepository{db: db}
+}
+
+func (r *SqliteOutboxRepository) Create(event *domain.OutboxEvent) error {
+	result, err := r.db.Exec(`
+		INSERT INTO outbox_events (event_name, aggregate_type, aggregate_id, payload, status, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.Name,
+		event.AggregateType,
+		event.AggregateID,
+		string(event.Payload),
+		event.Status,
+		event.Attempts,
+		formatTimestamp(event.NextAttemptAt),
+		formatTimestamp(event.CreatedAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	event.ID = int(id)
+	return nil
+}
+
+func (r *SqliteOutboxRepository) GetByID(id int) (*domain.OutboxEvent, error) {
+	events, err := r.queryEvents(`SELECT `+outboxEventColumns+` FROM outbox_events WHERE id = ?`, id)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return &events[0], nil
+}
+
+func (r *SqliteOutboxRepository) List(filter port.OutboxFilter) ([]domain.OutboxEvent, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM outbox_events `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	events, err := r.queryEvents(`
+		SELECT `+outboxEventColumns+`
+		FROM outbox_events `+where+`
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?`, append(args, filter.PageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+func (r *SqliteOutboxRepository) ClaimDue(asOf, claimUntil time.Time, limit int) ([]domain.OutboxEvent, error) {
+	// SQLite runs one writer at a time, so selecting and postponing the
+	// events in one UPDATE claims them. next_attempt_at is always stored in
+	// UTC so string comparison orders correctly.
+	events, err := r.queryEvents(`
+		UPDATE outbox_events SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY id ASC
+			LIMIT ?)
+		RETURNING `+outboxEventColumns,
+		formatTimestamp(claimUntil), domain.OutboxEventPending, formatTimestamp(asOf), limit)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING does not follow the subquery's order
+	slices.SortFunc(events, func(a, b domain.OutboxEvent) int { return a.ID - b.ID })
+	return events, nil
+}
+
+func (r *SqliteOutboxRepository) MarkPublished(id int, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE outbox_events SET status = ?, published_at = ? WHERE id = ?`,
+		domain.OutboxEventPublished, formatTimestamp(at), id)
+	return err
+}
+
+func (r *SqliteOutboxRepository) MarkFailed(id int, lastError string, nextAttemptAt time.Time, publishedTo []string) error {
+	sinks, err := json.Marshal(publishedTo)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		UPDATE outbox_events SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?, published_to = ?
+		WHERE id = ?`, lastError, formatTimestamp(nextAttemptAt), string(sinks), id)
+	return err
+}
+
+func (r *SqliteOutboxRepository) MarkDead(id int, lastError string, publishedTo []string) error {
+	sinks, err := json.Marshal(publishedTo)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		UPDATE outbox_events SET status = ?, attempts = attempts + 1, last_error = ?, published_to = ?
+		WHERE id = ?`, domain.OutboxEventDead, lastError, string(sinks), id)
+	return err
+}
+
+func (r *SqliteOutboxRepository) Requeue(id int, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE outbox_events SET status = ?, attempts = 0, next_attempt_at = ? WHERE id = ?`,
+		domain.OutboxEventPending, formatTimestamp(at), id)
+	return err
+}
+
+func (r *SqliteOutboxRepository) queryEvents(query string, args ...interface{}) ([]domain.OutboxEvent, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.OutboxEvent{}
+	for rows.Next() {
+		var event domain.OutboxEvent
+		var payload, nextAttemptAtStr, createdAtStr string
+		var lastError, publishedAtStr, publishedTo sql.NullString
+		err := rows.Scan(
+			&event.ID,
+			&event.Name,
+			&event.AggregateType,
+			&event.AggregateID,
+			&payload,
+			&event.Status,
+			&event.Attempts,
+			&lastError,
+			&nextAttemptAtStr,
+			&publishedAtStr,
+			&createdAtStr,
+			&publishedTo)
+		if err != nil {
+			return nil, err
+		}
+
+		event.Payload = []byte(payload)
+		if err := parseTimestamp(nextAttemptAtStr, &event.NextAttemptAt); err != nil {
+			return nil, err
+		}
+		if publishedAtStr.Valid {
+			if err := parseTimestampPtr(publishedAtStr.String, &event.PublishedAt); err != nil {
+				return nil, err
+			}
+		}
+		if err := parseTimestamp(createdAtStr, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		if lastError.Valid {
+			event.LastError = &lastError.String
+		}
+		if publishedTo.Valid {
+			if err := json.Unmarshal([]byte(publishedTo.String), &event.PublishedTo); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
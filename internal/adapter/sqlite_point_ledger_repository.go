@@ -9,17 +9,28 @@ import (
 )
 
 type SqlitePointLedgerRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewSqlitePointLedgerRepository(db *sql.DB) port.PointLedgerRepository {
 	return &SqlitePointLedgerRepository{db: db}
 }
 
+// Create appends entry to the user's hash chain. The previous hash is read
+// from the user's latest entry, so writers for the same user must be
+// serialized by the caller's transaction.
 func (r *SqlitePointLedgerRepository) Create(entry *domain.PointLedger) error {
+	var prevHash sql.NullString
+	err := r.db.QueryRow(`SELECT hash FROM point_ledger WHERE user_id = ? ORDER BY id DESC LIMIT 1`, entry.UserID).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	entry.PrevHash = prevHash.String
+	entry.Hash = entry.ComputeHash(entry.PrevHash)
+
 	query := `
-		INSERT INTO point_ledger (user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO point_ledger (user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	result, err := r.db.Exec(query,
 		entry.UserID,
@@ -29,7 +40,9 @@ func (r *SqlitePointLedgerRepository) Create(entry *domain.PointLedger) error {
 		entry.TransferID,
 		entry.Reference,
 		entry.Metadata,
-		entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"))
+		formatTimestamp(entry.CreatedAt),
+		entry.PrevHash,
+		entry.Hash)
 	if err != nil {
 		return err
 	}
@@ -45,8 +58,25 @@ func (r *SqlitePointLedgerRepository) Create(entry *domain.PointLedger) error {
 
 func (r *SqlitePointLedgerRepository) GetByUserID(userID int) ([]domain.PointLedger, error) {
 	query := `
-		SELECT id, user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at
-		FROM point_ledger WHERE user_id = ? ORDER BY created_at DESC
+		SELECT id, user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at, prev_hash, hash
+		FROM point_ledger WHERE user_id = ? ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLedgerEntries(rows)
+}
+
+// GetChain returns the user's entries in insertion order, which is the order
+// the hash chain was built in.
+func (r *SqlitePointLedgerRepository) GetChain(userID int) ([]domain.PointLedger, error) {
+	query := `
+		SELECT id, user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at, prev_hash, hash
+		FROM point_ledger WHERE user_id = ? ORDER BY id ASC
 	`
 
 	rows, err := r.db.Query(query, userID)
@@ -55,12 +85,105 @@ func (r *SqlitePointLedgerRepository) GetByUserID(userID int) ([]domain.PointLed
 	}
 	defer rows.Close()
 
+	return scanLedgerEntries(rows)
+}
+
+func (r *SqlitePointLedgerRepository) GetUserIDs() ([]int, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT user_id FROM point_ledger ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func (r *SqlitePointLedgerRepository) GetEarnedSince(userID int, since time.Time) (int, error) {
+	// julianday normalizes the stored offsets before comparing
+	query := `
+		SELECT COALESCE(SUM(change), 0) FROM point_ledger
+		WHERE user_id = ? AND event_type = ? AND julianday(created_at) >= julianday(?)
+	`
+
+	var earned int
+	err := r.db.QueryRow(query, userID, domain.EventTypeEarn, formatTimestamp(since)).Scan(&earned)
+	return earned, err
+}
+
+func (r *SqlitePointLedgerRepository) GetUserBalance(userID int) (int, error) {
+	query := `
+		SELECT balance_after FROM point_ledger
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	var balance int
+	err := r.db.QueryRow(query, userID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		// No ledger entries, check user table for initial points
+		userQuery := `SELECT points FROM users WHERE id = ?`
+		err = r.db.QueryRow(userQuery, userID).Scan(&balance)
+		if err != nil {
+			return 0, err
+		}
+		return balance, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// BackfillLedgerHashes computes hashes for entries written before the hash
+// chain existed. It is safe to run repeatedly; entries that already carry a
+// hash are left untouched and only extend the chain.
+func BackfillLedgerHashes(db *sql.DB) (int, error) {
+	rows, err := db.Query(`
+		SELECT id, user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at, prev_hash, hash
+		FROM point_ledger ORDER BY id ASC
+	`)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := scanLedgerEntries(rows)
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	lastHash := map[int]string{}
+	updated := 0
+	for _, entry := range entries {
+		if entry.Hash == "" {
+			entry.PrevHash = lastHash[entry.UserID]
+			entry.Hash = entry.ComputeHash(entry.PrevHash)
+			if _, err := db.Exec(`UPDATE point_ledger SET prev_hash = ?, hash = ? WHERE id = ?`, entry.PrevHash, entry.Hash, entry.ID); err != nil {
+				return updated, err
+			}
+			updated++
+		}
+		lastHash[entry.UserID] = entry.Hash
+	}
+	return updated, nil
+}
+
+func scanLedgerEntries(rows *sql.Rows) ([]domain.PointLedger, error) {
 	var entries []domain.PointLedger
 	for rows.Next() {
 		var entry domain.PointLedger
 		var createdAtStr string
 		var transferID sql.NullInt64
-		var reference, metadata sql.NullString
+		var reference, metadata, prevHash, hash sql.NullString
 
 		err := rows.Scan(
 			&entry.ID,
@@ -71,14 +194,14 @@ func (r *SqlitePointLedgerRepository) GetByUserID(userID int) ([]domain.PointLed
 			&transferID,
 			&reference,
 			&metadata,
-			&createdAtStr)
+			&createdAtStr,
+			&prevHash,
+			&hash)
 		if err != nil {
 			return nil, err
 		}
 
-		// Parse time string
-		entry.CreatedAt, err = time.Parse("2006-01-02T15:04:05Z07:00", createdAtStr)
-		if err != nil {
+		if err := parseTimestamp(createdAtStr, &entry.CreatedAt); err != nil {
 			return nil, err
 		}
 
@@ -93,35 +216,11 @@ func (r *SqlitePointLedgerRepository) GetByUserID(userID int) ([]domain.PointLed
 		if metadata.Valid {
 			entry.Metadata = &metadata.String
 		}
+		entry.PrevHash = prevHash.String
+		entry.Hash = hash.String
 
 		entries = append(entries, entry)
 	}
 
-	return entries, nil
-}
-
-func (r *SqlitePointLedgerRepository) GetUserBalance(userID int) (int, error) {
-	query := `
-		SELECT balance_after FROM point_ledger
-		WHERE user_id = ?
-		ORDER BY created_at DESC
-		LIMIT 1
-	`
-
-	var balance int
-	err := r.db.QueryRow(query, userID).Scan(&balance)
-	if err == sql.ErrNoRows {
-		// No ledger entries, check user table for initial points
-		userQuery := `SELECT points FROM users WHERE id = ?`
-		err = r.db.QueryRow(userQuery, userID).Scan(&balance)
-		if err != nil {
-			return 0, err
-		}
-		return balance, nil
-	}
-	if err != nil {
-		return 0, err
-	}
-
-	return balance, nil
+	return entries, rows.Err()
 }
@@ -0,0 +1,24 @@
+package adapter
+
+import (
+	"database/sql"
+
+	"workshop4-backend/internal/port"
+)
+
+type SqliteSequenceRepository struct {
+	db dbtx
+}
+
+func NewSqliteSequenceRepository(db *sql.DB) port.SequenceRepository {
+	return &SqliteSequenceRepository{db: db}
+}
+
+func (r *SqliteSequenceRepository) Next(name string) (int, error) {
+	var value int
+	err := r.db.QueryRow(`
+		INSERT INTO sequences (name, value) VALUES (?, 1)
+		ON CONFLICT(name) DO UPDATE SET value = value + 1
+		RETURNING value`, name).Scan(&value)
+	return value, err
+}
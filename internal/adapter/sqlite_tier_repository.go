@@ -0,0 +1,66 @@
+package adapter
+
+import (
+	"database/sql"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type SqliteTierRepository struct {
+	db dbtx
+}
+
+func NewSqliteTierRepository(db *sql.DB) port.TierRepository {
+	return &SqliteTierRepository{db: db}
+}
+
+func (r *SqliteTierRepository) CreateHistory(history *domain.TierHistory) error {
+	result, err := r.db.Exec(`
+		INSERT INTO tier_history (user_id, from_level, to_level, earned_in_window, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		history.UserID,
+		history.FromLevel,
+		history.ToLevel,
+		history.EarnedInWindow,
+		history.Reason,
+		formatTimestamp(history.CreatedAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	history.ID = int(id)
+	return nil
+}
+
+func (r *SqliteTierRepository) GetHistoryByUserID(userID int) ([]domain.TierHistory, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, from_level, to_level, earned_in_window, reason, created_at
+		FROM tier_history
+		WHERE user_id = ?
+		ORDER BY id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []domain.TierHistory{}
+	for rows.Next() {
+		var h domain.TierHistory
+		var createdAtStr string
+		if err := rows.Scan(&h.ID, &h.UserID, &h.FromLevel, &h.ToLevel, &h.EarnedInWindow, &h.Reason, &createdAtStr); err != nil {
+			return nil, err
+		}
+		if err := parseTimestamp(createdAtStr, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"database/sql"
+	"fmt"
+
+	"workshop4-backend/internal/port"
+)
+
+// dbtx is the subset of *sql.DB and *sql.Tx used by the repositories, so the
+// same repository code runs inside and outside a transaction.
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type SqliteTransactor struct {
+	db *sql.DB
+}
+
+func NewSqliteTransactor(db *sql.DB) port.Transactor {
+	return &SqliteTransactor{db: db}
+}
+
+func (t *SqliteTransactor) WithinTransaction(fn func(repos port.Repositories) error) error {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	repos := port.Repositories{
+		Users:         &SqliteUserRepository{db: tx},
+		Transfers:     &SqliteTransferRepository{db: tx},
+		Ledger:        &SqlitePointLedgerRepository{db: tx},
+		Journal:       &SqliteJournalRepository{db: tx},
+		Lots:          &SqliteLotRepository{db: tx},
+		Tiers:         &SqliteTierRepository{db: tx},
+		Sequences:     &SqliteSequenceRepository{db: tx},
+		Confirmations: &SqliteTransferConfirmationRepository{db: tx},
+		StatusHistory: &SqliteAccountStatusRepository{db: tx},
+		Outbox:        &SqliteOutboxRepository{db: tx},
+		Audit:         &SqliteAuditRepository{db: tx},
+	}
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package adapter
+
+import (
+	"database/sql"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type SqliteTransferConfirmationRepository struct {
+	db dbtx
+}
+
+func NewSqliteTransferConfirmationRepository(db *sql.DB) port.TransferConfirmationRepository {
+	return &SqliteTransferConfirmationRepository{db: db}
+}
+
+func (r *SqliteTransferConfirmationRepository) Create(confirmation *domain.TransferConfirmation) error {
+	_, err := r.db.Exec(`
+		INSERT INTO transfer_confirmations (id, from_user_id, to_user_id, recipient_name, amount, note, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		confirmation.ID,
+		confirmation.FromUserID,
+		confirmation.ToUserID,
+		confirmation.RecipientName,
+		confirmation.Amount,
+		confirmation.Note,
+		formatTimestamp(confirmation.CreatedAt),
+		formatTimestamp(confirmation.ExpiresAt))
+	return err
+}
+
+func (r *SqliteTransferConfirmationRepository) GetByID(id string) (*domain.TransferConfirmation, error) {
+	var c domain.TransferConfirmation
+	var note, consumedAtStr sql.NullString
+	var createdAtStr, expiresAtStr string
+
+	err := r.db.QueryRow(`
+		SELECT id, from_user_id, to_user_id, recipient_name, amount, note, created_at, expires_at, consumed_at
+		FROM transfer_confirmations
+		WHERE id = ?`, id).
+		Scan(&c.ID, &c.FromUserID, &c.ToUserID, &c.RecipientName, &c.Amount, &note, &createdAtStr, &expiresAtStr, &consumedAtStr)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := parseTimestamp(createdAtStr, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := parseTimestamp(expiresAtStr, &c.ExpiresAt); err != nil {
+		return nil, err
+	}
+	if consumedAtStr.Valid {
+		var consumedAt time.Time
+		if err := parseTimestamp(consumedAtStr.String, &consumedAt); err != nil {
+			return nil, err
+		}
+		c.ConsumedAt = &consumedAt
+	}
+	if note.Valid {
+		c.Note = &note.String
+	}
+
+	return &c, nil
+}
+
+func (r *SqliteTransferConfirmationRepository) MarkConsumed(id string, at time.Time) error {
+	result, err := r.db.Exec(`UPDATE transfer_confirmations SET consumed_at = ? WHERE id = ? AND consumed_at IS NULL`,
+		formatTimestamp(at), id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return port.ErrConfirmationConsumed
+	}
+	return nil
+}
@@ -8,34 +8,8 @@ import (
 	"workshop4-backend/internal/port"
 )
 
-// Helper functions for time parsing
-func parseTimeString(timeStr string, target *time.Time) error {
-	t, err := time.Parse("2006-01-02T15:04:05Z07:00", timeStr)
-	if err != nil {
-		return err
-	}
-	*target = t
-	return nil
-}
-
-func parseTimeStringPtr(timeStr string, target **time.Time) error {
-	t, err := time.Parse("2006-01-02T15:04:05Z07:00", timeStr)
-	if err != nil {
-		return err
-	}
-	*target = &t
-	return nil
-}
-
-func formatTimePtr(t *time.Time) interface{} {
-	if t == nil {
-		return nil
-	}
-	return t.Format("2006-01-02T15:04:05Z07:00")
-}
-
 type SqliteTransferRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewSqliteTransferRepository(db *sql.DB) port.TransferRepository {
@@ -54,9 +28,9 @@ func (r *SqliteTransferRepository) Create(transfer *domain.Transfer) error {
 		transfer.Status,
 		transfer.Note,
 		transfer.IdempotencyKey,
-		transfer.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		transfer.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		formatTimePtr(transfer.CompletedAt),
+		formatTimestamp(transfer.CreatedAt),
+		formatTimestamp(transfer.UpdatedAt),
+		formatTimestampPtr(transfer.CompletedAt),
 		transfer.FailReason)
 	if err != nil {
 		return err
@@ -102,10 +76,10 @@ func (r *SqliteTransferRepository) GetByIdempotencyKey(key string) (*domain.Tran
 	}
 
 	// Parse time strings
-	if err := parseTimeString(createdAtStr, &transfer.CreatedAt); err != nil {
+	if err := parseTimestamp(createdAtStr, &transfer.CreatedAt); err != nil {
 		return nil, err
 	}
-	if err := parseTimeString(updatedAtStr, &transfer.UpdatedAt); err != nil {
+	if err := parseTimestamp(updatedAtStr, &transfer.UpdatedAt); err != nil {
 		return nil, err
 	}
 
@@ -117,7 +91,7 @@ func (r *SqliteTransferRepository) GetByIdempotencyKey(key string) (*domain.Tran
 		transfer.FailReason = &failReason.String
 	}
 	if completedAtStr.Valid {
-		if err := parseTimeStringPtr(completedAtStr.String, &transfer.CompletedAt); err != nil {
+		if err := parseTimestampPtr(completedAtStr.String, &transfer.CompletedAt); err != nil {
 			return nil, err
 		}
 	}
@@ -177,10 +151,10 @@ func (r *SqliteTransferRepository) GetByUserID(userID int, page, pageSize int) (
 		}
 
 		// Parse time strings
-		if err := parseTimeString(createdAtStr, &transfer.CreatedAt); err != nil {
+		if err := parseTimestamp(createdAtStr, &transfer.CreatedAt); err != nil {
 			return nil, 0, err
 		}
-		if err := parseTimeString(updatedAtStr, &transfer.UpdatedAt); err != nil {
+		if err := parseTimestamp(updatedAtStr, &transfer.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
 
@@ -192,7 +166,7 @@ func (r *SqliteTransferRepository) GetByUserID(userID int, page, pageSize int) (
 			transfer.FailReason = &failReason.String
 		}
 		if completedAtStr.Valid {
-			if err := parseTimeStringPtr(completedAtStr.String, &transfer.CompletedAt); err != nil {
+			if err := parseTimestampPtr(completedAtStr.String, &transfer.CompletedAt); err != nil {
 				return nil, 0, err
 			}
 		}
@@ -203,12 +177,12 @@ func (r *SqliteTransferRepository) GetByUserID(userID int, page, pageSize int) (
 	return transfers, total, nil
 }
 
-func (r *SqliteTransferRepository) UpdateStatus(id int, status domain.TransferStatus, completedAt *string, failReason *string) error {
+func (r *SqliteTransferRepository) UpdateStatus(id int, status domain.TransferStatus, completedAt *time.Time, failReason *string) error {
 	query := `
 		UPDATE transfers
-		SET status = ?, updated_at = datetime('now'), completed_at = ?, fail_reason = ?
+		SET status = ?, updated_at = ` + sqlNow + `, completed_at = ?, fail_reason = ?
 		WHERE id = ?
 	`
-	_, err := r.db.Exec(query, status, completedAt, failReason, id)
+	_, err := r.db.Exec(query, status, formatTimestampPtr(completedAt), failReason, id)
 	return err
 }
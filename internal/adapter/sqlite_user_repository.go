@@ -2,21 +2,47 @@ package adapter
 
 import (
 	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
 
 	"workshop4-backend/internal/domain"
 	"workshop4-backend/internal/port"
 )
 
 type SqliteUserRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewSqliteUserRepository(db *sql.DB) port.UserRepository {
 	return &SqliteUserRepository{db: db}
 }
 
+// userColumns are the users columns read into a domain.User by scanUser.
+const userColumns = `id, name, phone, email, member_since, membership_level, member_id, points, created_at, updated_at, version, status, deleted_at`
+
+func scanUser(row interface{ Scan(...interface{}) error }, user *domain.User) error {
+	var createdAt, updatedAt string
+	var deletedAt sql.NullString
+	if err := row.Scan(&user.ID, &user.Name, &user.Phone, &user.Email, &user.MemberSince, &user.MembershipLevel, &user.MemberID, &user.Points, &createdAt, &updatedAt, &user.Version, &user.Status, &deletedAt); err != nil {
+		return err
+	}
+	if err := parseTimestamp(createdAt, &user.CreatedAt); err != nil {
+		return err
+	}
+	if err := parseTimestamp(updatedAt, &user.UpdatedAt); err != nil {
+		return err
+	}
+	user.DeletedAt = nil
+	if deletedAt.Valid {
+		return parseTimestampPtr(deletedAt.String, &user.DeletedAt)
+	}
+	return nil
+}
+
 func (r *SqliteUserRepository) GetAll() ([]domain.User, error) {
-	rows, err := r.db.Query(`SELECT id, name, phone, email, member_since, membership_level, member_id, points, created_at, updated_at FROM users`)
+	rows, err := r.db.Query(`SELECT ` + userColumns + ` FROM users WHERE deleted_at IS NULL`)
 	if err != nil {
 		return nil, err
 	}
@@ -24,7 +50,7 @@ func (r *SqliteUserRepository) GetAll() ([]domain.User, error) {
 	var users []domain.User
 	for rows.Next() {
 		var user domain.User
-		err := rows.Scan(&user.ID, &user.Name, &user.Phone, &user.Email, &user.MemberSince, &user.MembershipLevel, &user.MemberID, &user.Points, &user.CreatedAt, &user.UpdatedAt)
+		err := scanUser(rows, &user)
 		if err != nil {
 			return nil, err
 		}
@@ -33,10 +59,110 @@ func (r *SqliteUserRepository) GetAll() ([]domain.User, error) {
 	return users, nil
 }
 
+// userSortColumns maps each port.UserSort to the expression it orders by.
+var userSortColumns = map[port.UserSort]string{
+	port.UserSortID:          "id",
+	port.UserSortName:        "name",
+	port.UserSortPoints:      "points",
+	port.UserSortMemberSince: "member_since",
+	port.UserSortCreatedAt:   "created_at",
+}
+
+// likeEscaper escapes LIKE wildcards so a name prefix matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *SqliteUserRepository) List(filter port.UserFilter) ([]domain.User, int, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if filter.NamePrefix != "" {
+		conditions = append(conditions, `name LIKE ? ESCAPE '\'`)
+		args = append(args, likeEscaper.Replace(filter.NamePrefix)+"%")
+	}
+	if filter.MembershipLevel != "" {
+		conditions = append(conditions, "membership_level = ? COLLATE NOCASE")
+		args = append(args, filter.MembershipLevel)
+	}
+	if filter.MemberSinceFrom != nil {
+		conditions = append(conditions, "member_since >= ?")
+		args = append(args, filter.MemberSinceFrom.Format(domain.DateLayout))
+	}
+	if filter.MemberSinceTo != nil {
+		conditions = append(conditions, "member_since <= ?")
+		args = append(args, filter.MemberSinceTo.Format(domain.DateLayout))
+	}
+	if filter.MinPoints != nil {
+		conditions = append(conditions, "points >= ?")
+		args = append(args, *filter.MinPoints)
+	}
+	if filter.MaxPoints != nil {
+		conditions = append(conditions, "points <= ?")
+		args = append(args, *filter.MaxPoints)
+	}
+
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM users `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := userSortColumns[filter.Sort]
+	if !ok {
+		order = "id"
+	}
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users ` + where +
+		` ORDER BY ` + order + ` ` + direction + `, id ` + direction + ` LIMIT ? OFFSET ?`
+	offset := (filter.Page - 1) * filter.PageSize
+	rows, err := r.db.Query(query, append(args, filter.PageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		var user domain.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, rows.Err()
+}
+
 func (r *SqliteUserRepository) GetByID(id int) (*domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow(`SELECT id, name, phone, email, member_since, membership_level, member_id, points, created_at, updated_at FROM users WHERE id = ?`, id).
-		Scan(&user.ID, &user.Name, &user.Phone, &user.Email, &user.MemberSince, &user.MembershipLevel, &user.MemberID, &user.Points, &user.CreatedAt, &user.UpdatedAt)
+	err := scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ?`, id), &user)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *SqliteUserRepository) GetByMemberID(memberID string) (*domain.User, error) {
+	return r.getOne(`member_id = ?`, memberID)
+}
+
+func (r *SqliteUserRepository) GetByPhone(phone string) (*domain.User, error) {
+	return r.getOne(`phone = ? AND deleted_at IS NULL`, phone)
+}
+
+func (r *SqliteUserRepository) GetByEmail(email string) (*domain.User, error) {
+	return r.getOne(`email = ? AND deleted_at IS NULL`, email)
+}
+
+func (r *SqliteUserRepository) getOne(condition string, arg interface{}) (*domain.User, error) {
+	var user domain.User
+	err := scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE `+condition+` ORDER BY id LIMIT 1`, arg), &user)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -47,30 +173,54 @@ func (r *SqliteUserRepository) GetByID(id int) (*domain.User, error) {
 }
 
 func (r *SqliteUserRepository) Create(user *domain.User) error {
-	result, err := r.db.Exec(`INSERT INTO users (name, phone, email, member_since, membership_level, member_id, points) VALUES (?, ?, ?, ?, ?, ?, ?)`, user.Name, user.Phone, user.Email, user.MemberSince, user.MembershipLevel, user.MemberID, user.Points)
+	if user.Status == "" {
+		user.Status = domain.AccountStatusActive
+	}
+	result, err := r.db.Exec(`INSERT INTO users (name, phone, email, member_since, membership_level, member_id, points, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, user.Name, user.Phone, user.Email, user.MemberSince, user.MembershipLevel, user.MemberID, user.Points, user.Status, formatTimestamp(user.CreatedAt), formatTimestamp(user.UpdatedAt))
 	if err != nil {
-		return err
+		return contactConflict(err)
 	}
 	id, err := result.LastInsertId()
 	if err != nil {
 		return err
 	}
 	user.ID = int(id)
-	return nil
+	user.Version = 1
+	return indexUser(r.db, user)
 }
 
 func (r *SqliteUserRepository) Update(user *domain.User) error {
-	_, err := r.db.Exec(`UPDATE users SET name = ?, phone = ?, email = ?, member_since = ?, membership_level = ?, member_id = ?, points = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, user.Name, user.Phone, user.Email, user.MemberSince, user.MembershipLevel, user.MemberID, user.Points, user.ID)
-	return err
+	// member_id is assigned once at creation and never changes
+	result, err := r.db.Exec(`UPDATE users SET name = ?, phone = ?, email = ?, member_since = ?, membership_level = ?, points = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?`, user.Name, user.Phone, user.Email, user.MemberSince, user.MembershipLevel, user.Points, formatTimestamp(user.UpdatedAt), user.ID, user.Version)
+	if err != nil {
+		return contactConflict(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return port.ErrVersionConflict
+	}
+	user.Version++
+	return indexUser(r.db, user)
 }
 
-func (r *SqliteUserRepository) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM users WHERE id = ?`, id)
-	return err
+func (r *SqliteUserRepository) UpdateStatus(id int, status domain.AccountStatus) error {
+	_, err := r.db.Exec(`UPDATE users SET status = ?, deleted_at = CASE WHEN ? = 'closed' THEN `+sqlNow+` END, updated_at = `+sqlNow+`, version = version + 1 WHERE id = ?`, status, status, id)
+	if err != nil || status != domain.AccountStatusClosed {
+		return err
+	}
+	return unindexUser(r.db, id)
 }
 
 func (r *SqliteUserRepository) UpdatePoints(userID int, newBalance int) error {
-	_, err := r.db.Exec(`UPDATE users SET points = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, newBalance, userID)
+	_, err := r.db.Exec(`UPDATE users SET points = ?, updated_at = `+sqlNow+`, version = version + 1 WHERE id = ?`, newBalance, userID)
+	return err
+}
+
+func (r *SqliteUserRepository) UpdateMembershipLevel(userID int, level string) error {
+	_, err := r.db.Exec(`UPDATE users SET membership_level = ?, updated_at = `+sqlNow+`, version = version + 1 WHERE id = ?`, level, userID)
 	return err
 }
 
@@ -82,3 +232,189 @@ func (r *SqliteUserRepository) GetUserBalance(userID int) (int, error) {
 	}
 	return balance, nil
 }
+
+// BackfillMemberIDs assigns a generated member ID to users whose member_id is
+// missing or shared with an earlier user, so the UNIQUE index can be built.
+func BackfillMemberIDs(db *sql.DB, format domain.MemberIDFormat) (int, error) {
+	rows, err := db.Query(`
+		SELECT id FROM users u
+		WHERE member_id IS NULL OR TRIM(member_id) = ''
+			OR EXISTS (SELECT 1 FROM users o WHERE o.member_id = u.member_id AND o.id < u.id)
+		ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	var userIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	rows.Close()
+
+	for _, userID := range userIDs {
+		tx, err := db.Begin()
+		if err != nil {
+			return 0, err
+		}
+		seq, err := (&SqliteSequenceRepository{db: tx}).Next(domain.SequenceMemberID)
+		if err == nil {
+			_, err = tx.Exec(`UPDATE users SET member_id = ? WHERE id = ?`, format.Generate(seq), userID)
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	return len(userIDs), nil
+}
+
+// NormalizeContacts rewrites stored phone numbers to E.164 and email
+// addresses to lower case where they are valid, so lookups can match them
+// exactly. Values that fail validation are left untouched.
+func NormalizeContacts(db *sql.DB) (int, error) {
+	rows, err := db.Query(`SELECT id, COALESCE(phone, ''), COALESCE(email, '') FROM users ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	type contact struct {
+		id           int
+		phone, email string
+	}
+	var changed []contact
+	for rows.Next() {
+		var c contact
+		if err := rows.Scan(&c.id, &c.phone, &c.email); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		phone, email := c.phone, c.email
+		if normalized, err := domain.NormalizePhone(phone); err == nil {
+			phone = normalized
+		}
+		if normalized, err := domain.NormalizeEmail(email); err == nil {
+			email = normalized
+		}
+		if phone != c.phone || email != c.email {
+			changed = append(changed, contact{id: c.id, phone: phone, email: email})
+		}
+	}
+	rows.Close()
+
+	for _, c := range changed {
+		if _, err := db.Exec(`UPDATE users SET phone = ?, email = ? WHERE id = ?`, c.phone, c.email, c.id); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), nil
+}
+
+// DuplicateContact is a phone number or email address shared by more than
+// one open account, which the open-account unique indexes do not allow.
+type DuplicateContact struct {
+	Field   string // "phone" or "email"
+	UserIDs []int
+}
+
+// FindDuplicateContacts returns the phone numbers and email addresses that
+// more than one open account shares, phones first, each with its user IDs in
+// ascending order.
+func FindDuplicateContacts(db *sql.DB) ([]DuplicateContact, error) {
+	rows, err := db.Query(`SELECT id, phone, email FROM users WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	phones := map[string][]int{}
+	emails := map[string][]int{}
+	var phoneOrder, emailOrder []string
+	for rows.Next() {
+		var id int
+		var phone, email sql.NullString
+		if err := rows.Scan(&id, &phone, &email); err != nil {
+			return nil, err
+		}
+		// NULLs never conflict in a unique index
+		if phone.Valid {
+			if phones[phone.String] == nil {
+				phoneOrder = append(phoneOrder, phone.String)
+			}
+			phones[phone.String] = append(phones[phone.String], id)
+		}
+		if email.Valid {
+			if emails[email.String] == nil {
+				emailOrder = append(emailOrder, email.String)
+			}
+			emails[email.String] = append(emails[email.String], id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var duplicates []DuplicateContact
+	for _, value := range phoneOrder {
+		if ids := phones[value]; len(ids) > 1 {
+			duplicates = append(duplicates, DuplicateContact{Field: "phone", UserIDs: ids})
+		}
+	}
+	for _, value := range emailOrder {
+		if ids := emails[value]; len(ids) > 1 {
+			duplicates = append(duplicates, DuplicateContact{Field: "email", UserIDs: ids})
+		}
+	}
+	return duplicates, nil
+}
+
+// NormalizeMemberSince rewrites member_since values stored as Buddhist-era
+// dates ("15/6/2566") as ISO 8601 dates ("2023-06-15") so they sort and
+// compare correctly. Values that cannot be parsed are left untouched.
+func NormalizeMemberSince(db *sql.DB) (int, error) {
+	rows, err := db.Query(`SELECT id, member_since FROM users WHERE member_since IS NOT NULL AND member_since != '' ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	changed := map[int]string{}
+	for rows.Next() {
+		var id int
+		var memberSince string
+		if err := rows.Scan(&id, &memberSince); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if normalized, err := domain.NormalizeMemberSince(memberSince); err == nil && normalized != memberSince {
+			changed[id] = normalized
+		}
+	}
+	rows.Close()
+
+	for id, memberSince := range changed {
+		if _, err := db.Exec(`UPDATE users SET member_since = ? WHERE id = ?`, memberSince, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), nil
+}
+
+// contactConflict maps a violation of the open-account phone and email
+// indexes to port.ErrPhoneTaken or port.ErrEmailTaken.
+func contactConflict(err error) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.ExtendedCode != sqlite3.ErrConstraintUnique {
+		return err
+	}
+	switch {
+	case strings.Contains(sqliteErr.Error(), "users.phone"):
+		return port.ErrPhoneTaken
+	case strings.Contains(sqliteErr.Error(), "users.email"):
+		return port.ErrEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package adapter
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"workshop4-backend/internal/domain"
+)
+
+// minMatchTermLength is the shortest term the trigram index can match;
+// shorter terms are matched with LIKE instead.
+const minMatchTermLength = 3
+
+// Search returns up to limit open accounts whose name, email, phone or
+// member ID contains every term. With the FTS5 index, results are ranked by
+// relevance; otherwise they are in ID order.
+func (r *SqliteUserRepository) Search(terms []string, limit int) ([]domain.User, error) {
+	var matchTerms, conditions []string
+	var args []interface{}
+	for _, term := range terms {
+		if userSearchFTS && utf8.RuneCountInString(term) >= minMatchTermLength {
+			matchTerms = append(matchTerms, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+			continue
+		}
+		pattern := "%" + likeEscaper.Replace(term) + "%"
+		conditions = append(conditions, `(name LIKE ? ESCAPE '\' OR email LIKE ? ESCAPE '\' OR phone LIKE ? ESCAPE '\' OR member_id LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	conditions = append(conditions, "deleted_at IS NULL")
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY id LIMIT ?`
+	if len(matchTerms) > 0 {
+		query = `SELECT ` + userColumns + ` FROM users
+			JOIN (SELECT rowid, rank FROM users_fts WHERE users_fts MATCH ?) m ON m.rowid = users.id
+			WHERE ` + strings.Join(conditions, " AND ") + `
+			ORDER BY m.rank, id LIMIT ?`
+		args = append([]interface{}{strings.Join(matchTerms, " ")}, args...)
+	}
+
+	rows, err := r.db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		var user domain.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
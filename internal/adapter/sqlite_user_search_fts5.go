@@ -0,0 +1,64 @@
+//go:build sqlite_fts5
+
+package adapter
+
+import (
+	"database/sql"
+
+	"workshop4-backend/internal/domain"
+)
+
+// userSearchFTS reports whether users are searched through the users_fts
+// index, which needs go-sqlite3 built with the sqlite_fts5 tag.
+const userSearchFTS = true
+
+// RebuildUserSearchIndex creates the users_fts index if needed and refills
+// it from the open accounts. The trigram tokenizer matches any substring of
+// three or more characters, so Thai names, which have no spaces between
+// words, and phone fragments are found without word segmentation.
+func RebuildUserSearchIndex(db *sql.DB) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS users_fts USING fts5(name, email, phone, member_id, tokenize = 'trigram')`,
+		`DELETE FROM users_fts`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return 0, err
+		}
+	}
+	result, err := tx.Exec(`
+		INSERT INTO users_fts (rowid, name, email, phone, member_id)
+		SELECT id, name, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(member_id, '')
+		FROM users
+		WHERE deleted_at IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), tx.Commit()
+}
+
+// indexUser replaces the user's row in users_fts.
+func indexUser(db dbtx, user *domain.User) error {
+	if err := unindexUser(db, user.ID); err != nil {
+		return err
+	}
+	_, err := db.Exec(`INSERT INTO users_fts (rowid, name, email, phone, member_id) VALUES (?, ?, ?, ?, ?)`,
+		user.ID, user.Name, user.Email, user.Phone, user.MemberID)
+	return err
+}
+
+// unindexUser removes the user from users_fts.
+func unindexUser(db dbtx, id int) error {
+	_, err := db.Exec(`DELETE FROM users_fts WHERE rowid = ?`, id)
+	return err
+}
@@ -0,0 +1,27 @@
+//go:build !sqlite_fts5
+
+package adapter
+
+import (
+	"database/sql"
+
+	"workshop4-backend/internal/domain"
+)
+
+// userSearchFTS reports whether users are searched through the users_fts
+// index. Without the sqlite_fts5 build tag, go-sqlite3 lacks FTS5 and
+// Search falls back to LIKE.
+const userSearchFTS = false
+
+// RebuildUserSearchIndex is a no-op without FTS5.
+func RebuildUserSearchIndex(db *sql.DB) (int, error) {
+	return 0, nil
+}
+
+func indexUser(db dbtx, user *domain.User) error {
+	return nil
+}
+
+func unindexUser(db dbtx, id int) error {
+	return nil
+}
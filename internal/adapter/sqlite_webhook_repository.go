@@ -0,0 +1,251 @@
+package adapter
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_name, body, status, attempts, response_status,
+	last_error, next_attempt_at, delivered_at, created_at`
+
+type SqliteWebhookRepository struct {
+	db dbtx
+}
+
+func NewSqliteWebhookRepository(db *sql.DB) port.WebhookRepository {
+	return &SqliteWebhookRepository{db: db}
+}
+
+func (r *SqliteWebhookRepository) CreateSubscription(subscription *domain.WebhookSubscription) error {
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return err
+	}
+	userIDs, err := json.Marshal(subscription.UserIDs)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`
+		INSERT INTO webhook_subscriptions (url, event_types, user_ids, secret, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		subscription.URL,
+		string(eventTypes),
+		string(userIDs),
+		subscription.Secret,
+		formatTimestamp(subscription.CreatedAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	subscription.ID = int(id)
+	return nil
+}
+
+func (r *SqliteWebhookRepository) GetSubscription(id int) (*domain.WebhookSubscription, error) {
+	subscriptions, err := r.querySubscriptions(`WHERE id = ?`, id)
+	if err != nil || len(subscriptions) == 0 {
+		return nil, err
+	}
+	return &subscriptions[0], nil
+}
+
+func (r *SqliteWebhookRepository) ListSubscriptions() ([]domain.WebhookSubscription, error) {
+	return r.querySubscriptions("")
+}
+
+func (r *SqliteWebhookRepository) querySubscriptions(where string, args ...interface{}) ([]domain.WebhookSubscription, error) {
+	rows, err := r.db.Query(`
+		SELECT id, url, event_types, user_ids, secret, created_at
+		FROM webhook_subscriptions `+where+`
+		ORDER BY id ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []domain.WebhookSubscription{}
+	for rows.Next() {
+		var subscription domain.WebhookSubscription
+		var eventTypes, createdAtStr string
+		var userIDs sql.NullString
+		if err := rows.Scan(&subscription.ID, &subscription.URL, &eventTypes, &userIDs, &subscription.Secret, &createdAtStr); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(eventTypes), &subscription.EventTypes); err != nil {
+			return nil, err
+		}
+		if userIDs.Valid {
+			if err := json.Unmarshal([]byte(userIDs.String), &subscription.UserIDs); err != nil {
+				return nil, err
+			}
+		}
+		if err := parseTimestamp(createdAtStr, &subscription.CreatedAt); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *SqliteWebhookRepository) DeleteSubscription(id int) error {
+	if _, err := r.db.Exec(`DELETE FROM webhook_deliveries WHERE subscription_id = ?`, id); err != nil {
+		return err
+	}
+	_, err := r.db.Exec(`DELETE FROM webhook_subscriptions WHERE id = ?`, id)
+	return err
+}
+
+func (r *SqliteWebhookRepository) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	err := r.db.QueryRow(`
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_name, body, status, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+		RETURNING id`,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventName,
+		string(delivery.Body),
+		delivery.Status,
+		delivery.Attempts,
+		formatTimestamp(delivery.NextAttemptAt),
+		formatTimestamp(delivery.CreatedAt)).
+		Scan(&delivery.ID)
+	// Already queued
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+func (r *SqliteWebhookRepository) GetDelivery(id int) (*domain.WebhookDelivery, error) {
+	deliveries, err := r.queryDeliveries(`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+func (r *SqliteWebhookRepository) GetDueDeliveries(asOf time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	// next_attempt_at is always stored in UTC so string comparison orders correctly
+	return r.queryDeliveries(`
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY id ASC
+		LIMIT ?`, domain.WebhookDeliveryPending, formatTimestamp(asOf), limit)
+}
+
+func (r *SqliteWebhookRepository) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_status = ?, last_error = ?, next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?`,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.LastError,
+		formatTimestamp(delivery.NextAttemptAt),
+		formatTimestampPtr(delivery.DeliveredAt),
+		delivery.ID)
+	return err
+}
+
+func (r *SqliteWebhookRepository) ListDeliveries(filter port.WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.SubscriptionID != 0 {
+		conditions = append(conditions, "subscription_id = ?")
+		args = append(args, filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	deliveries, err := r.queryDeliveries(`
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries `+where+`
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?`, append(args, filter.PageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (r *SqliteWebhookRepository) queryDeliveries(query string, args ...interface{}) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []domain.WebhookDelivery{}
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		var body, nextAttemptAtStr, createdAtStr string
+		var responseStatus sql.NullInt64
+		var lastError, deliveredAtStr sql.NullString
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&delivery.EventName,
+			&body,
+			&delivery.Status,
+			&delivery.Attempts,
+			&responseStatus,
+			&lastError,
+			&nextAttemptAtStr,
+			&deliveredAtStr,
+			&createdAtStr)
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.Body = []byte(body)
+		if responseStatus.Valid {
+			status := int(responseStatus.Int64)
+			delivery.ResponseStatus = &status
+		}
+		if lastError.Valid {
+			delivery.LastError = &lastError.String
+		}
+		if err := parseTimestamp(nextAttemptAtStr, &delivery.NextAttemptAt); err != nil {
+			return nil, err
+		}
+		if deliveredAtStr.Valid {
+			if err := parseTimestampPtr(deliveredAtStr.String, &delivery.DeliveredAt); err != nil {
+				return nil, err
+			}
+		}
+		if err := parseTimestamp(createdAtStr, &delivery.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
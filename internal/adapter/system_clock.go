@@ -0,0 +1,18 @@
+package adapter
+
+import (
+	"time"
+
+	"workshop4-backend/internal/port"
+)
+
+// SystemClock is the wall clock. Times are in UTC and truncated to whole
+// seconds, matching how timestamps are stored, so a value returned from a
+// write reads back unchanged.
+type SystemClock struct{}
+
+var _ port.Clock = SystemClock{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
@@ -0,0 +1,134 @@
+package adapter
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// timestampLayout is the one format timestamps are stored in: RFC 3339 in
+// UTC with second precision, e.g. "2024-01-02T15:04:05Z". Values in this
+// format compare chronologically as text, so range queries work on them.
+const timestampLayout = time.RFC3339
+
+// sqlNow is the SQL expression for the current time in timestampLayout, for
+// statements that stamp rows without a time from the caller.
+const sqlNow = `strftime('%Y-%m-%dT%H:%M:%SZ', 'now')`
+
+// legacyTimestampLayouts are formats written before timestampLayout was
+// enforced: SQLite's CURRENT_TIMESTAMP and datetime('now'), and time.Time
+// values bound directly by the driver.
+var legacyTimestampLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999",
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+func formatTimestampPtr(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return formatTimestamp(*t)
+}
+
+// parseTimestamp parses a stored timestamp in timestampLayout or a legacy
+// layout into UTC. Legacy values without a zone are UTC.
+func parseTimestamp(s string, target *time.Time) error {
+	t, err := time.Parse(timestampLayout, s)
+	for _, layout := range legacyTimestampLayouts {
+		if err == nil {
+			break
+		}
+		t, err = time.Parse(layout, s)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", s)
+	}
+	*target = t.UTC()
+	return nil
+}
+
+func parseTimestampPtr(s string, target **time.Time) error {
+	var t time.Time
+	if err := parseTimestamp(s, &t); err != nil {
+		return err
+	}
+	*target = &t
+	return nil
+}
+
+// timestampColumns lists the columns NormalizeTimestamps rewrites. audit_log
+// is append-only and keeps the format each entry was written in;
+// parseTimestamp reads both.
+var timestampColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"users", []string{"created_at", "updated_at", "deleted_at"}},
+	{"transfers", []string{"created_at", "updated_at", "completed_at"}},
+	{"point_ledger", []string{"created_at"}},
+	{"journal_entries", []string{"created_at"}},
+	{"point_lots", []string{"earned_at", "expires_at"}},
+	{"transfer_confirmations", []string{"created_at", "expires_at", "consumed_at"}},
+	{"tier_history", []string{"created_at"}},
+	{"account_status_history", []string{"created_at"}},
+}
+
+// NormalizeTimestamps rewrites stored timestamps in legacy layouts or with a
+// zone offset to timestampLayout, so text comparisons order them correctly.
+// The ledger hash covers the UTC instant rather than the stored text, so
+// rewritten ledger rows keep their hashes. Values that cannot be parsed are
+// left untouched.
+func NormalizeTimestamps(db *sql.DB) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	total := 0
+	for _, tc := range timestampColumns {
+		for _, column := range tc.columns {
+			n, err := normalizeTimestampColumn(tx, tc.table, column)
+			if err != nil {
+				return 0, fmt.Errorf("%s.%s: %w", tc.table, column, err)
+			}
+			total += n
+		}
+	}
+	return total, tx.Commit()
+}
+
+func normalizeTimestampColumn(tx *sql.Tx, table, column string) (int, error) {
+	// CAST reads the stored text; the driver would otherwise parse DATETIME
+	// columns itself
+	rows, err := tx.Query(`SELECT rowid, CAST(` + column + ` AS TEXT) FROM ` + table + ` WHERE ` + column + ` IS NOT NULL`)
+	if err != nil {
+		return 0, err
+	}
+	changed := map[int64]string{}
+	for rows.Next() {
+		var rowid int64
+		var stored string
+		if err := rows.Scan(&rowid, &stored); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		var t time.Time
+		if err := parseTimestamp(stored, &t); err == nil && formatTimestamp(t) != stored {
+			changed[rowid] = formatTimestamp(t)
+		}
+	}
+	rows.Close()
+
+	for rowid, value := range changed {
+		if _, err := tx.Exec(`UPDATE `+table+` SET `+column+` = ? WHERE rowid = ?`, value, rowid); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), nil
+}
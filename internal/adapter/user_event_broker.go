@@ -0,0 +1,155 @@
+package adapter
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// it is dropped; the client reconnects and resumes from the buffer.
+const subscriberBuffer = 32
+
+// UserEventBroker is an in-process UserEventBus. It keeps each user's most
+// recent events for resuming streams, and forgets users that have had no
+// subscribers or events for the retention period. Event IDs are
+// "<epoch>-<sequence>", where the epoch identifies this process, so an ID
+// from before a restart is reported as missed rather than mistaken for a
+// recent one.
+type UserEventBroker struct {
+	mu        sync.Mutex
+	epoch     string
+	seq       uint64
+	capacity  int
+	retention time.Duration
+	clock     port.Clock
+	users     map[int]*userEvents
+	swept     time.Time
+}
+
+type userEvents struct {
+	// buffer holds the last events published for the user, oldest first
+	buffer []sequencedEvent
+	// evicted is the sequence of the newest event dropped from buffer, or
+	// published before the user was last forgotten
+	evicted     uint64
+	subscribers map[chan domain.UserEvent]struct{}
+	// active is when the user last published, subscribed or unsubscribed
+	active time.Time
+}
+
+type sequencedEvent struct {
+	seq   uint64
+	event domain.UserEvent
+}
+
+// NewUserEventBroker returns a broker that keeps the last capacity events
+// of each user for retention after their last subscriber leaves.
+func NewUserEventBroker(capacity int, retention time.Duration, clock port.Clock) port.UserEventBus {
+	return &UserEventBroker{
+		epoch:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		capacity:  capacity,
+		retention: retention,
+		clock:     clock,
+		users:     map[int]*userEvents{},
+		swept:     clock.Now(),
+	}
+}
+
+func (b *UserEventBroker) Publish(event domain.UserEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	user := b.user(event.UserID)
+	b.seq++
+	event.ID = b.epoch + "-" + strconv.FormatUint(b.seq, 10)
+	user.buffer = append(user.buffer, sequencedEvent{seq: b.seq, event: event})
+	if len(user.buffer) > b.capacity {
+		user.evicted = user.buffer[0].seq
+		user.buffer = append(user.buffer[:0:0], user.buffer[1:]...)
+	}
+
+	for ch := range user.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Too far behind: end the stream so the client resumes
+			delete(user.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *UserEventBroker) Subscribe(userID int, lastEventID string) *port.UserEventSubscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	user := b.user(userID)
+	subscription := &port.UserEventSubscription{}
+	if lastEventID != "" {
+		after, ok := b.parseID(lastEventID)
+		subscription.Missed = !ok || user.evicted > after
+		for _, e := range user.buffer {
+			if e.seq > after {
+				subscription.Replay = append(subscription.Replay, e.event)
+			}
+		}
+	}
+
+	ch := make(chan domain.UserEvent, subscriberBuffer)
+	user.subscribers[ch] = struct{}{}
+	subscription.Events = ch
+	subscription.Close = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := user.subscribers[ch]; ok {
+			delete(user.subscribers, ch)
+			close(ch)
+		}
+		user.active = b.clock.Now()
+	}
+	return subscription
+}
+
+// user returns the user's events, creating them if the user is new or was
+// forgotten. A new entry treats every earlier event as evicted, so a client
+// resuming from one gets a snapshot instead of an empty replay. It also
+// forgets idle users, at most once per retention period.
+func (b *UserEventBroker) user(userID int) *userEvents {
+	now := b.clock.Now()
+	if now.Sub(b.swept) >= b.retention {
+		for id, user := range b.users {
+			if len(user.subscribers) == 0 && now.Sub(user.active) >= b.retention {
+				delete(b.users, id)
+			}
+		}
+		b.swept = now
+	}
+
+	user, ok := b.users[userID]
+	if !ok {
+		user = &userEvents{evicted: b.seq, subscribers: map[chan domain.UserEvent]struct{}{}}
+		b.users[userID] = user
+	}
+	user.active = now
+	return user
+}
+
+// parseID returns the sequence of an event ID issued by this broker. IDs
+// from another process or in another format yield false, and a sequence of
+// zero so every buffered event is replayed.
+func (b *UserEventBroker) parseID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil || n > b.seq {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,16 @@
+package adapter
+
+import (
+	"workshop4-backend/internal/port"
+
+	"github.com/google/uuid"
+)
+
+// UUIDGenerator issues random (version 4) UUIDs.
+type UUIDGenerator struct{}
+
+var _ port.IDGenerator = UUIDGenerator{}
+
+func (UUIDGenerator) NewID() string {
+	return uuid.New().String()
+}
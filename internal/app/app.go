@@ -1,15 +1,21 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	_ "github.com/mattn/go-sqlite3"
 
 	"workshop4-backend/internal/adapter"
+	"workshop4-backend/internal/config"
 	"workshop4-backend/internal/domain"
 	"workshop4-backend/internal/handler"
+	"workshop4-backend/internal/port"
 	"workshop4-backend/internal/service"
 )
 
@@ -17,9 +23,11 @@ import (
 var db *sql.DB
 
 // InitDatabase initializes the SQLite database and creates all tables
-func InitDatabase() *sql.DB {
+func InitDatabase(cfg config.Config) *sql.DB {
 	var err error
-	db, err = sql.Open("sqlite3", "users.db")
+	// Immediate transactions take the write lock up front so concurrent
+	// transfers queue behind each other instead of failing with SQLITE_BUSY
+	db, err = sql.Open("sqlite3", cfg.Database.Path+"?_busy_timeout=5000&_txlock=immediate")
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
@@ -27,6 +35,55 @@ func InitDatabase() *sql.DB {
 	createTables()
 	insertSampleDataIfNeeded()
 
+	if n, err := adapter.NormalizeTimestamps(db); err != nil {
+		log.Fatal("Failed to normalize timestamps:", err)
+	} else if n > 0 {
+		log.Printf("Normalized %d timestamps to UTC RFC 3339", n)
+	}
+
+	if n, err := adapter.BackfillMemberIDs(db, memberIDFormat(cfg)); err != nil {
+		log.Fatal("Failed to backfill member IDs:", err)
+	} else if n > 0 {
+		log.Printf("Assigned member IDs to %d users", n)
+	}
+	if _, err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_member_id ON users(member_id);"); err != nil {
+		log.Fatal("Failed to create member_id index:", err)
+	}
+
+	if n, err := adapter.NormalizeContacts(db); err != nil {
+		log.Fatal("Failed to normalize user contacts:", err)
+	} else if n > 0 {
+		log.Printf("Normalized phone and email for %d users", n)
+	}
+	createContactIndexes()
+
+	if n, err := adapter.NormalizeMemberSince(db); err != nil {
+		log.Fatal("Failed to normalize member_since:", err)
+	} else if n > 0 {
+		log.Printf("Converted member_since to ISO dates for %d users", n)
+	}
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_users_member_since ON users(member_since);"); err != nil {
+		log.Fatal("Failed to create member_since index:", err)
+	}
+
+	if n, err := adapter.RebuildUserSearchIndex(db); err != nil {
+		log.Fatal("Failed to build user search index:", err)
+	} else if n > 0 {
+		log.Printf("Indexed %d users for search", n)
+	}
+
+	if n, err := adapter.BackfillOpeningBalances(db); err != nil {
+		log.Fatal("Failed to backfill opening balances:", err)
+	} else if n > 0 {
+		log.Printf("Booked opening journal entries for %d users", n)
+	}
+
+	if n, err := adapter.BackfillLots(db, expiryPolicy(cfg)); err != nil {
+		log.Fatal("Failed to backfill point lots:", err)
+	} else if n > 0 {
+		log.Printf("Created point lots for %d users", n)
+	}
+
 	return db
 }
 
@@ -42,13 +99,32 @@ func createTables() {
 		membership_level TEXT,
 		member_id TEXT,
 		points INTEGER DEFAULT 0,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+		updated_at DATETIME DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+		version INTEGER NOT NULL DEFAULT 1,
+		status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active','suspended','closed')),
+		deleted_at DATETIME
 	);`
 
 	if _, err := db.Exec(createUsersTable); err != nil {
 		log.Fatal("Failed to create users table:", err)
 	}
+	// Bumped on every write; clients send it back in If-Match
+	addColumnIfMissing("users", "version", "INTEGER NOT NULL DEFAULT 1")
+	// Accounts are closed rather than deleted so their history stays intact
+	addColumnIfMissing("users", "status", "TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active','suspended','closed'))")
+	addColumnIfMissing("users", "deleted_at", "DATETIME")
+
+	// Create sequences table backing server-generated identifiers
+	createSequencesTable := `
+	CREATE TABLE IF NOT EXISTS sequences (
+		name TEXT PRIMARY KEY,
+		value INTEGER NOT NULL
+	);`
+
+	if _, err := db.Exec(createSequencesTable); err != nil {
+		log.Fatal("Failed to create sequences table:", err)
+	}
 
 	// Create transfers table
 	createTransfersTable := `
@@ -92,11 +168,13 @@ func createTables() {
 		user_id INTEGER NOT NULL,
 		change INTEGER NOT NULL,
 		balance_after INTEGER NOT NULL,
-		event_type TEXT NOT NULL CHECK (event_type IN ('transfer_out','transfer_in','adjust','earn','redeem')),
+		event_type TEXT NOT NULL CHECK (event_type IN ('transfer_out','transfer_in','adjust','earn','redeem','expire','forfeit')),
 		transfer_id INTEGER,
 		reference TEXT,
 		metadata TEXT,
 		created_at TEXT NOT NULL,
+		prev_hash TEXT,
+		hash TEXT,
 		FOREIGN KEY (user_id) REFERENCES users(id),
 		FOREIGN KEY (transfer_id) REFERENCES transfers(id)
 	);`
@@ -105,6 +183,16 @@ func createTables() {
 		log.Fatal("Failed to create point_ledger table:", err)
 	}
 
+	// Databases created before the hash chain existed lack the hash columns
+	addColumnIfMissing("point_ledger", "prev_hash", "TEXT")
+	addColumnIfMissing("point_ledger", "hash", "TEXT")
+	recreateTableIfOutdated("point_ledger", "'forfeit'", createLedgerTable)
+	if n, err := adapter.BackfillLedgerHashes(db); err != nil {
+		log.Fatal("Failed to backfill ledger hashes:", err)
+	} else if n > 0 {
+		log.Printf("Backfilled hashes for %d ledger entries", n)
+	}
+
 	// Create ledger indexes
 	ledgerIndexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_ledger_user ON point_ledger(user_id);",
@@ -117,6 +205,381 @@ func createTables() {
 			log.Fatal("Failed to create ledger index:", err)
 		}
 	}
+
+	// Create double-entry journal tables. Every journal entry's postings sum
+	// to zero across member and system accounts.
+	createJournalEntriesTable := `
+	CREATE TABLE IF NOT EXISTS journal_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		entry_type TEXT NOT NULL CHECK (entry_type IN ('opening','transfer','earn','redeem','adjust','expire','forfeit')),
+		transfer_id INTEGER,
+		reference TEXT,
+		created_at TEXT NOT NULL,
+		FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+	);`
+
+	createJournalPostingsTable := `
+	CREATE TABLE IF NOT EXISTS journal_postings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		journal_entry_id INTEGER NOT NULL,
+		account TEXT NOT NULL,
+		amount INTEGER NOT NULL CHECK (amount != 0),
+		FOREIGN KEY (journal_entry_id) REFERENCES journal_entries(id)
+	);`
+
+	for _, stmt := range []string{createJournalEntriesTable, createJournalPostingsTable} {
+		if _, err := db.Exec(stmt); err != nil {
+			log.Fatal("Failed to create journal tables:", err)
+		}
+	}
+	recreateTableIfOutdated("journal_entries", "'forfeit'", createJournalEntriesTable)
+
+	// Create journal indexes
+	journalIndexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_journal_entries_transfer ON journal_entries(transfer_id);",
+		"CREATE INDEX IF NOT EXISTS idx_journal_postings_entry ON journal_postings(journal_entry_id);",
+		"CREATE INDEX IF NOT EXISTS idx_journal_postings_account ON journal_postings(account);",
+	}
+
+	for _, idx := range journalIndexes {
+		if _, err := db.Exec(idx); err != nil {
+			log.Fatal("Failed to create journal index:", err)
+		}
+	}
+
+	// Create point_lots table. Each credit of points is a lot that is
+	// consumed FIFO and expires at expires_at (stored in UTC).
+	createLotsTable := `
+	CREATE TABLE IF NOT EXISTS point_lots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		ledger_entry_id INTEGER,
+		amount INTEGER NOT NULL CHECK (amount > 0),
+		remaining INTEGER NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+		earned_at TEXT NOT NULL,
+		expires_at TEXT NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (ledger_entry_id) REFERENCES point_ledger(id)
+	);`
+
+	if _, err := db.Exec(createLotsTable); err != nil {
+		log.Fatal("Failed to create point_lots table:", err)
+	}
+
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_lots_user_expiry ON point_lots(user_id, expires_at);"); err != nil {
+		log.Fatal("Failed to create point_lots index:", err)
+	}
+
+	// Create transfer_confirmations table holding transfer previews that the
+	// sender confirms by ID before the transfer is made.
+	createConfirmationsTable := `
+	CREATE TABLE IF NOT EXISTS transfer_confirmations (
+		id TEXT PRIMARY KEY,
+		from_user_id INTEGER NOT NULL,
+		to_user_id INTEGER NOT NULL,
+		recipient_name TEXT NOT NULL,
+		amount INTEGER NOT NULL CHECK (amount > 0),
+		note TEXT,
+		created_at TEXT NOT NULL,
+		expires_at TEXT NOT NULL,
+		consumed_at TEXT,
+		FOREIGN KEY (from_user_id) REFERENCES users(id),
+		FOREIGN KEY (to_user_id) REFERENCES users(id)
+	);`
+
+	if _, err := db.Exec(createConfirmationsTable); err != nil {
+		log.Fatal("Failed to create transfer_confirmations table:", err)
+	}
+
+	// Create tier_history table recording every MembershipLevel change made
+	// by the tier engine.
+	createTierHistoryTable := `
+	CREATE TABLE IF NOT EXISTS tier_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		from_level TEXT,
+		to_level TEXT NOT NULL,
+		earned_in_window INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	if _, err := db.Exec(createTierHistoryTable); err != nil {
+		log.Fatal("Failed to create tier_history table:", err)
+	}
+
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_tier_history_user ON tier_history(user_id);"); err != nil {
+		log.Fatal("Failed to create tier_history index:", err)
+	}
+
+	// Create account_status_history table recording every suspension,
+	// reinstatement and closure.
+	createAccountStatusHistoryTable := `
+	CREATE TABLE IF NOT EXISTS account_status_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		from_status TEXT NOT NULL,
+		to_status TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	if _, err := db.Exec(createAccountStatusHistoryTable); err != nil {
+		log.Fatal("Failed to create account_status_history table:", err)
+	}
+
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_account_status_history_user ON account_status_history(user_id);"); err != nil {
+		log.Fatal("Failed to create account_status_history index:", err)
+	}
+
+	// Create audit_log table. Triggers reject UPDATE and DELETE so the log
+	// stays append-only.
+	createAuditTable := `
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		entity_type TEXT NOT NULL,
+		entity_id TEXT NOT NULL,
+		before_json TEXT,
+		after_json TEXT,
+		diff_json TEXT,
+		request_id TEXT,
+		ip TEXT,
+		created_at TEXT NOT NULL
+	);`
+
+	if _, err := db.Exec(createAuditTable); err != nil {
+		log.Fatal("Failed to create audit_log table:", err)
+	}
+
+	// Create audit indexes and immutability triggers
+	auditStatements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_log(actor);",
+		"CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_log(entity_type, entity_id);",
+		"CREATE INDEX IF NOT EXISTS idx_audit_created ON audit_log(created_at);",
+		`CREATE TRIGGER IF NOT EXISTS trg_audit_log_no_update BEFORE UPDATE ON audit_log
+		BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;`,
+		`CREATE TRIGGER IF NOT EXISTS trg_audit_log_no_delete BEFORE DELETE ON audit_log
+		BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;`,
+	}
+
+	for _, stmt := range auditStatements {
+		if _, err := db.Exec(stmt); err != nil {
+			log.Fatal("Failed to create audit index or trigger:", err)
+		}
+	}
+
+	// Create outbox_events table. Domain events are written here in the
+	// transaction that caused them and published by the dispatcher job.
+	createOutboxTable := `
+	CREATE TABLE IF NOT EXISTS outbox_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_name TEXT NOT NULL,
+		aggregate_type TEXT NOT NULL,
+		aggregate_id TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_attempt_at TEXT NOT NULL,
+		published_at TEXT,
+		created_at TEXT NOT NULL,
+		published_to TEXT
+	);`
+
+	if _, err := db.Exec(createOutboxTable); err != nil {
+		log.Fatal("Failed to create outbox_events table:", err)
+	}
+	// published_to lists the sinks that accepted an event still being retried
+	addColumnIfMissing("outbox_events", "published_to", "TEXT")
+	// status separates events still being retried from published ones and
+	// from dead ones, which ran out of attempts
+	addColumnIfMissing("outbox_events", "status", "TEXT NOT NULL DEFAULT 'pending'")
+
+	outboxStatements := []string{
+		`UPDATE outbox_events SET status = 'published' WHERE status = 'pending' AND published_at IS NOT NULL`,
+		`DROP INDEX IF EXISTS idx_outbox_due`,
+		`CREATE INDEX IF NOT EXISTS idx_outbox_status ON outbox_events(status, next_attempt_at)`,
+	}
+	for _, stmt := range outboxStatements {
+		if _, err := db.Exec(stmt); err != nil {
+			log.Fatal("Failed to migrate outbox_events:", err)
+		}
+	}
+
+	// Create webhook tables. Each published event becomes one delivery per
+	// subscription to it; the delivery row doubles as the delivery log.
+	webhookStatements := []string{
+		`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT NOT NULL,
+			event_types TEXT NOT NULL,
+			user_ids TEXT,
+			secret TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			subscription_id INTEGER NOT NULL,
+			event_id INTEGER NOT NULL,
+			event_name TEXT NOT NULL,
+			body TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER,
+			last_error TEXT,
+			next_attempt_at TEXT NOT NULL,
+			delivered_at TEXT,
+			created_at TEXT NOT NULL,
+			FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id)
+		);`,
+		// Publish inserts with ON CONFLICT DO NOTHING against this index, so
+		// a republished event is queued once per subscription
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(subscription_id, event_id);",
+		"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';",
+	}
+
+	for _, stmt := range webhookStatements {
+		if _, err := db.Exec(stmt); err != nil {
+			log.Fatal("Failed to create webhook table or index:", err)
+		}
+	}
+	addColumnIfMissing("webhook_subscriptions", "user_ids", "TEXT")
+
+	// Create notification_preferences table. Users without a row get the
+	// default preferences.
+	createNotificationPreferencesTable := `
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id INTEGER PRIMARY KEY,
+		email_enabled INTEGER NOT NULL,
+		sms_enabled INTEGER NOT NULL,
+		language TEXT NOT NULL CHECK (language IN ('th','en')),
+		muted TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	if _, err := db.Exec(createNotificationPreferencesTable); err != nil {
+		log.Fatal("Failed to create notification_preferences table:", err)
+	}
+}
+
+// createContactIndexes stops open accounts sharing a phone or email; closed
+// accounts free theirs. Duplicates left over from before normalization would
+// make creating an index fail, so a field with duplicates is reported with
+// the user IDs involved and left unindexed until they are merged or closed.
+func createContactIndexes() {
+	for _, idx := range []string{"DROP INDEX IF EXISTS idx_users_phone;", "DROP INDEX IF EXISTS idx_users_email;"} {
+		if _, err := db.Exec(idx); err != nil {
+			log.Fatal("Failed to drop user index:", err)
+		}
+	}
+
+	duplicates, err := adapter.FindDuplicateContacts(db)
+	if err != nil {
+		log.Fatal("Failed to check for duplicate user contacts:", err)
+	}
+	blocked := map[string]bool{}
+	for _, d := range duplicates {
+		log.Printf("Open users %v have the same %s; merge or close all but one", d.UserIDs, d.Field)
+		blocked[d.Field] = true
+	}
+
+	for _, field := range []string{"phone", "email"} {
+		if blocked[field] {
+			log.Printf("Not enforcing unique %s for open users until duplicates are resolved", field)
+			continue
+		}
+		idx := fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_open_%[1]s ON users(%[1]s) WHERE deleted_at IS NULL;", field)
+		if _, err := db.Exec(idx); err != nil {
+			log.Fatal("Failed to create user index:", err)
+		}
+	}
+}
+
+// addColumnIfMissing adds column to table when an older schema lacks it.
+func addColumnIfMissing(table, column, definition string) {
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		log.Fatal("Failed to inspect table:", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			log.Fatal("Failed to inspect table:", err)
+		}
+		if name == column {
+			return
+		}
+	}
+	rows.Close()
+
+	if _, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition); err != nil {
+		log.Fatalf("Failed to add column %s.%s: %v", table, column, err)
+	}
+}
+
+// recreateTableIfOutdated rebuilds table from createSQL when its stored
+// definition does not contain marker. SQLite cannot alter CHECK constraints
+// in place, so rows are copied into a new table that then replaces the old
+// one. It must run before the table's indexes are created.
+func recreateTableIfOutdated(table, marker, createSQL string) {
+	var current string
+	err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&current)
+	if err != nil {
+		log.Fatal("Failed to inspect table:", err)
+	}
+	if strings.Contains(current, marker) {
+		return
+	}
+
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		log.Fatal("Failed to inspect table:", err)
+	}
+	var columns []string
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			log.Fatal("Failed to inspect table:", err)
+		}
+		columns = append(columns, name)
+	}
+	rows.Close()
+
+	newTable := table + "_new"
+	columnList := strings.Join(columns, ", ")
+	statements := []string{
+		strings.Replace(createSQL, table+" (", newTable+" (", 1),
+		"INSERT INTO " + newTable + " (" + columnList + ") SELECT " + columnList + " FROM " + table,
+		"DROP TABLE " + table,
+		"ALTER TABLE " + newTable + " RENAME TO " + table,
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatal("Failed to start migration:", err)
+	}
+	defer tx.Rollback()
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			log.Fatalf("Failed to rebuild %s table: %v", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("Failed to rebuild %s table: %v", table, err)
+	}
+	log.Printf("Rebuilt %s table with updated constraints", table)
 }
 
 func insertSampleDataIfNeeded() {
@@ -132,30 +595,33 @@ func insertSampleDataIfNeeded() {
 	}
 }
 
-// insertSampleData adds initial sample users to the database
-func insertSampleData() {
-	sampleUsers := []domain.User{
+// sampleUsers are the users a new database starts with.
+func sampleUsers() []domain.User {
+	return []domain.User{
 		{
 			Name:            "สมชาย ใจดี",
-			Phone:           "081-234-5678",
+			Phone:           "+66812345678",
 			Email:           "somchai@example.com",
-			MemberSince:     "15/6/2566",
+			MemberSince:     "2023-06-15",
 			MembershipLevel: "Gold",
 			MemberID:        "LBK001234",
 			Points:          15420,
 		},
 		{
 			Name:            "สมหญิง ดีใจ",
-			Phone:           "081-567-8901",
+			Phone:           "+66815678901",
 			Email:           "somying@example.com",
-			MemberSince:     "20/7/2566",
+			MemberSince:     "2023-07-20",
 			MembershipLevel: "Silver",
 			MemberID:        "LBK001235",
 			Points:          8500,
 		},
 	}
+}
 
-	for _, user := range sampleUsers {
+// insertSampleData adds initial sample users to the database
+func insertSampleData() {
+	for _, user := range sampleUsers() {
 		_, err := db.Exec(`
 			INSERT INTO users (name, phone, email, member_since, membership_level, member_id, points)
 			VALUES (?, ?, ?, ?, ?, ?, ?)`,
@@ -166,22 +632,201 @@ func insertSampleData() {
 	}
 }
 
+func expiryPolicy(cfg config.Config) domain.ExpiryPolicy {
+	return domain.ExpiryPolicy{Months: cfg.Points.ExpiryMonths}
+}
+
+func memberIDFormat(cfg config.Config) domain.MemberIDFormat {
+	return domain.MemberIDFormat{Prefix: cfg.Members.IDPrefix, Digits: cfg.Members.IDDigits}
+}
+
+func tierPolicy(cfg config.Config) domain.TierPolicy {
+	rules := make([]domain.TierRule, 0, len(cfg.Tiers.Levels))
+	for _, level := range cfg.Tiers.Levels {
+		rules = append(rules, domain.TierRule{Name: level.Name, MinPoints: level.MinPoints})
+	}
+	return domain.NewTierPolicy(cfg.Tiers.WindowMonths, rules)
+}
+
+func dispatchPolicy(cfg config.Config) domain.DispatchPolicy {
+	return domain.DispatchPolicy{
+		Backoff:      domain.Backoff{Base: cfg.Events.RetryBase, Max: cfg.Events.RetryMax},
+		MaxAttempts:  cfg.Events.MaxAttempts,
+		ClaimTimeout: cfg.Events.ClaimTimeout,
+	}
+}
+
+func webhookPolicy(cfg config.Config) domain.WebhookPolicy {
+	return domain.WebhookPolicy{
+		Backoff:     domain.Backoff{Base: cfg.Webhooks.RetryBase, Max: cfg.Webhooks.RetryMax},
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+	}
+}
+
+// notifiers builds the email and SMS notifiers chosen by
+// notifications.email.driver and notifications.sms.driver.
+func notifiers(cfg config.Config) map[domain.NotificationChannel]port.Notifier {
+	notifiers := map[domain.NotificationChannel]port.Notifier{}
+	email := cfg.Notifications.Email
+	switch email.Driver {
+	case "smtp":
+		notifiers[domain.NotificationEmail] = adapter.NewSMTPNotifier(email.Host, email.Port, email.Username, email.Password, email.From)
+	case "log":
+		notifiers[domain.NotificationEmail] = adapter.LogNotifier{}
+	case "", "none":
+	default:
+		log.Fatalf("Unknown email driver %q", email.Driver)
+	}
+
+	sms := cfg.Notifications.SMS
+	switch sms.Driver {
+	case "http":
+		notifiers[domain.NotificationSMS] = adapter.NewHTTPSMSNotifier(sms.GatewayURL, sms.APIKey, sms.Sender, sms.Timeout)
+	case "log":
+		notifiers[domain.NotificationSMS] = adapter.LogNotifier{}
+	case "", "none":
+	default:
+		log.Fatalf("Unknown SMS driver %q", sms.Driver)
+	}
+	return notifiers
+}
+
+// eventSinks builds the sinks named by events.sinks.
+func eventSinks(cfg config.Config, webhooks *service.WebhookService, notifications *service.NotificationService) map[string]port.EventSink {
+	sinks := map[string]port.EventSink{}
+	for _, name := range cfg.Events.Sinks {
+		switch name {
+		case "log":
+			sinks[name] = adapter.LogEventSink{}
+		case "webhooks":
+			sinks[name] = webhooks
+		case "notifications":
+			sinks[name] = notifications
+		default:
+			log.Fatalf("Unknown event sink %q", name)
+		}
+	}
+	return sinks
+}
+
+// startJob runs job every interval for the lifetime of the process.
+func startJob(interval time.Duration, job func()) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			job()
+		}
+	}()
+}
+
+func runExpiryJob(expiry *service.ExpiryService) {
+	run, err := expiry.ExpireDue()
+	if err != nil {
+		log.Printf("Expiry job failed: %v", err)
+		return
+	}
+	if run.LotsExpired > 0 {
+		log.Printf("Expiry job expired %d points from %d lots across %d users",
+			run.PointsExpired, run.LotsExpired, run.UsersAffected)
+	}
+	if run.UsersFailed > 0 {
+		log.Printf("Expiry job failed for %d users; they will be retried on the next run", run.UsersFailed)
+	}
+}
+
+func runTierJob(tiers *service.TierService) {
+	run, err := tiers.EvaluateAll(domain.TierChangeReasonScheduled)
+	if err != nil {
+		log.Printf("Tier job failed: %v", err)
+		return
+	}
+	if run.Upgraded+run.Downgraded > 0 {
+		log.Printf("Tier job upgraded %d and downgraded %d of %d users",
+			run.Upgraded, run.Downgraded, run.UsersEvaluated)
+	}
+	if run.UsersFailed > 0 {
+		log.Printf("Tier job failed for %d users; they will be retried on the next run", run.UsersFailed)
+	}
+}
+
+func runDispatchJob(dispatcher *service.EventDispatcher) {
+	run, err := dispatcher.DispatchDue(context.Background())
+	if err != nil {
+		log.Printf("Event dispatcher failed: %v", err)
+		return
+	}
+	if run.Failed+run.Dead > 0 {
+		log.Printf("Event dispatcher published %d events; %d will be retried and %d are dead", run.Published, run.Failed, run.Dead)
+	}
+}
+
+func runWebhookJob(webhooks *service.WebhookService) {
+	run, err := webhooks.DeliverDue(context.Background())
+	if err != nil {
+		log.Printf("Webhook job failed: %v", err)
+		return
+	}
+	if run.Retrying+run.Dead > 0 {
+		log.Printf("Webhook job delivered %d; %d will be retried and %d are dead", run.Delivered, run.Retrying, run.Dead)
+	}
+}
+
+// Address returns the host:port the server listens on.
+func Address(cfg config.Config) string {
+	return fmt.Sprintf(":%d", cfg.Server.Port)
+}
+
 // SetupServer initializes the fiber server with all dependencies
-func SetupServer(db *sql.DB) *fiber.App {
-	// Initialize repositories
-	userRepo := adapter.NewSqliteUserRepository(db)
-	transferRepo := adapter.NewSqliteTransferRepository(db)
-	ledgerRepo := adapter.NewSqlitePointLedgerRepository(db)
+func SetupServer(storage Storage, cfg config.Config) *fiber.App {
+	userRepo := storage.Users
+	transferRepo := storage.Transfers
+	ledgerRepo := storage.Ledger
+	journalRepo := storage.Journal
+	lotRepo := storage.Lots
+	tierRepo := storage.Tiers
+	statusRepo := storage.StatusHistory
+	auditRepo := storage.Audit
+	transactor := storage.Transactor
+	clock := adapter.SystemClock{}
+	userEvents := adapter.NewUserEventBroker(cfg.Streams.BufferSize, cfg.Streams.Retention, clock)
 
 	// Initialize services
-	userService := service.NewUserService(userRepo)
-	transferService := service.NewTransferService(transferRepo, ledgerRepo, userRepo, db)
+	auditService := service.NewAuditService(auditRepo)
+	ledgerService := service.NewLedgerService(ledgerRepo, journalRepo)
+	userService := service.NewUserService(userRepo, transactor, expiryPolicy(cfg), tierPolicy(cfg), memberIDFormat(cfg), clock)
+	transferService := service.NewStreamingTransferService(
+		service.NewTransferService(transferRepo, ledgerRepo, userRepo, transactor, clock, adapter.UUIDGenerator{}), ledgerRepo, userEvents)
+	tierService := service.NewTierService(userRepo, ledgerRepo, tierRepo, transactor, tierPolicy(cfg), clock)
+	pointsService := service.NewStreamingPointsService(
+		service.NewTieredPointsService(service.NewPointsService(transactor, expiryPolicy(cfg), clock), tierService), userEvents)
+	userStreamService := service.NewUserStreamService(userRepo, ledgerRepo, userEvents, clock)
+	expiryService := service.NewExpiryService(lotRepo, userRepo, transactor, clock)
+	webhookService := service.NewWebhookService(storage.Webhooks, adapter.NewHTTPWebhookSender(cfg.Webhooks.Timeout), clock,
+		webhookPolicy(cfg), cfg.Webhooks.BatchSize)
+	notificationService := service.NewNotificationService(userRepo, storage.Notifications, notifiers(cfg), clock)
+	sinks := eventSinks(cfg, webhookService, notificationService)
+	// Streams rely on the outbox for changes made outside requests, so this
+	// sink is not optional
+	sinks["streams"] = userStreamService
+	dispatcher := service.NewEventDispatcher(storage.Outbox, sinks, clock, dispatchPolicy(cfg), cfg.Events.BatchSize)
+	accountService := service.NewAccountService(userRepo, statusRepo, transactor, clock)
 
 	// Initialize handlers
 	userHandler := handler.NewUserHandler(userService)
 	transferHandler := handler.NewTransferHandler(transferService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	ledgerHandler := handler.NewLedgerHandler(ledgerService)
+	pointsHandler := handler.NewPointsHandler(pointsService, expiryService)
+	tierHandler := handler.NewTierHandler(tierService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	eventHandler := handler.NewEventHandler(dispatcher)
+	userStreamHandler := handler.NewUserStreamHandler(userStreamService, cfg.Streams.HeartbeatInterval)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
 
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(handler.RequestMeta())
 
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("hello world")
@@ -190,6 +835,28 @@ func SetupServer(db *sql.DB) *fiber.App {
 	// Register routes
 	userHandler.RegisterRoutes(app)
 	transferHandler.RegisterRoutes(app)
+	auditHandler.RegisterRoutes(app)
+	ledgerHandler.RegisterRoutes(app)
+	pointsHandler.RegisterRoutes(app)
+	tierHandler.RegisterRoutes(app)
+	accountHandler.RegisterRoutes(app)
+	webhookHandler.RegisterRoutes(app)
+	eventHandler.RegisterRoutes(app)
+	userStreamHandler.RegisterRoutes(app)
+	notificationHandler.RegisterRoutes(app)
+
+	if cfg.Points.ExpiryJobInterval > 0 {
+		startJob(cfg.Points.ExpiryJobInterval, func() { runExpiryJob(expiryService) })
+	}
+	if cfg.Tiers.EvaluationInterval > 0 {
+		startJob(cfg.Tiers.EvaluationInterval, func() { runTierJob(tierService) })
+	}
+	if cfg.Events.DispatchInterval > 0 {
+		startJob(cfg.Events.DispatchInterval, func() { runDispatchJob(dispatcher) })
+	}
+	if cfg.Webhooks.DeliveryInterval > 0 {
+		startJob(cfg.Webhooks.DeliveryInterval, func() { runWebhookJob(webhookService) })
+	}
 
 	return app
 }
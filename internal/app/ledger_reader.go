@@ -0,0 +1,67 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"workshop4-backend/internal/adapter"
+	"workshop4-backend/internal/config"
+	"workshop4-backend/internal/port"
+)
+
+// OpenLedgerReadOnly opens the point ledger of the database chosen by
+// database.driver for verification. Unlike OpenStorage it runs no
+// migrations, backfills or seeding and opens the database read-only, so a
+// check can never change what it checks. It fails when the database or the
+// ledger's hash column does not exist.
+func OpenLedgerReadOnly(cfg config.Config) (port.PointLedgerRepository, func() error, error) {
+	switch cfg.Database.Driver {
+	case "", "sqlite3":
+		if _, err := os.Stat(cfg.Database.Path); err != nil {
+			return nil, nil, err
+		}
+		db, err := sql.Open("sqlite3", "file:"+cfg.Database.Path+"?mode=ro")
+		if err != nil {
+			return nil, nil, err
+		}
+		err = requireHashColumn(db, `SELECT COUNT(*) FROM pragma_table_info('point_ledger') WHERE name = 'hash'`)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return adapter.NewSqlitePointLedgerRepository(db), db.Close, nil
+	case "postgres":
+		pgConfig, err := pgx.ParseConfig(cfg.Database.DSN)
+		if err != nil {
+			return nil, nil, err
+		}
+		pgConfig.RuntimeParams["default_transaction_read_only"] = "on"
+		db := stdlib.OpenDB(*pgConfig)
+		err = requireHashColumn(db, `SELECT COUNT(*) FROM information_schema.columns WHERE table_name = 'point_ledger' AND column_name = 'hash'`)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return adapter.NewPostgresPointLedgerRepository(db), db.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("database driver %q has no stored ledger to verify", cfg.Database.Driver)
+	}
+}
+
+// requireHashColumn fails unless query, which counts the point_ledger hash
+// columns, finds one.
+func requireHashColumn(db *sql.DB, query string) error {
+	var n int
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("point_ledger has no hash column")
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package app
+
+import (
+	"database/sql"
+	"log"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"workshop4-backend/internal/adapter"
+	"workshop4-backend/internal/config"
+)
+
+// postgresSchema mirrors the SQLite tables with native identity columns,
+// TIMESTAMPTZ timestamps and enforced foreign keys. Every statement is
+// idempotent, so it runs on each start.
+var postgresSchema = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		name TEXT NOT NULL,
+		phone TEXT,
+		email TEXT,
+		member_since TEXT,
+		membership_level TEXT,
+		member_id TEXT UNIQUE,
+		points INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT date_trunc('second', now()),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT date_trunc('second', now()),
+		version INTEGER NOT NULL DEFAULT 1,
+		status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active','suspended','closed')),
+		deleted_at TIMESTAMPTZ
+	)`,
+	// Open accounts may not share a phone or email; closed accounts free theirs
+	`DROP INDEX IF EXISTS idx_users_phone`,
+	`DROP INDEX IF EXISTS idx_users_email`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_open_phone ON users(phone) WHERE deleted_at IS NULL`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_open_email ON users(email) WHERE deleted_at IS NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_users_member_since ON users(member_since)`,
+
+	`CREATE TABLE IF NOT EXISTS sequences (
+		name TEXT PRIMARY KEY,
+		value INTEGER NOT NULL
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS transfers (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		from_user_id INTEGER NOT NULL REFERENCES users(id),
+		to_user_id INTEGER NOT NULL REFERENCES users(id),
+		amount INTEGER NOT NULL CHECK (amount > 0),
+		status TEXT NOT NULL CHECK (status IN ('pending','processing','completed','failed','cancelled','reversed')),
+		note TEXT,
+		idempotency_key TEXT NOT NULL UNIQUE,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		completed_at TIMESTAMPTZ,
+		fail_reason TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_transfers_from ON transfers(from_user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_transfers_to ON transfers(to_user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_transfers_created ON transfers(created_at)`,
+
+	`CREATE TABLE IF NOT EXISTS point_ledger (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		change INTEGER NOT NULL,
+		balance_after INTEGER NOT NULL,
+		event_type TEXT NOT NULL CHECK (event_type IN ('transfer_out','transfer_in','adjust','earn','redeem','expire','forfeit')),
+		transfer_id INTEGER REFERENCES transfers(id),
+		reference TEXT,
+		metadata TEXT,
+		created_at TIMESTAMPTZ NOT NULL,
+		prev_hash TEXT,
+		hash TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_ledger_user ON point_ledger(user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_ledger_transfer ON point_ledger(transfer_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_ledger_created ON point_ledger(created_at)`,
+
+	`CREATE TABLE IF NOT EXISTS journal_entries (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		entry_type TEXT NOT NULL CHECK (entry_type IN ('opening','transfer','earn','redeem','adjust','expire','forfeit')),
+		transfer_id INTEGER REFERENCES transfers(id),
+		reference TEXT,
+		created_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS journal_postings (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		journal_entry_id INTEGER NOT NULL REFERENCES journal_entries(id),
+		account TEXT NOT NULL,
+		amount INTEGER NOT NULL CHECK (amount != 0)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_journal_entries_transfer ON journal_entries(transfer_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_journal_postings_entry ON journal_postings(journal_entry_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_journal_postings_account ON journal_postings(account)`,
+
+	`CREATE TABLE IF NOT EXISTS point_lots (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		ledger_entry_id INTEGER REFERENCES point_ledger(id),
+		amount INTEGER NOT NULL CHECK (amount > 0),
+		remaining INTEGER NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+		earned_at TIMESTAMPTZ NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_lots_user_expiry ON point_lots(user_id, expires_at)`,
+
+	`CREATE TABLE IF NOT EXISTS transfer_confirmations (
+		id TEXT PRIMARY KEY,
+		from_user_id INTEGER NOT NULL REFERENCES users(id),
+		to_user_id INTEGER NOT NULL REFERENCES users(id),
+		recipient_name TEXT NOT NULL,
+		amount INTEGER NOT NULL CHECK (amount > 0),
+		note TEXT,
+		created_at TIMESTAMPTZ NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		consumed_at TIMESTAMPTZ
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS tier_history (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		from_level TEXT,
+		to_level TEXT NOT NULL,
+		earned_in_window INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_tier_history_user ON tier_history(user_id)`,
+
+	`CREATE TABLE IF NOT EXISTS account_status_history (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		from_status TEXT NOT NULL,
+		to_status TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_account_status_history_user ON account_status_history(user_id)`,
+
+	// The trigger rejects UPDATE and DELETE so the log stays append-only
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		entity_type TEXT NOT NULL,
+		entity_id TEXT NOT NULL,
+		before_json TEXT,
+		after_json TEXT,
+		diff_json TEXT,
+		request_id TEXT,
+		ip TEXT,
+		created_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_log(actor)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_log(entity_type, entity_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_created ON audit_log(created_at)`,
+	`CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_log is append-only';
+	END;
+	$$ LANGUAGE plpgsql`,
+	`CREATE OR REPLACE TRIGGER trg_audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+		FOR EACH ROW EXECUTE FUNCTION audit_log_append_only()`,
+
+	`CREATE TABLE IF NOT EXISTS outbox_events (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		event_name TEXT NOT NULL,
+		aggregate_type TEXT NOT NULL,
+		aggregate_id TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_attempt_at TIMESTAMPTZ NOT NULL,
+		published_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL,
+		published_to TEXT
+	)`,
+	`ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS published_to TEXT`,
+	`ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'pending'`,
+	`UPDATE outbox_events SET status = 'published' WHERE status = 'pending' AND published_at IS NOT NULL`,
+	`DROP INDEX IF EXISTS idx_outbox_due`,
+	`CREATE INDEX IF NOT EXISTS idx_outbox_status ON outbox_events(status, next_attempt_at)`,
+
+	`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		url TEXT NOT NULL,
+		event_types TEXT NOT NULL,
+		user_ids TEXT,
+		secret TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	)`,
+	`ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS user_ids TEXT`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions(id),
+		event_id INTEGER NOT NULL,
+		event_name TEXT NOT NULL,
+		body TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		response_status INTEGER,
+		last_error TEXT,
+		next_attempt_at TIMESTAMPTZ NOT NULL,
+		delivered_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(subscription_id, event_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'`,
+
+	`CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id INTEGER PRIMARY KEY REFERENCES users(id),
+		email_enabled BOOLEAN NOT NULL,
+		sms_enabled BOOLEAN NOT NULL,
+		language TEXT NOT NULL CHECK (language IN ('th','en')),
+		muted TEXT NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	)`,
+}
+
+// InitPostgres connects to database.dsn and creates any missing tables.
+func InitPostgres(cfg config.Config) *sql.DB {
+	db, err := sql.Open("pgx", cfg.Database.DSN)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := db.Ping(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	if err := CreatePostgresSchema(db); err != nil {
+		log.Fatal("Failed to create tables:", err)
+	}
+	return db
+}
+
+// CreatePostgresSchema creates any missing tables, indexes and triggers.
+func CreatePostgresSchema(db *sql.DB) error {
+	for _, stmt := range postgresSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewPostgresStorage(db *sql.DB) Storage {
+	return Storage{
+		Users:         adapter.NewPostgresUserRepository(db),
+		Transfers:     adapter.NewPostgresTransferRepository(db),
+		Ledger:        adapter.NewPostgresPointLedgerRepository(db),
+		Journal:       adapter.NewPostgresJournalRepository(db),
+		Lots:          adapter.NewPostgresLotRepository(db),
+		Tiers:         adapter.NewPostgresTierRepository(db),
+		StatusHistory: adapter.NewPostgresAccountStatusRepository(db),
+		Audit:         adapter.NewPostgresAuditRepository(db),
+		Outbox:        adapter.NewPostgresOutboxRepository(db),
+		Webhooks:      adapter.NewPostgresWebhookRepository(db),
+		Notifications: adapter.NewPostgresNotificationPreferenceRepository(db),
+		Transactor:    adapter.NewPostgresTransactor(db),
+		Close:         db.Close,
+	}
+}
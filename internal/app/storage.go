@@ -0,0 +1,126 @@
+package app
+
+import (
+	"database/sql"
+	"log"
+
+	"workshop4-backend/internal/adapter"
+	"workshop4-backend/internal/adapter/memory"
+	"workshop4-backend/internal/config"
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// Storage is the set of repositories the server runs on.
+type Storage struct {
+	Users         port.UserRepository
+	Transfers     port.TransferRepository
+	Ledger        port.PointLedgerRepository
+	Journal       port.JournalRepository
+	Lots          port.LotRepository
+	Tiers         port.TierRepository
+	StatusHistory port.AccountStatusRepository
+	Audit         port.AuditRepository
+	Outbox        port.OutboxRepository
+	Webhooks      port.WebhookRepository
+	Notifications port.NotificationPreferenceRepository
+	Transactor    port.Transactor
+	// Close releases the underlying database.
+	Close func() error
+}
+
+// OpenStorage opens the storage chosen by database.driver: "sqlite3" (the
+// default), "postgres", which connects to database.dsn, or "memory", which
+// starts from the sample users and forgets everything on exit.
+func OpenStorage(cfg config.Config) Storage {
+	switch cfg.Database.Driver {
+	case "", "sqlite3":
+		return NewSqliteStorage(InitDatabase(cfg))
+	case "postgres":
+		storage := NewPostgresStorage(InitPostgres(cfg))
+		seedIfEmpty(storage, cfg)
+		return storage
+	case "memory":
+		return NewMemoryStorage(cfg)
+	default:
+		log.Fatalf("Unknown database driver %q", cfg.Database.Driver)
+		return Storage{}
+	}
+}
+
+func NewSqliteStorage(db *sql.DB) Storage {
+	return Storage{
+		Users:         adapter.NewSqliteUserRepository(db),
+		Transfers:     adapter.NewSqliteTransferRepository(db),
+		Ledger:        adapter.NewSqlitePointLedgerRepository(db),
+		Journal:       adapter.NewSqliteJournalRepository(db),
+		Lots:          adapter.NewSqliteLotRepository(db),
+		Tiers:         adapter.NewSqliteTierRepository(db),
+		StatusHistory: adapter.NewSqliteAccountStatusRepository(db),
+		Audit:         adapter.NewSqliteAuditRepository(db),
+		Outbox:        adapter.NewSqliteOutboxRepository(db),
+		Webhooks:      adapter.NewSqliteWebhookRepository(db),
+		Notifications: adapter.NewSqliteNotificationPreferenceRepository(db),
+		Transactor:    adapter.NewSqliteTransactor(db),
+		Close:         db.Close,
+	}
+}
+
+// NewMemoryStorage returns in-memory storage holding the sample users.
+func NewMemoryStorage(cfg config.Config) Storage {
+	store := memory.NewStore()
+	storage := Storage{
+		Users:         memory.NewUserRepository(store),
+		Transfers:     memory.NewTransferRepository(store),
+		Ledger:        memory.NewPointLedgerRepository(store),
+		Journal:       memory.NewJournalRepository(store),
+		Lots:          memory.NewLotRepository(store),
+		Tiers:         memory.NewTierRepository(store),
+		StatusHistory: memory.NewAccountStatusRepository(store),
+		Audit:         memory.NewAuditRepository(store),
+		Outbox:        memory.NewOutboxRepository(store),
+		Webhooks:      memory.NewWebhookRepository(store),
+		Notifications: memory.NewNotificationPreferenceRepository(store),
+		Transactor:    memory.NewTransactor(store),
+		Close:         func() error { return nil },
+	}
+
+	seedIfEmpty(storage, cfg)
+	log.Printf("Using in-memory storage; data is lost on exit")
+	return storage
+}
+
+// seedIfEmpty gives storage without users the sample users, with their
+// opening balances booked like the SQLite backfills do.
+func seedIfEmpty(storage Storage, cfg config.Config) {
+	users, err := storage.Users.GetAll()
+	if err != nil {
+		log.Fatal("Failed to check for users:", err)
+	}
+	if len(users) > 0 {
+		return
+	}
+
+	now := adapter.SystemClock{}.Now()
+	policy := expiryPolicy(cfg)
+	err = storage.Transactor.WithinTransaction(func(repos port.Repositories) error {
+		for _, user := range sampleUsers() {
+			user.CreatedAt, user.UpdatedAt = now, now
+			if err := repos.Users.Create(&user); err != nil {
+				return err
+			}
+			entry := domain.NewJournalEntry(domain.JournalEntryOpening, domain.AccountIssuance, domain.MemberAccount(user.ID), user.Points, now)
+			if err := repos.Journal.Create(entry); err != nil {
+				return err
+			}
+			lot := &domain.PointLot{UserID: user.ID, Amount: user.Points, Remaining: user.Points, EarnedAt: now, ExpiresAt: policy.ExpiresAt(now)}
+			if err := repos.Lots.Create(lot); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal("Failed to seed sample users:", err)
+	}
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// AccountStatusChange records a change of a member's account status, such as
+// a fraud freeze, with who made it and why.
+type AccountStatusChange struct {
+	ID         int           `json:"id" db:"id"`
+	UserID     int           `json:"userId" db:"user_id"`
+	FromStatus AccountStatus `json:"fromStatus" db:"from_status"`
+	ToStatus   AccountStatus `json:"toStatus" db:"to_status"`
+	Reason     string        `json:"reason" db:"reason"`
+	Actor      string        `json:"actor" db:"actor"`
+	CreatedAt  time.Time     `json:"createdAt" db:"created_at"`
+}
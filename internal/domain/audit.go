@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionUserCreate     AuditAction = "user.create"
+	AuditActionUserUpdate     AuditAction = "user.update"
+	AuditActionUserDelete     AuditAction = "user.delete"
+	AuditActionUserClose      AuditAction = "user.close"
+	AuditActionUserSuspend    AuditAction = "user.suspend"
+	AuditActionUserUnsuspend  AuditAction = "user.unsuspend"
+	AuditActionTransferCreate AuditAction = "transfer.create"
+	AuditActionPointsEarn     AuditAction = "points.earn"
+	AuditActionPointsRedeem   AuditAction = "points.redeem"
+)
+
+const (
+	AuditEntityUser        = "user"
+	AuditEntityTransfer    = "transfer"
+	AuditEntityLedgerEntry = "ledger_entry"
+)
+
+// AuditEntry is an immutable record of a state-changing operation.
+// Before, After and Diff hold JSON snapshots of the affected entity.
+type AuditEntry struct {
+	ID         int         `json:"id" db:"id"`
+	Actor      string      `json:"actor" db:"actor"`
+	Action     AuditAction `json:"action" db:"action"`
+	EntityType string      `json:"entityType" db:"entity_type"`
+	EntityID   string      `json:"entityId" db:"entity_id"`
+	Before     *string     `json:"before,omitempty" db:"before_json"`
+	After      *string     `json:"after,omitempty" db:"after_json"`
+	Diff       *string     `json:"diff,omitempty" db:"diff_json"`
+	RequestID  string      `json:"requestId" db:"request_id"`
+	IP         string      `json:"ip" db:"ip"`
+	CreatedAt  time.Time   `json:"createdAt" db:"created_at"`
+}
+
+// RequestMeta identifies who triggered an operation and where it came from.
+type RequestMeta struct {
+	Actor     string
+	RequestID string
+	IP        string
+}
+
+type requestMetaKey struct{}
+
+// WithRequestMeta returns a copy of ctx carrying meta.
+func WithRequestMeta(ctx context.Context, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+// RequestMetaFromContext returns the RequestMeta stored in ctx. Operations
+// started outside an HTTP request are attributed to the "system" actor.
+func RequestMetaFromContext(ctx context.Context) RequestMeta {
+	if ctx != nil {
+		if meta, ok := ctx.Value(requestMetaKey{}).(RequestMeta); ok {
+			return meta
+		}
+	}
+	return RequestMeta{Actor: "system"}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+var (
+	ErrInvalidPhone = errors.New("must be a Thai mobile number such as 081-234-5678 or +66812345678")
+	ErrInvalidEmail = errors.New("must be a valid email address")
+)
+
+// NormalizePhone converts a Thai mobile number written as 08x-xxx-xxxx,
+// 0xxxxxxxxx, +66xxxxxxxxx or 66xxxxxxxxx to E.164, e.g. "+66812345678".
+// Spaces, dashes, dots and parentheses are ignored.
+func NormalizePhone(phone string) (string, error) {
+	var digits strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0, r == ' ', r == '-', r == '.', r == '(', r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	national := digits.String()
+	switch {
+	case len(national) == 10 && national[0] == '0':
+		national = national[1:]
+	case len(national) == 11 && strings.HasPrefix(national, "66"):
+		national = national[2:]
+	default:
+		return "", ErrInvalidPhone
+	}
+
+	// Thai mobile numbers start with 6, 8 or 9 after the trunk prefix
+	if national[0] != '6' && national[0] != '8' && national[0] != '9' {
+		return "", ErrInvalidPhone
+	}
+	return "+66" + national, nil
+}
+
+// PhoneSearchTerm rewrites a phone fragment typed in local form, such as
+// "081-234" or "0812345678", to match numbers stored in E.164 ("6681234").
+// Separators are dropped; it reports false for terms that are not phone
+// fragments. Short runs of digits such as "0012" are left alone, since they
+// may be part of a member ID (LBK001234), unless written with separators.
+func PhoneSearchTerm(term string) (string, bool) {
+	var digits strings.Builder
+	separated := false
+	for i, r := range term {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == '-', r == '.', r == '(', r == ')':
+			separated = true
+		default:
+			return "", false
+		}
+	}
+	fragment := digits.String()
+	if fragment == "" {
+		return "", false
+	}
+	if fragment[0] == '0' && (separated || len(fragment) >= 9) {
+		fragment = "66" + fragment[1:]
+	}
+	return fragment, true
+}
+
+// NormalizeEmail trims and lower-cases an email address and checks its
+// syntax. Display names ("Somchai <somchai@example.com>") are rejected.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	at := strings.LastIndex(email, "@")
+	if !strings.Contains(email[at+1:], ".") {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
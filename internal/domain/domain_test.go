@@ -2,6 +2,7 @@ package domain
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -48,6 +49,44 @@ func TestUser_Validation(t *testing.T) {
 			},
 			isValid: false,
 		},
+		{
+			name: "malformed phone",
+			user: User{
+				Name:  "Test User",
+				Email: "test@example.com",
+				Phone: "abc",
+			},
+			isValid: false,
+		},
+		{
+			name: "malformed email",
+			user: User{
+				Name:  "Test User",
+				Email: "test@example",
+				Phone: "081-234-5678",
+			},
+			isValid: false,
+		},
+		{
+			name: "Buddhist-era member_since",
+			user: User{
+				Name:        "Test User",
+				Email:       "test@example.com",
+				Phone:       "081-234-5678",
+				MemberSince: "15/6/2566",
+			},
+			isValid: true,
+		},
+		{
+			name: "malformed member_since",
+			user: User{
+				Name:        "Test User",
+				Email:       "test@example.com",
+				Phone:       "081-234-5678",
+				MemberSince: "June 2023",
+			},
+			isValid: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -126,3 +165,468 @@ func TestTransfer_Validation(t *testing.T) {
 		})
 	}
 }
+
+func buildLedgerChain(n int) []PointLedger {
+	entries := make([]PointLedger, n)
+	prevHash := ""
+	balance := 1000
+	for i := range entries {
+		balance -= 10
+		entries[i] = PointLedger{
+			ID:           i + 1,
+			UserID:       1,
+			Change:       -10,
+			BalanceAfter: balance,
+			EventType:    EventTypeTransferOut,
+			CreatedAt:    time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC),
+			PrevHash:     prevHash,
+		}
+		entries[i].Hash = entries[i].ComputeHash(prevHash)
+		prevHash = entries[i].Hash
+	}
+	return entries
+}
+
+func TestVerifyLedgerChain(t *testing.T) {
+	t.Run("intact chain", func(t *testing.T) {
+		checked, brk := VerifyLedgerChain(buildLedgerChain(3))
+		assert.Equal(t, 3, checked)
+		assert.Nil(t, brk)
+	})
+
+	t.Run("tampered content", func(t *testing.T) {
+		entries := buildLedgerChain(3)
+		entries[1].Change = -1000
+		checked, brk := VerifyLedgerChain(entries)
+		assert.Equal(t, 2, checked)
+		if assert.NotNil(t, brk) {
+			assert.Equal(t, 2, brk.EntryID)
+			assert.Equal(t, "entry content does not match its hash", brk.Reason)
+		}
+	})
+
+	t.Run("deleted entry", func(t *testing.T) {
+		entries := buildLedgerChain(3)
+		entries = append(entries[:1], entries[2:]...)
+		_, brk := VerifyLedgerChain(entries)
+		if assert.NotNil(t, brk) {
+			assert.Equal(t, 3, brk.EntryID)
+			assert.Equal(t, "previous hash does not match preceding entry", brk.Reason)
+		}
+	})
+
+	t.Run("missing hash", func(t *testing.T) {
+		entries := buildLedgerChain(3)
+		entries[2].Hash = ""
+		checked, brk := VerifyLedgerChain(entries)
+		assert.Equal(t, 3, checked)
+		if assert.NotNil(t, brk) {
+			assert.Equal(t, 3, brk.EntryID)
+			assert.Equal(t, "entry has no hash", brk.Reason)
+		}
+	})
+
+	t.Run("hash ignores time zone", func(t *testing.T) {
+		entry := buildLedgerChain(1)[0]
+		bangkok := entry
+		bangkok.CreatedAt = entry.CreatedAt.In(time.FixedZone("ICT", 7*60*60))
+		assert.Equal(t, entry.ComputeHash(""), bangkok.ComputeHash(""))
+	})
+}
+
+func TestJournalEntry_Validation(t *testing.T) {
+	now := time.Now()
+
+	assert.NoError(t, NewJournalEntry(JournalEntryEarn, AccountIssuance, MemberAccount(1), 100, now).Validate())
+
+	unbalanced := NewJournalEntry(JournalEntryEarn, AccountIssuance, MemberAccount(1), 100, now)
+	unbalanced.Postings[1].Amount = 90
+	assert.EqualError(t, unbalanced.Validate(), "journal entry is not balanced")
+
+	single := &JournalEntry{Postings: []Posting{{Account: MemberAccount(1), Amount: 100}}}
+	assert.Error(t, single.Validate())
+
+	zero := NewJournalEntry(JournalEntryTransfer, MemberAccount(1), MemberAccount(2), 0, now)
+	assert.Error(t, zero.Validate())
+}
+
+func TestNewTrialBalance(t *testing.T) {
+	tb := NewTrialBalance([]AccountBalance{
+		{Account: MemberAccount(1), Balance: 700},
+		{Account: MemberAccount(2), Balance: 300},
+		{Account: AccountIssuance, Balance: -1100},
+		{Account: AccountRedemption, Balance: 100},
+	})
+	assert.True(t, tb.Balanced)
+	assert.Equal(t, 0, tb.Total)
+
+	tb = NewTrialBalance([]AccountBalance{{Account: MemberAccount(1), Balance: 5}})
+	assert.False(t, tb.Balanced)
+	assert.Equal(t, 5, tb.Total)
+}
+
+func TestConsumeFIFO(t *testing.T) {
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	lots := []PointLot{
+		{ID: 1, Remaining: 100, ExpiresAt: jan},
+		{ID: 2, Remaining: 0, ExpiresAt: jan},
+		{ID: 3, Remaining: 50, ExpiresAt: feb},
+	}
+
+	assert.Equal(t, []LotConsumption{
+		{LotID: 1, Amount: 100, Remaining: 0, ExpiresAt: jan},
+		{LotID: 3, Amount: 20, Remaining: 30, ExpiresAt: feb},
+	}, ConsumeFIFO(lots, 120))
+
+	assert.Equal(t, []LotConsumption{
+		{LotID: 1, Amount: 40, Remaining: 60, ExpiresAt: jan},
+	}, ConsumeFIFO(lots, 40))
+
+	// A shortfall is left untracked rather than failing
+	consumed := ConsumeFIFO(lots, 500)
+	assert.Len(t, consumed, 2)
+}
+
+func TestExpiryPolicy_ExpiresAt(t *testing.T) {
+	earned := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2027, 1, 15, 10, 0, 0, 0, time.UTC), ExpiryPolicy{Months: 24}.ExpiresAt(earned))
+}
+
+func TestTierPolicy_Progress(t *testing.T) {
+	policy := NewTierPolicy(12, []TierRule{
+		{Name: "Gold", MinPoints: 15000},
+		{Name: "Bronze", MinPoints: 0},
+		{Name: "Silver", MinPoints: 5000},
+	})
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		earned        int
+		wantQualified string
+		wantNext      string
+		wantToNext    int
+		wantPercent   int
+	}{
+		{"no earnings", 0, "Bronze", "Silver", 5000, 0},
+		{"halfway to gold", 10000, "Silver", "Gold", 5000, 50},
+		{"top tier", 20000, "Gold", "", 0, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := policy.Progress("Bronze", tt.earned, now)
+			assert.Equal(t, tt.wantQualified, progress.QualifiedTier)
+			if tt.wantNext == "" {
+				assert.Nil(t, progress.NextTier)
+			} else if assert.NotNil(t, progress.NextTier) {
+				assert.Equal(t, tt.wantNext, *progress.NextTier)
+			}
+			assert.Equal(t, tt.wantToNext, progress.PointsToNextTier)
+			assert.Equal(t, tt.wantPercent, progress.ProgressPercent)
+			assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), progress.WindowStart)
+		})
+	}
+
+	assert.Equal(t, 2, policy.Rank("gold"))
+	assert.Equal(t, -1, policy.Rank("Diamond"))
+}
+
+func TestMemberIDFormat(t *testing.T) {
+	format := MemberIDFormat{Prefix: "LBK", Digits: 6}
+
+	assert.Equal(t, 3, LuhnCheckDigit("7992739871"))
+	assert.Equal(t, "LBK0000018", format.Generate(1))
+	assert.Equal(t, "LBK0012344", format.Generate(1234))
+
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{"generated", "LBK0012344", true},
+		{"wrong check digit", "LBK0012340", false},
+		{"non-digit", "LBK00123X4", false},
+		{"legacy format", "LBK001234", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, format.CheckDigitValid(tt.id))
+		})
+	}
+
+	assert.Equal(t, "LBK0012344", NormalizeMemberID(" lbk-001234 4"))
+}
+
+func TestRecipientIdentifier_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      RecipientIdentifier
+		want    string
+		wantErr bool
+	}{
+		{"local phone", RecipientIdentifier{Type: RecipientTypePhone, Value: "081-234-5678"}, "+66812345678", false},
+		{"international phone", RecipientIdentifier{Type: RecipientTypePhone, Value: "+66 81 234 5678"}, "+66812345678", false},
+		{"email", RecipientIdentifier{Type: RecipientTypeEmail, Value: " Somchai@Example.COM "}, "somchai@example.com", false},
+		{"member ID", RecipientIdentifier{Type: RecipientTypeMemberID, Value: "lbk-001234"}, "LBK001234", false},
+		{"blank value", RecipientIdentifier{Type: RecipientTypePhone, Value: "--"}, "", true},
+		{"unknown type", RecipientIdentifier{Type: "nickname", Value: "Chai"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.in.Normalize()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.Value)
+		})
+	}
+}
+
+func TestMaskName(t *testing.T) {
+	assert.Equal(t, "สม*** ใ***", MaskName("สมชาย ใจดี"))
+	assert.Equal(t, "Jo*** S***", MaskName("John Smith"))
+	assert.Equal(t, "A***", MaskName("Al"))
+}
+
+func TestConfirmedTransfer_RoundTrip(t *testing.T) {
+	completedAt := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	transfer := &Transfer{
+		ID: 7, FromUserID: 1, ToUserID: 2, Amount: 300, Status: TransferStatusCompleted,
+		IdempotencyKey: "k-1", CreatedAt: completedAt, UpdatedAt: completedAt, CompletedAt: &completedAt,
+	}
+	confirmation := &TransferConfirmation{ID: "c-1", ToUserID: 2, RecipientName: "สม*** ใ***"}
+
+	confirmed := NewConfirmedTransfer(transfer, confirmation)
+	assert.Equal(t, "c-1", confirmed.ConfirmationID)
+	assert.Equal(t, "สม*** ใ***", confirmed.RecipientName)
+	assert.Equal(t, transfer, confirmed.Transfer())
+}
+
+func TestUser_Validate_FieldDetails(t *testing.T) {
+	user := User{Name: " ", Email: "not-an-email", Phone: "02-123-4567"}
+	err := user.Validate()
+
+	verr, ok := err.(*ValidationError)
+	if assert.True(t, ok) {
+		assert.Equal(t, []FieldError{
+			{Field: "name", Code: FieldCodeRequired, Message: "is required"},
+			{Field: "email", Code: FieldCodeInvalid, Message: ErrInvalidEmail.Error()},
+			{Field: "phone", Code: FieldCodeInvalid, Message: ErrInvalidPhone.Error()},
+		}, verr.Fields)
+		assert.False(t, verr.Conflict())
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"081-234-5678", "+66812345678", false},
+		{"0912345678", "+66912345678", false},
+		{"+66 81 234 5678", "+66812345678", false},
+		{"66812345678", "+66812345678", false},
+		{"(06) 1234-5678", "+66612345678", false},
+		{"02-123-4567", "", true},
+		{"081-234-567", "", true},
+		{"+1 415 555 0100", "", true},
+		{"08l-234-5678", "", true},
+		{"abc", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := NormalizePhone(tt.in)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPhone)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPhoneSearchTerm(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"081-234", "6681234", true},
+		{"+66812", "66812", true},
+		{"5678", "5678", true},
+		{"(08)1", "6681", true},
+		{"0812345678", "66812345678", true},
+		{"0012", "0012", true},
+		{"สมชาย", "", false},
+		{"lbk001", "", false},
+		{"--", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := PhoneSearchTerm(tt.in)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeMemberSince(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"15/6/2566", "2023-06-15", false},
+		{" 1/1/2567 ", "2024-01-01", false},
+		{"29/2/2567", "2024-02-29", false},
+		{"2023-06-15", "2023-06-15", false},
+		{"", "", false},
+		{"29/2/2566", "", true},
+		{"31/4/2566", "", true},
+		{"15/6", "", true},
+		{"15/6/2566 extra", "", true},
+		{"June 2023", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := NormalizeMemberSince(tt.in)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidMemberSince)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeMemberSince_RejectsGregorianYears(t *testing.T) {
+	_, err := NormalizeMemberSince("15/6/2023")
+	assert.ErrorIs(t, err, ErrNotBuddhistYear)
+	assert.EqualError(t, err, "year must be a Buddhist-era year: 2023 looks like a Gregorian year, which is 2566 in the Buddhist era")
+
+	_, err = NormalizeMemberSince("15/6/3000")
+	assert.EqualError(t, err, "year must be a Buddhist-era year between 2400 and 2700")
+
+	got, err := NormalizeMemberSince("1/1/2400")
+	assert.NoError(t, err)
+	assert.Equal(t, "1857-01-01", got)
+}
+
+func TestFormatBuddhistDate(t *testing.T) {
+	assert.Equal(t, "15/6/2566", FormatBuddhistDate(time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "1/1/2567", FormatBuddhistDate(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	got, err := NormalizeEmail(" Somchai@Example.COM ")
+	assert.NoError(t, err)
+	assert.Equal(t, "somchai@example.com", got)
+
+	for _, in := range []string{"", "somchai", "somchai@", "somchai@example", "Somchai <somchai@example.com>", "a b@example.com"} {
+		_, err := NormalizeEmail(in)
+		assert.ErrorIs(t, err, ErrInvalidEmail, in)
+	}
+}
+
+func TestUserPatch_Apply(t *testing.T) {
+	tests := []struct {
+		name       string
+		patch      UserPatch
+		want       User
+		wantFields []string
+	}{
+		{
+			name:  "absent members are kept",
+			patch: UserPatch{"name": []byte(`"Somsri"`)},
+			want:  User{Name: "Somsri", Phone: "+66812345678", Email: "a@example.com", Points: 10},
+		},
+		{
+			name:  "null clears the field",
+			patch: UserPatch{"email": []byte(`null`)},
+			want:  User{Name: "Somchai", Phone: "+66812345678", Points: 10},
+		},
+		{
+			name:       "read-only and unknown members are rejected",
+			patch:      UserPatch{"points": []byte(`0`), "member_id": []byte(`"X"`), "nickname": []byte(`"x"`)},
+			want:       User{Name: "Somchai", Phone: "+66812345678", Email: "a@example.com", Points: 10},
+			wantFields: []string{"member_id", "nickname", "points"},
+		},
+		{
+			name:       "non-string value",
+			patch:      UserPatch{"phone": []byte(`812345678`)},
+			want:       User{Name: "Somchai", Phone: "+66812345678", Email: "a@example.com", Points: 10},
+			wantFields: []string{"phone"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := User{Name: "Somchai", Phone: "+66812345678", Email: "a@example.com", Points: 10}
+			err := tt.patch.Apply(&user)
+			assert.Equal(t, tt.want, user)
+			if tt.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var fields []string
+			for _, f := range err.(*ValidationError).Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := Backoff{Base: time.Second, Max: 10 * time.Second}
+	for attempts, want := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		5:  10 * time.Second,
+		60: 10 * time.Second,
+	} {
+		assert.Equal(t, want, backoff.Delay(attempts), "attempts %d", attempts)
+	}
+}
+
+func TestFormatPoints(t *testing.T) {
+	for n, want := range map[int]string{
+		0:       "0",
+		999:     "999",
+		1000:    "1,000",
+		15420:   "15,420",
+		1234567: "1,234,567",
+		-2500:   "-2,500",
+	} {
+		assert.Equal(t, want, FormatPoints(n))
+	}
+}
+
+func TestRenderNotification(t *testing.T) {
+	data := NotificationData{Amount: 500, Balance: 12000, Counterparty: "สมหญิง ดีใจ"}
+	subject, body, err := RenderNotification(NotificationTransferSent, LanguageThai, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "โอนคะแนนสำเร็จ", subject)
+	assert.Equal(t, "คุณโอน 500 คะแนนให้ สมหญิง ดีใจ แล้ว คะแนนคงเหลือ 12,000 คะแนน", body)
+
+	// Every kind has a template in both languages
+	for _, language := range []Language{LanguageThai, LanguageEnglish} {
+		for _, kind := range NotificationKinds {
+			_, _, err := RenderNotification(kind, language, data)
+			assert.NoError(t, err, "%s in %s", kind, language)
+		}
+	}
+}
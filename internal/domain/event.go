@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// EventName identifies what a domain event reports.
+type EventName string
+
+const (
+	EventUserCreated       EventName = "user.created"
+	EventTransferCompleted EventName = "transfer.completed"
+	EventPointsEarned      EventName = "points.earned"
+	EventPointsRedeemed    EventName = "points.redeemed"
+	EventPointsExpired     EventName = "points.expired"
+	EventPointsForfeited   EventName = "points.forfeited"
+)
+
+// OutboxEventStatus tracks an event through the dispatcher.
+type OutboxEventStatus string
+
+const (
+	// OutboxEventPending is waiting for its first or next attempt.
+	OutboxEventPending OutboxEventStatus = "pending"
+	// OutboxEventPublished was accepted by every sink.
+	OutboxEventPublished OutboxEventStatus = "published"
+	// OutboxEventDead ran out of attempts and is only retried on request.
+	OutboxEventDead OutboxEventStatus = "dead"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the change
+// it reports. The dispatcher publishes it until every sink accepts it, so a
+// sink may see the same event more than once; ID identifies duplicates.
+type OutboxEvent struct {
+	ID            int               `json:"eventId" db:"id"`
+	Name          EventName         `json:"event" db:"event_name"`
+	AggregateType string            `json:"aggregateType" db:"aggregate_type"`
+	AggregateID   string            `json:"aggregateId" db:"aggregate_id"`
+	Payload       json.RawMessage   `json:"payload" db:"payload"`
+	CreatedAt     time.Time         `json:"createdAt" db:"created_at"`
+	Status        OutboxEventStatus `json:"-" db:"status"`
+	// Attempts counts failed deliveries; the next is due at NextAttemptAt.
+	Attempts      int        `json:"-" db:"attempts"`
+	LastError     *string    `json:"-" db:"last_error"`
+	NextAttemptAt time.Time  `json:"-" db:"next_attempt_at"`
+	PublishedAt   *time.Time `json:"-" db:"published_at"`
+	// PublishedTo names the sinks that accepted the event; retries skip them.
+	PublishedTo []string `json:"-" db:"published_to"`
+}
+
+// NewOutboxEvent returns an event about the aggregate, due for delivery at
+// once, with payload encoded as JSON.
+func NewOutboxEvent(name EventName, aggregateType string, aggregateID int, payload interface{}, at time.Time) (*OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		Name:          name,
+		AggregateType: aggregateType,
+		AggregateID:   strconv.Itoa(aggregateID),
+		Payload:       data,
+		CreatedAt:     at,
+		Status:        OutboxEventPending,
+		NextAttemptAt: at,
+	}, nil
+}
+
+// UserIDs returns the members the event is about, read from its payload:
+// both sides of a transfer, and the member for every other event.
+func (e *OutboxEvent) UserIDs() ([]int, error) {
+	switch e.Name {
+	case EventTransferCompleted:
+		var payload TransferCompletedEvent
+		if err := json.Unmarshal(e.Payload, &payload); err != nil {
+			return nil, err
+		}
+		return []int{payload.FromUserID, payload.ToUserID}, nil
+	case EventUserCreated:
+		var payload UserCreatedEvent
+		if err := json.Unmarshal(e.Payload, &payload); err != nil {
+			return nil, err
+		}
+		return []int{payload.UserID}, nil
+	default:
+		var payload PointsChangedEvent
+		if err := json.Unmarshal(e.Payload, &payload); err != nil {
+			return nil, err
+		}
+		return []int{payload.UserID}, nil
+	}
+}
+
+// UserCreatedEvent is the payload of user.created.
+type UserCreatedEvent struct {
+	UserID          int    `json:"userId"`
+	MemberID        string `json:"memberId"`
+	MembershipLevel string `json:"membershipLevel"`
+	Points          int    `json:"points"`
+}
+
+// TransferCompletedEvent is the payload of transfer.completed.
+type TransferCompletedEvent struct {
+	TransferID       int       `json:"transferId"`
+	FromUserID       int       `json:"fromUserId"`
+	ToUserID         int       `json:"toUserId"`
+	Amount           int       `json:"amount"`
+	Note             *string   `json:"note,omitempty"`
+	FromBalanceAfter int       `json:"fromBalanceAfter"`
+	ToBalanceAfter   int       `json:"toBalanceAfter"`
+	CompletedAt      time.Time `json:"completedAt"`
+}
+
+// PointsChangedEvent is the payload of points.earned, points.redeemed and
+// points.expired. Change is signed, like the ledger entry it reports.
+type PointsChangedEvent struct {
+	UserID        int     `json:"userId"`
+	LedgerEntryID int     `json:"ledgerEntryId"`
+	Change        int     `json:"change"`
+	BalanceAfter  int     `json:"balanceAfter"`
+	Reference     *string `json:"reference,omitempty"`
+}
+
+// Backoff spaces out retries: the delay doubles after every failed attempt,
+// starting at Base and capped at Max.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns how long to wait after the given number of failed attempts.
+func (b Backoff) Delay(attempts int) time.Duration {
+	delay := b.Base
+	for i := 1; i < attempts && delay < b.Max; i++ {
+		delay *= 2
+	}
+	return min(delay, b.Max)
+}
+
+// DispatchPolicy controls outbox retries: failed attempts back off until
+// MaxAttempts have been made, after which the event is dead. The dispatcher
+// claims the events it publishes for ClaimTimeout, after which an event it
+// has not settled, e.g. because it crashed, is due again.
+type DispatchPolicy struct {
+	Backoff      Backoff
+	MaxAttempts  int
+	ClaimTimeout time.Duration
+}
+
+// DispatchRun summarizes one pass of the outbox dispatcher.
+type DispatchRun struct {
+	Published int `json:"published"`
+	Failed    int `json:"failed"`
+	Dead      int `json:"dead"`
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// System accounts are the counterparties of every point movement that does
+// not happen between two members.
+const (
+	AccountIssuance   = "system:issuance"
+	AccountRedemption = "system:redemption"
+	AccountBreakage   = "system:breakage"
+)
+
+// MemberAccount returns the journal account code holding a member's points.
+func MemberAccount(userID int) string {
+	return "member:" + strconv.Itoa(userID)
+}
+
+type JournalEntryType string
+
+const (
+	JournalEntryOpening  JournalEntryType = "opening"
+	JournalEntryTransfer JournalEntryType = "transfer"
+	JournalEntryEarn     JournalEntryType = "earn"
+	JournalEntryRedeem   JournalEntryType = "redeem"
+	JournalEntryAdjust   JournalEntryType = "adjust"
+	JournalEntryExpire   JournalEntryType = "expire"
+	JournalEntryForfeit  JournalEntryType = "forfeit"
+)
+
+// JournalEntry is a double-entry record: its postings move points between
+// accounts and always sum to zero.
+type JournalEntry struct {
+	ID         int              `json:"id" db:"id"`
+	EntryType  JournalEntryType `json:"entryType" db:"entry_type"`
+	TransferID *int             `json:"transferId,omitempty" db:"transfer_id"`
+	Reference  *string          `json:"reference,omitempty" db:"reference"`
+	CreatedAt  time.Time        `json:"createdAt" db:"created_at"`
+	Postings   []Posting        `json:"postings"`
+}
+
+// Posting credits (positive amount) or debits (negative amount) an account.
+type Posting struct {
+	ID             int    `json:"id" db:"id"`
+	JournalEntryID int    `json:"journalEntryId" db:"journal_entry_id"`
+	Account        string `json:"account" db:"account"`
+	Amount         int    `json:"amount" db:"amount"`
+}
+
+// NewJournalEntry builds an entry that moves amount points from one account
+// to another.
+func NewJournalEntry(entryType JournalEntryType, from, to string, amount int, createdAt time.Time) *JournalEntry {
+	return &JournalEntry{
+		EntryType: entryType,
+		CreatedAt: createdAt,
+		Postings: []Posting{
+			{Account: from, Amount: -amount},
+			{Account: to, Amount: amount},
+		},
+	}
+}
+
+// Validate checks that the entry is balanced
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return errors.New("journal entry needs at least two postings")
+	}
+	sum := 0
+	for _, p := range e.Postings {
+		if p.Account == "" {
+			return errors.New("posting account is required")
+		}
+		if p.Amount == 0 {
+			return errors.New("posting amount must not be zero")
+		}
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return errors.New("journal entry is not balanced")
+	}
+	return nil
+}
+
+// AccountBalance is the net of all postings to one account.
+type AccountBalance struct {
+	Account string `json:"account"`
+	Balance int    `json:"balance"`
+}
+
+// TrialBalance lists every account balance. The books balance when the sum
+// across all accounts is zero.
+type TrialBalance struct {
+	Accounts []AccountBalance `json:"accounts"`
+	Total    int              `json:"total"`
+	Balanced bool             `json:"balanced"`
+}
+
+// NewTrialBalance totals accounts and reports whether they balance.
+func NewTrialBalance(accounts []AccountBalance) *TrialBalance {
+	tb := &TrialBalance{Accounts: accounts}
+	if tb.Accounts == nil {
+		tb.Accounts = []AccountBalance{}
+	}
+	for _, a := range accounts {
+		tb.Total += a.Balance
+	}
+	tb.Balanced = tb.Total == 0
+	return tb
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// LedgerChainBreak describes the first ledger entry whose hash link does not
+// verify.
+type LedgerChainBreak struct {
+	UserID       int    `json:"userId"`
+	EntryID      int    `json:"entryId"`
+	Reason       string `json:"reason"`
+	ExpectedHash string `json:"expectedHash"`
+	ActualHash   string `json:"actualHash"`
+}
+
+// LedgerVerification is the result of walking one or more ledger hash chains.
+type LedgerVerification struct {
+	Valid          bool              `json:"valid"`
+	EntriesChecked int               `json:"entriesChecked"`
+	FirstBreak     *LedgerChainBreak `json:"firstBreak,omitempty"`
+}
+
+// ComputeHash returns the SHA-256 hash linking this entry to prevHash. The
+// hash covers every column except id and the hashes themselves.
+func (e *PointLedger) ComputeHash(prevHash string) string {
+	content, _ := json.Marshal(struct {
+		UserID       int       `json:"userId"`
+		Change       int       `json:"change"`
+		BalanceAfter int       `json:"balanceAfter"`
+		EventType    EventType `json:"eventType"`
+		TransferID   *int      `json:"transferId"`
+		Reference    *string   `json:"reference"`
+		Metadata     *string   `json:"metadata"`
+		CreatedAt    string    `json:"createdAt"`
+	}{
+		UserID:       e.UserID,
+		Change:       e.Change,
+		BalanceAfter: e.BalanceAfter,
+		EventType:    e.EventType,
+		TransferID:   e.TransferID,
+		Reference:    e.Reference,
+		Metadata:     e.Metadata,
+		CreatedAt:    e.CreatedAt.UTC().Format(time.RFC3339),
+	})
+
+	sum := sha256.Sum256(append([]byte(prevHash), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyLedgerChain walks a single user's entries, oldest first, and returns
+// how many entries were checked and the first broken link, if any.
+func VerifyLedgerChain(entries []PointLedger) (int, *LedgerChainBreak) {
+	prevHash := ""
+	for i, entry := range entries {
+		if entry.Hash == "" {
+			return i + 1, &LedgerChainBreak{
+				UserID:       entry.UserID,
+				EntryID:      entry.ID,
+				Reason:       "entry has no hash",
+				ExpectedHash: entry.ComputeHash(prevHash),
+			}
+		}
+		if entry.PrevHash != prevHash {
+			return i + 1, &LedgerChainBreak{
+				UserID:       entry.UserID,
+				EntryID:      entry.ID,
+				Reason:       "previous hash does not match preceding entry",
+				ExpectedHash: prevHash,
+				ActualHash:   entry.PrevHash,
+			}
+		}
+		if expected := entry.ComputeHash(prevHash); entry.Hash != expected {
+			return i + 1, &LedgerChainBreak{
+				UserID:       entry.UserID,
+				EntryID:      entry.ID,
+				Reason:       "entry content does not match its hash",
+				ExpectedHash: expected,
+				ActualHash:   entry.Hash,
+			}
+		}
+		prevHash = entry.Hash
+	}
+	return len(entries), nil
+}
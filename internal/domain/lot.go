@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// PointLot is a batch of points credited to a member at one time. Lots are
+// consumed oldest-expiry first and whatever remains at ExpiresAt is expired.
+type PointLot struct {
+	ID            int       `json:"lotId" db:"id"`
+	UserID        int       `json:"userId" db:"user_id"`
+	LedgerEntryID *int      `json:"ledgerEntryId,omitempty" db:"ledger_entry_id"`
+	Amount        int       `json:"amount" db:"amount"`
+	Remaining     int       `json:"remaining" db:"remaining"`
+	EarnedAt      time.Time `json:"earnedAt" db:"earned_at"`
+	ExpiresAt     time.Time `json:"expiresAt" db:"expires_at"`
+}
+
+// LotConsumption is the part of a lot used up by a debit.
+type LotConsumption struct {
+	LotID     int
+	Amount    int
+	Remaining int
+	ExpiresAt time.Time
+}
+
+// ConsumeFIFO takes amount points from lots, which must be ordered by
+// expiry, and returns what was taken from each lot. If the lots hold less
+// than amount, the shortfall is left to untracked points, which never expire.
+func ConsumeFIFO(lots []PointLot, amount int) []LotConsumption {
+	var consumed []LotConsumption
+	for _, lot := range lots {
+		if amount <= 0 {
+			break
+		}
+		if lot.Remaining <= 0 {
+			continue
+		}
+		take := lot.Remaining
+		if take > amount {
+			take = amount
+		}
+		consumed = append(consumed, LotConsumption{
+			LotID:     lot.ID,
+			Amount:    take,
+			Remaining: lot.Remaining - take,
+			ExpiresAt: lot.ExpiresAt,
+		})
+		amount -= take
+	}
+	return consumed
+}
+
+// ExpiryPolicy decides when newly credited points expire.
+type ExpiryPolicy struct {
+	Months int
+}
+
+// ExpiresAt returns the expiry time of points earned at earnedAt.
+func (p ExpiryPolicy) ExpiresAt(earnedAt time.Time) time.Time {
+	return earnedAt.AddDate(0, p.Months, 0)
+}
+
+// ExpiryRun summarizes one pass of the expiry job.
+type ExpiryRun struct {
+	UsersAffected int `json:"usersAffected"`
+	LotsExpired   int `json:"lotsExpired"`
+	PointsExpired int `json:"pointsExpired"`
+	// UsersFailed counts members whose expiry failed; they are retried on
+	// the next run.
+	UsersFailed int `json:"usersFailed"`
+}
+
+// ExpiringPoints lists a member's lots that expire before Until.
+type ExpiringPoints struct {
+	UserID int        `json:"userId"`
+	Until  time.Time  `json:"until"`
+	Total  int        `json:"total"`
+	Lots   []PointLot `json:"lots"`
+}
@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NotificationChannel is how a notification reaches the member.
+type NotificationChannel string
+
+const (
+	NotificationEmail NotificationChannel = "email"
+	NotificationSMS   NotificationChannel = "sms"
+)
+
+// Language selects the notification templates.
+type Language string
+
+const (
+	LanguageThai    Language = "th"
+	LanguageEnglish Language = "en"
+)
+
+// NotificationKind identifies what a notification tells the member.
+type NotificationKind string
+
+const (
+	NotificationTransferSent     NotificationKind = "transfer_sent"
+	NotificationTransferReceived NotificationKind = "transfer_received"
+	NotificationPointsEarned     NotificationKind = "points_earned"
+	NotificationPointsRedeemed   NotificationKind = "points_redeemed"
+)
+
+// NotificationKinds lists every kind a member can mute.
+var NotificationKinds = []NotificationKind{
+	NotificationTransferSent, NotificationTransferReceived, NotificationPointsEarned, NotificationPointsRedeemed,
+}
+
+// NotificationPreferences are a member's choice of channels, language and
+// muted kinds. Members who never set them get DefaultNotificationPreferences.
+type NotificationPreferences struct {
+	UserID   int                `json:"userId"`
+	Email    bool               `json:"email"`
+	SMS      bool               `json:"sms"`
+	Language Language           `json:"language"`
+	Muted    []NotificationKind `json:"muted"`
+	// UpdatedAt is zero, and omitted, until the member saves preferences.
+	UpdatedAt time.Time `json:"updatedAt,omitzero"`
+}
+
+// DefaultNotificationPreferences sends every kind by email, in Thai. SMS is
+// opt-in because each message costs money.
+func DefaultNotificationPreferences(userID int) *NotificationPreferences {
+	return &NotificationPreferences{UserID: userID, Email: true, Language: LanguageThai, Muted: []NotificationKind{}}
+}
+
+// Validate checks the language and muted kinds.
+func (p *NotificationPreferences) Validate() error {
+	verr := &ValidationError{}
+	if p.Language != LanguageThai && p.Language != LanguageEnglish {
+		verr.Add("language", FieldCodeInvalid, "must be th or en")
+	}
+	for i, kind := range p.Muted {
+		if !slices.Contains(NotificationKinds, kind) {
+			verr.Add("muted["+strconv.Itoa(i)+"]", FieldCodeInvalid, "is not a known notification")
+		}
+	}
+	return verr.OrNil()
+}
+
+// Wants reports whether the member wants notifications of kind over channel.
+func (p *NotificationPreferences) Wants(channel NotificationChannel, kind NotificationKind) bool {
+	if slices.Contains(p.Muted, kind) {
+		return false
+	}
+	switch channel {
+	case NotificationEmail:
+		return p.Email
+	case NotificationSMS:
+		return p.SMS
+	default:
+		return false
+	}
+}
+
+// Notification is one rendered message. SMS messages have no subject.
+type Notification struct {
+	Channel NotificationChannel
+	To      string
+	Subject string
+	Body    string
+}
+
+// NotificationData fills a notification template. Counterparty and Note are
+// only set for transfers.
+type NotificationData struct {
+	Amount       int
+	Balance      int
+	Counterparty string
+	Note         string
+}
+
+type notificationTemplate struct {
+	subject string
+	body    *template.Template
+}
+
+var notificationTemplates = map[Language]map[NotificationKind]notificationTemplate{
+	LanguageThai: {
+		NotificationTransferSent: newNotificationTemplate("โอนคะแนนสำเร็จ",
+			`คุณโอน {{points .Amount}} คะแนนให้ {{.Counterparty}} แล้ว{{with .Note}} (หมายเหตุ: {{.}}){{end}} คะแนนคงเหลือ {{points .Balance}} คะแนน`),
+		NotificationTransferReceived: newNotificationTemplate("คุณได้รับคะแนน",
+			`คุณได้รับ {{points .Amount}} คะแนนจาก {{.Counterparty}}{{with .Note}} (หมายเหตุ: {{.}}){{end}} คะแนนคงเหลือ {{points .Balance}} คะแนน`),
+		NotificationPointsEarned: newNotificationTemplate("ได้รับคะแนนสะสม",
+			`คุณได้รับ {{points .Amount}} คะแนนสะสม คะแนนคงเหลือ {{points .Balance}} คะแนน`),
+		NotificationPointsRedeemed: newNotificationTemplate("แลกคะแนนสำเร็จ",
+			`คุณแลก {{points .Amount}} คะแนนแล้ว คะแนนคงเหลือ {{points .Balance}} คะแนน`),
+	},
+	LanguageEnglish: {
+		NotificationTransferSent: newNotificationTemplate("Points sent",
+			`You sent {{points .Amount}} points to {{.Counterparty}}.{{with .Note}} Note: {{.}}{{end}} Your balance is {{points .Balance}} points.`),
+		NotificationTransferReceived: newNotificationTemplate("You received points",
+			`You received {{points .Amount}} points from {{.Counterparty}}.{{with .Note}} Note: {{.}}{{end}} Your balance is {{points .Balance}} points.`),
+		NotificationPointsEarned: newNotificationTemplate("Points earned",
+			`You earned {{points .Amount}} points. Your balance is {{points .Balance}} points.`),
+		NotificationPointsRedeemed: newNotificationTemplate("Points redeemed",
+			`You redeemed {{points .Amount}} points. Your balance is {{points .Balance}} points.`),
+	},
+}
+
+func newNotificationTemplate(subject, body string) notificationTemplate {
+	funcs := template.FuncMap{"points": FormatPoints}
+	return notificationTemplate{subject: subject, body: template.Must(template.New("").Funcs(funcs).Parse(body))}
+}
+
+// RenderNotification returns the subject and body of a kind notification
+// in language, falling back to Thai for an unknown language.
+func RenderNotification(kind NotificationKind, language Language, data NotificationData) (string, string, error) {
+	templates, ok := notificationTemplates[language]
+	if !ok {
+		templates = notificationTemplates[LanguageThai]
+	}
+	tmpl, ok := templates[kind]
+	if !ok {
+		return "", "", fmt.Errorf("no template for notification %q", kind)
+	}
+
+	var body strings.Builder
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return tmpl.subject, body.String(), nil
+}
+
+// FormatPoints writes n with thousands separators, e.g. 15,420.
+func FormatPoints(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String()
+}
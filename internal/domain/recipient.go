@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+type RecipientType string
+
+const (
+	RecipientTypePhone    RecipientType = "phone"
+	RecipientTypeEmail    RecipientType = "email"
+	RecipientTypeMemberID RecipientType = "memberId"
+)
+
+// TransferConfirmationTTL is how long a transfer preview can be confirmed.
+const TransferConfirmationTTL = 5 * time.Minute
+
+// RecipientIdentifier names a transfer recipient by something members know
+// about each other rather than by internal user ID.
+type RecipientIdentifier struct {
+	Type  RecipientType `json:"type" validate:"required,oneof=phone email memberId"`
+	Value string        `json:"value" validate:"required"`
+}
+
+// Normalize returns the identifier in the form used for lookups. Invalid
+// identifiers are reported as a *ValidationError on recipient.type or
+// recipient.value.
+func (r RecipientIdentifier) Normalize() (RecipientIdentifier, error) {
+	verr := &ValidationError{}
+	var value string
+	var err error
+	switch r.Type {
+	case RecipientTypePhone:
+		value, err = NormalizePhone(r.Value)
+	case RecipientTypeEmail:
+		value, err = NormalizeEmail(r.Value)
+	case RecipientTypeMemberID:
+		value = NormalizeMemberID(r.Value)
+	default:
+		verr.Add("recipient.type", FieldCodeInvalid, "must be one of phone, email, memberId")
+		return r, verr
+	}
+
+	switch {
+	case strings.TrimSpace(r.Value) == "" || value == "" && err == nil:
+		verr.Add("recipient.value", FieldCodeRequired, "is required")
+	case err != nil:
+		verr.Add("recipient.value", FieldCodeInvalid, err.Error())
+	}
+	if err := verr.OrNil(); err != nil {
+		return r, err
+	}
+	return RecipientIdentifier{Type: r.Type, Value: value}, nil
+}
+
+// MaskName hides most of a name so a sender can confirm the recipient
+// without learning their full name: the first two characters of the first
+// word and the first character of every other word are kept.
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		runes := []rune(word)
+		keep := 1
+		if i == 0 && len(runes) > 2 {
+			keep = 2
+		}
+		// Keep Thai combining vowel and tone marks with their base character
+		for keep < len(runes) && unicode.Is(unicode.Mn, runes[keep]) {
+			keep++
+		}
+		if keep >= len(runes) {
+			continue
+		}
+		words[i] = string(runes[:keep]) + "***"
+	}
+	return strings.Join(words, " ")
+}
+
+// TransferConfirmation is a previewed transfer awaiting confirmation by the
+// sender. The recipient's user ID is not exposed; only the masked name is.
+type TransferConfirmation struct {
+	ID            string              `json:"confirmationId" db:"id"`
+	FromUserID    int                 `json:"fromUserId" db:"from_user_id"`
+	ToUserID      int                 `json:"-" db:"to_user_id"`
+	Recipient     RecipientIdentifier `json:"recipient" db:"-"`
+	RecipientName string              `json:"recipientName" db:"recipient_name"`
+	Amount        int                 `json:"amount" db:"amount"`
+	Note          *string             `json:"note,omitempty" db:"note"`
+	CreatedAt     time.Time           `json:"createdAt" db:"created_at"`
+	ExpiresAt     time.Time           `json:"expiresAt" db:"expires_at"`
+	ConsumedAt    *time.Time          `json:"-" db:"consumed_at"`
+}
+
+// Expired reports whether the confirmation can no longer be used at now.
+func (c *TransferConfirmation) Expired(now time.Time) bool {
+	return c.ConsumedAt != nil || !now.Before(c.ExpiresAt)
+}
+
+// ConfirmedTransfer is a transfer made from a TransferConfirmation as shown
+// to its sender. Like the preview, it names the recipient only by masked
+// name.
+type ConfirmedTransfer struct {
+	ID             int            `json:"transferId"`
+	ConfirmationID string         `json:"confirmationId"`
+	FromUserID     int            `json:"fromUserId"`
+	ToUserID       int            `json:"-"`
+	RecipientName  string         `json:"recipientName"`
+	Amount         int            `json:"amount"`
+	Status         TransferStatus `json:"status"`
+	Note           *string        `json:"note,omitempty"`
+	IdempotencyKey string         `json:"idemKey"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	CompletedAt    *time.Time     `json:"completedAt,omitempty"`
+}
+
+// NewConfirmedTransfer returns the sender's view of transfer, made from
+// confirmation.
+func NewConfirmedTransfer(transfer *Transfer, confirmation *TransferConfirmation) *ConfirmedTransfer {
+	return &ConfirmedTransfer{
+		ID:             transfer.ID,
+		ConfirmationID: confirmation.ID,
+		FromUserID:     transfer.FromUserID,
+		ToUserID:       transfer.ToUserID,
+		RecipientName:  confirmation.RecipientName,
+		Amount:         transfer.Amount,
+		Status:         transfer.Status,
+		Note:           transfer.Note,
+		IdempotencyKey: transfer.IdempotencyKey,
+		CreatedAt:      transfer.CreatedAt,
+		UpdatedAt:      transfer.UpdatedAt,
+		CompletedAt:    transfer.CompletedAt,
+	}
+}
+
+// Transfer returns the transfer the view was made from.
+func (t *ConfirmedTransfer) Transfer() *Transfer {
+	return &Transfer{
+		ID:             t.ID,
+		FromUserID:     t.FromUserID,
+		ToUserID:       t.ToUserID,
+		Amount:         t.Amount,
+		Status:         t.Status,
+		Note:           t.Note,
+		IdempotencyKey: t.IdempotencyKey,
+		CreatedAt:      t.CreatedAt,
+		UpdatedAt:      t.UpdatedAt,
+		CompletedAt:    t.CompletedAt,
+	}
+}
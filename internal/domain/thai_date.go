@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout is the ISO 8601 layout dates without time of day are stored
+// and exchanged in.
+const DateLayout = "2006-01-02"
+
+// BuddhistEraOffset is the difference between a Thai Buddhist-era year and
+// the Gregorian year, e.g. 2566 BE is 2023 CE.
+const BuddhistEraOffset = 543
+
+var ErrInvalidMemberSince = errors.New("must be a date such as 2023-06-15 or 15/6/2566")
+
+// ErrNotBuddhistYear is returned for a Buddhist-era date whose year falls
+// outside MinBuddhistYear and MaxBuddhistYear, usually a Gregorian year
+// typed by mistake.
+var ErrNotBuddhistYear = errors.New("year must be a Buddhist-era year")
+
+// MinBuddhistYear and MaxBuddhistYear bound the years ParseBuddhistDate
+// accepts, 1857 to 2157 CE.
+const (
+	MinBuddhistYear = 2400
+	MaxBuddhistYear = 2700
+)
+
+// ParseBuddhistDate parses a Thai "day/month/year" date with a Buddhist-era
+// year, such as "15/6/2566", into the Gregorian date at midnight UTC.
+func ParseBuddhistDate(s string) (time.Time, error) {
+	var day, month, year int
+	var rest string
+	if n, _ := fmt.Sscanf(strings.TrimSpace(s), "%d/%d/%d%s", &day, &month, &year, &rest); n != 3 {
+		return time.Time{}, fmt.Errorf("invalid Buddhist-era date %q", s)
+	}
+	if year < MinBuddhistYear || year > MaxBuddhistYear {
+		if ceYear := year + BuddhistEraOffset; ceYear >= MinBuddhistYear && ceYear <= MaxBuddhistYear {
+			return time.Time{}, fmt.Errorf("%w: %d looks like a Gregorian year, which is %d in the Buddhist era", ErrNotBuddhistYear, year, ceYear)
+		}
+		return time.Time{}, fmt.Errorf("%w between %d and %d", ErrNotBuddhistYear, MinBuddhistYear, MaxBuddhistYear)
+	}
+	t := time.Date(year-BuddhistEraOffset, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	// time.Date normalizes out-of-range days and months, e.g. 31/4 to 1/5
+	if t.Day() != day || int(t.Month()) != month {
+		return time.Time{}, fmt.Errorf("invalid Buddhist-era date %q", s)
+	}
+	return t, nil
+}
+
+// FormatBuddhistDate formats t as a Thai "day/month/year" date with a
+// Buddhist-era year, such as "15/6/2566".
+func FormatBuddhistDate(t time.Time) string {
+	return fmt.Sprintf("%d/%d/%d", t.Day(), int(t.Month()), t.Year()+BuddhistEraOffset)
+}
+
+// NormalizeMemberSince converts an ISO 8601 date or a Buddhist-era date to
+// ISO 8601. An empty value is returned unchanged. A Buddhist-era date with
+// an implausible year returns ErrNotBuddhistYear, so the member can tell a
+// Gregorian year was entered.
+func NormalizeMemberSince(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(DateLayout, s); err == nil {
+		return t.Format(DateLayout), nil
+	}
+	t, err := ParseBuddhistDate(s)
+	if errors.Is(err, ErrNotBuddhistYear) {
+		return "", err
+	}
+	if err != nil {
+		return "", ErrInvalidMemberSince
+	}
+	return t.Format(DateLayout), nil
+}
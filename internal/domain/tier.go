@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// TierRule qualifies a member for a tier once they have earned at least
+// MinPoints within the policy's rolling window.
+type TierRule struct {
+	Name      string `json:"name"`
+	MinPoints int    `json:"minPoints"`
+}
+
+// TierPolicy holds the tier ladder, lowest first.
+type TierPolicy struct {
+	WindowMonths int
+	Rules        []TierRule
+}
+
+// NewTierPolicy returns a policy with rules ordered by threshold.
+func NewTierPolicy(windowMonths int, rules []TierRule) TierPolicy {
+	sorted := append([]TierRule(nil), rules...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].MinPoints < sorted[j].MinPoints })
+	return TierPolicy{WindowMonths: windowMonths, Rules: sorted}
+}
+
+// WindowStart returns the start of the rolling window ending at now.
+func (p TierPolicy) WindowStart(now time.Time) time.Time {
+	return now.AddDate(0, -p.WindowMonths, 0)
+}
+
+// Qualify returns the index of the highest tier earned points qualify for.
+// Members below every threshold are placed in the lowest tier.
+func (p TierPolicy) Qualify(earned int) int {
+	idx := 0
+	for i, rule := range p.Rules {
+		if earned >= rule.MinPoints {
+			idx = i
+		}
+	}
+	return idx
+}
+
+// StartingLevel returns the tier of a member who has not earned anything
+// yet, or "" when the policy has no tiers.
+func (p TierPolicy) StartingLevel() string {
+	if len(p.Rules) == 0 {
+		return ""
+	}
+	return p.Rules[p.Qualify(0)].Name
+}
+
+// Rank returns the position of the named tier on the ladder, or -1 when the
+// level is not part of the policy.
+func (p TierPolicy) Rank(level string) int {
+	for i, rule := range p.Rules {
+		if strings.EqualFold(rule.Name, level) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Progress describes where a member stands on the tier ladder.
+func (p TierPolicy) Progress(currentLevel string, earned int, now time.Time) *TierProgress {
+	progress := &TierProgress{
+		CurrentTier:    currentLevel,
+		EarnedInWindow: earned,
+		WindowStart:    p.WindowStart(now),
+		WindowEnd:      now,
+	}
+	if len(p.Rules) == 0 {
+		return progress
+	}
+
+	idx := p.Qualify(earned)
+	qualified := p.Rules[idx]
+	progress.QualifiedTier = qualified.Name
+	progress.ProgressPercent = 100
+
+	if idx+1 < len(p.Rules) {
+		next := p.Rules[idx+1]
+		progress.NextTier = &next.Name
+		progress.PointsToNextTier = next.MinPoints - earned
+		if span := next.MinPoints - qualified.MinPoints; span > 0 {
+			progress.ProgressPercent = (earned - qualified.MinPoints) * 100 / span
+			if progress.ProgressPercent < 0 {
+				progress.ProgressPercent = 0
+			}
+		}
+	}
+	return progress
+}
+
+// TierProgress is a member's current tier and the distance to the next one.
+type TierProgress struct {
+	UserID           int           `json:"userId"`
+	CurrentTier      string        `json:"currentTier"`
+	QualifiedTier    string        `json:"qualifiedTier"`
+	EarnedInWindow   int           `json:"earnedInWindow"`
+	WindowStart      time.Time     `json:"windowStart"`
+	WindowEnd        time.Time     `json:"windowEnd"`
+	NextTier         *string       `json:"nextTier,omitempty"`
+	PointsToNextTier int           `json:"pointsToNextTier"`
+	ProgressPercent  int           `json:"progressPercent"`
+	History          []TierHistory `json:"history"`
+}
+
+const (
+	TierChangeReasonLedger    = "ledger_change"
+	TierChangeReasonScheduled = "scheduled"
+)
+
+// TierHistory records a change of a member's MembershipLevel.
+type TierHistory struct {
+	ID             int       `json:"id" db:"id"`
+	UserID         int       `json:"userId" db:"user_id"`
+	FromLevel      string    `json:"fromLevel" db:"from_level"`
+	ToLevel        string    `json:"toLevel" db:"to_level"`
+	EarnedInWindow int       `json:"earnedInWindow" db:"earned_in_window"`
+	Reason         string    `json:"reason" db:"reason"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+}
+
+// TierEvaluationRun summarizes one pass over all members.
+type TierEvaluationRun struct {
+	UsersEvaluated int `json:"usersEvaluated"`
+	Upgraded       int `json:"upgraded"`
+	Downgraded     int `json:"downgraded"`
+	// UsersFailed are left at their current tier until the next run.
+	UsersFailed int `json:"usersFailed"`
+}
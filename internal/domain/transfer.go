@@ -49,6 +49,8 @@ const (
 	EventTypeAdjust      EventType = "adjust"
 	EventTypeEarn        EventType = "earn"
 	EventTypeRedeem      EventType = "redeem"
+	EventTypeExpire      EventType = "expire"
+	EventTypeForfeit     EventType = "forfeit"
 )
 
 type PointLedger struct {
@@ -61,4 +63,6 @@ type PointLedger struct {
 	Reference    *string   `json:"reference,omitempty" db:"reference"`
 	Metadata     *string   `json:"metadata,omitempty" db:"metadata"`
 	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	PrevHash     string    `json:"prevHash" db:"prev_hash"`
+	Hash         string    `json:"hash" db:"hash"`
 }
@@ -1,34 +1,133 @@
 package domain
 
 import (
-	"errors"
+	"encoding/json"
+	"sort"
 	"strings"
 	"time"
 )
 
+// AccountStatus is the lifecycle state of a member account.
+type AccountStatus string
+
+const (
+	AccountStatusActive    AccountStatus = "active"
+	AccountStatusSuspended AccountStatus = "suspended"
+	AccountStatusClosed    AccountStatus = "closed"
+)
+
 type User struct {
-	ID              int       `json:"id"`
-	Name            string    `json:"name"`
-	Phone           string    `json:"phone"`
-	Email           string    `json:"email"`
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+	// MemberSince is an ISO 8601 date, e.g. "2023-06-15"
 	MemberSince     string    `json:"member_since"`
 	MembershipLevel string    `json:"membership_level"`
 	MemberID        string    `json:"member_id"`
 	Points          int       `json:"points"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+	// Version increases with every change and is the user's ETag
+	Version int           `json:"version"`
+	Status  AccountStatus `json:"status"`
+	// DeletedAt is set when the account is closed. Closed users are kept
+	// because transfers and the ledger reference them.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Closed reports whether the account has been closed.
+func (u *User) Closed() bool {
+	return u.Status == AccountStatusClosed
+}
+
+// Suspended reports whether the account is frozen pending review.
+func (u *User) Suspended() bool {
+	return u.Status == AccountStatusSuspended
+}
+
+// Normalize trims the user's fields and converts phone, email and
+// member_since to their canonical form when they are valid. Invalid values
+// are left for Validate to report.
+func (u *User) Normalize() {
+	u.Name = strings.TrimSpace(u.Name)
+	u.Phone = strings.TrimSpace(u.Phone)
+	u.Email = strings.TrimSpace(u.Email)
+	if phone, err := NormalizePhone(u.Phone); err == nil {
+		u.Phone = phone
+	}
+	if email, err := NormalizeEmail(u.Email); err == nil {
+		u.Email = email
+	}
+	if memberSince, err := NormalizeMemberSince(u.MemberSince); err == nil {
+		u.MemberSince = memberSince
+	}
 }
 
-// Validate validates the user data
+// Validate validates the user data and returns a *ValidationError listing
+// every invalid field.
 func (u *User) Validate() error {
+	verr := &ValidationError{}
 	if strings.TrimSpace(u.Name) == "" {
-		return errors.New("name is required")
+		verr.Add("name", FieldCodeRequired, "is required")
 	}
 	if strings.TrimSpace(u.Email) == "" {
-		return errors.New("email is required")
+		verr.Add("email", FieldCodeRequired, "is required")
+	} else if _, err := NormalizeEmail(u.Email); err != nil {
+		verr.Add("email", FieldCodeInvalid, err.Error())
 	}
 	if strings.TrimSpace(u.Phone) == "" {
-		return errors.New("phone is required")
+		verr.Add("phone", FieldCodeRequired, "is required")
+	} else if _, err := NormalizePhone(u.Phone); err != nil {
+		verr.Add("phone", FieldCodeInvalid, err.Error())
+	}
+	if _, err := NormalizeMemberSince(u.MemberSince); err != nil {
+		verr.Add("member_since", FieldCodeInvalid, err.Error())
+	}
+	if u.Points < 0 {
+		verr.Add("points", FieldCodeInvalid, "must not be negative")
+	}
+	return verr.OrNil()
+}
+
+// UserPatch is a JSON Merge Patch (RFC 7396) for a user: members that are
+// absent are left unchanged and null clears the field.
+type UserPatch map[string]json.RawMessage
+
+// Apply merges the patch into u. Only name, phone and email may be changed;
+// any other member, or a value that is not a string, is reported in the
+// returned *ValidationError and u is left partially patched.
+func (p UserPatch) Apply(u *User) error {
+	mutable := map[string]*string{
+		"name":  &u.Name,
+		"phone": &u.Phone,
+		"email": &u.Email,
+	}
+
+	fields := make([]string, 0, len(p))
+	for field := range p {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	verr := &ValidationError{}
+	for _, field := range fields {
+		target, ok := mutable[field]
+		if !ok {
+			verr.Add(field, FieldCodeNotAllowed, "cannot be changed")
+			continue
+		}
+		raw := p[field]
+		if string(raw) == "null" {
+			*target = ""
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			verr.Add(field, FieldCodeInvalid, "must be a string")
+			continue
+		}
+		*target = value
 	}
-	return nil
+	return verr.OrNil()
 }
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// UserEventType names a message on a user's live event stream.
+type UserEventType string
+
+const (
+	// UserEventBalance carries a BalanceUpdate.
+	UserEventBalance UserEventType = "balance"
+	// UserEventTransfer carries an incoming Transfer.
+	UserEventTransfer UserEventType = "transfer"
+)
+
+// UserEvent is one message on a user's live stream. ID orders the user's
+// events and lets a reconnecting client resume after the last one it saw;
+// a snapshot sent on connect has no ID.
+type UserEvent struct {
+	ID     string
+	UserID int
+	Type   UserEventType
+	Data   interface{}
+}
+
+// BalanceReasonOpening is the Reason of the update for a new member's
+// starting points, which are issued without a ledger entry.
+const BalanceReasonOpening EventType = "opening"
+
+// BalanceUpdate reports a user's balance after a change. Balance is the
+// whole balance, so a client can apply updates without keeping a sum.
+type BalanceUpdate struct {
+	UserID  int       `json:"userId"`
+	Balance int       `json:"balance"`
+	Change  int       `json:"change"`
+	Reason  EventType `json:"reason,omitempty"`
+	At      time.Time `json:"at"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	FieldCodeRequired   = "required"
+	FieldCodeInvalid    = "invalid"
+	FieldCodeTaken      = "taken"
+	FieldCodeNotAllowed = "not_allowed"
+)
+
+// FieldError describes why a single input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every field error found in one input so clients
+// can report them together.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, fmt.Sprintf("%s %s", f.Field, f.Message))
+	}
+	return "validation error: " + strings.Join(parts, "; ")
+}
+
+// Add records a field error.
+func (e *ValidationError) Add(field, code, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Conflict reports whether every field error is a uniqueness violation.
+func (e *ValidationError) Conflict() bool {
+	for _, f := range e.Fields {
+		if f.Code != FieldCodeTaken {
+			return false
+		}
+	}
+	return len(e.Fields) > 0
+}
+
+// OrNil returns e when it holds field errors and nil otherwise.
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
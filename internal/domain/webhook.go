@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// EventNames lists every event a webhook can subscribe to.
+var EventNames = []EventName{
+	EventUserCreated, EventTransferCompleted, EventPointsEarned, EventPointsRedeemed, EventPointsExpired,
+	EventPointsForfeited,
+}
+
+// Headers sent with every webhook request. The signature lets the receiver
+// check that the body came from us and was sent at the given time.
+const (
+	WebhookHeaderID        = "X-Webhook-Id"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// MinWebhookSecretLength is the shortest secret a subscription accepts.
+const MinWebhookSecretLength = 16
+
+// WebhookSubscription asks for the events named in EventTypes to be POSTed
+// to URL, signed with Secret. The secret is never returned by the API.
+type WebhookSubscription struct {
+	ID         int         `json:"id" db:"id"`
+	URL        string      `json:"url" db:"url"`
+	EventTypes []EventName `json:"eventTypes" db:"event_types"`
+	// UserIDs limits the subscription to events about these members, such
+	// as a partner's own members. Without it every member's events are sent.
+	UserIDs   []int     `json:"userIds,omitempty" db:"user_ids"`
+	Secret    string    `json:"-" db:"secret"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Validate checks the URL, event types and secret of a new subscription.
+func (s *WebhookSubscription) Validate() error {
+	verr := &ValidationError{}
+	if s.URL == "" {
+		verr.Add("url", FieldCodeRequired, "is required")
+	} else if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add("url", FieldCodeInvalid, "must be an absolute http or https URL")
+	}
+
+	if len(s.EventTypes) == 0 {
+		verr.Add("eventTypes", FieldCodeRequired, "is required")
+	}
+	for i, name := range s.EventTypes {
+		if !slices.Contains(EventNames, name) {
+			verr.Add("eventTypes["+strconv.Itoa(i)+"]", FieldCodeInvalid, "is not a known event")
+		}
+	}
+
+	for i, userID := range s.UserIDs {
+		if userID <= 0 {
+			verr.Add("userIds["+strconv.Itoa(i)+"]", FieldCodeInvalid, "must be a positive integer")
+		}
+	}
+
+	if len(s.Secret) < MinWebhookSecretLength {
+		verr.Add("secret", FieldCodeInvalid, "must be at least "+strconv.Itoa(MinWebhookSecretLength)+" characters")
+	}
+	return verr.OrNil()
+}
+
+// Subscribes reports whether the subscription wants events named name.
+func (s *WebhookSubscription) Subscribes(name EventName) bool {
+	return slices.Contains(s.EventTypes, name)
+}
+
+// Covers reports whether the subscription wants events about any of
+// userIDs. A subscription without UserIDs covers every member.
+func (s *WebhookSubscription) Covers(userIDs []int) bool {
+	if len(s.UserIDs) == 0 {
+		return true
+	}
+	for _, userID := range userIDs {
+		if slices.Contains(s.UserIDs, userID) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSignature returns the X-Webhook-Signature value for body sent at
+// timestamp: the hex HMAC-SHA256, keyed by secret, of "<timestamp>.<body>".
+func WebhookSignature(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending is waiting for its first or next attempt.
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered was accepted with a 2xx response.
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryDead ran out of attempts and is only retried on request.
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is one event sent to one subscription. Body is fixed when
+// the delivery is created, so every attempt sends the same bytes.
+type WebhookDelivery struct {
+	ID             int                   `json:"id" db:"id"`
+	SubscriptionID int                   `json:"subscriptionId" db:"subscription_id"`
+	EventID        int                   `json:"eventId" db:"event_id"`
+	EventName      EventName             `json:"event" db:"event_name"`
+	Body           json.RawMessage       `json:"body" db:"body"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	ResponseStatus *int                  `json:"responseStatus,omitempty" db:"response_status"`
+	LastError      *string               `json:"lastError,omitempty" db:"last_error"`
+	NextAttemptAt  time.Time             `json:"nextAttemptAt" db:"next_attempt_at"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty" db:"delivered_at"`
+	CreatedAt      time.Time             `json:"createdAt" db:"created_at"`
+}
+
+// WebhookPolicy controls retries: failed attempts back off until MaxAttempts
+// have been made, after which the delivery is dead.
+type WebhookPolicy struct {
+	Backoff     Backoff
+	MaxAttempts int
+}
+
+// WebhookRun summarizes one pass of the webhook delivery job.
+type WebhookRun struct {
+	Delivered int `json:"delivered"`
+	Retrying  int `json:"retrying"`
+	Dead      int `json:"dead"`
+}
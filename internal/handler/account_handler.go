@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AccountStatusRequest gives the reason for a suspension or reinstatement,
+// kept in the account's status history.
+type AccountStatusRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type AccountStatusHistoryResponse struct {
+	Data interface{} `json:"data"`
+}
+
+type AccountHandler struct {
+	service service.AccountManager
+}
+
+func NewAccountHandler(service service.AccountManager) *AccountHandler {
+	return &AccountHandler{service: service}
+}
+
+func (h *AccountHandler) RegisterRoutes(app *fiber.App) {
+	app.Post("/admin/users/:id/suspend", h.SuspendUser)
+	app.Post("/admin/users/:id/unsuspend", h.UnsuspendUser)
+	app.Get("/admin/users/:id/status-history", h.GetStatusHistory)
+}
+
+// SuspendUser freezes the account so it can neither send nor receive points.
+func (h *AccountHandler) SuspendUser(c *fiber.Ctx) error {
+	id, req, fieldErrs := parseStatusRequest(c)
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	user, err := h.service.SuspendUser(c.UserContext(), id, req.Reason)
+	if err != nil {
+		return writeError(c, err, "Failed to suspend user")
+	}
+	setETag(c, user)
+	localizeUser(c, user)
+	return c.JSON(user)
+}
+
+// UnsuspendUser lifts the freeze on a suspended account.
+func (h *AccountHandler) UnsuspendUser(c *fiber.Ctx) error {
+	id, req, fieldErrs := parseStatusRequest(c)
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	user, err := h.service.UnsuspendUser(c.UserContext(), id, req.Reason)
+	if err != nil {
+		return writeError(c, err, "Failed to unsuspend user")
+	}
+	setETag(c, user)
+	localizeUser(c, user)
+	return c.JSON(user)
+}
+
+// GetStatusHistory lists the account's status changes, newest first.
+func (h *AccountHandler) GetStatusHistory(c *fiber.Ctx) error {
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	history, err := h.service.GetStatusHistory(id)
+	if err != nil {
+		return writeError(c, err, "Failed to get status history")
+	}
+	return c.JSON(AccountStatusHistoryResponse{Data: history})
+}
+
+func parseStatusRequest(c *fiber.Ctx) (int, AccountStatusRequest, []FieldError) {
+	var req AccountStatusRequest
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return 0, req, fieldErrs
+	}
+	return id, req, bindBody(c, &req)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditListQuery struct {
+	Actor      string `query:"actor"`
+	Action     string `query:"action"`
+	EntityType string `query:"entityType"`
+	EntityID   string `query:"entityId"`
+	From       string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To         string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Page       int    `query:"page" validate:"min=1"`
+	PageSize   int    `query:"pageSize" validate:"min=1,max=200"`
+}
+
+type AuditListResponse struct {
+	Data     interface{} `json:"data"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"pageSize"`
+	Total    int         `json:"total"`
+}
+
+type AuditHandler struct {
+	service *service.AuditService
+}
+
+func NewAuditHandler(service *service.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+func (h *AuditHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/admin/audit", h.ListAuditEntries)
+}
+
+func (h *AuditHandler) ListAuditEntries(c *fiber.Ctx) error {
+	query := AuditListQuery{Page: 1, PageSize: 20}
+	if fieldErrs := bindQuery(c, &query); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	filter := port.AuditFilter{
+		Actor:      query.Actor,
+		Action:     domain.AuditAction(query.Action),
+		EntityType: query.EntityType,
+		EntityID:   query.EntityID,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+	}
+	// Already validated by the datetime tag
+	if query.From != "" {
+		from, _ := time.Parse(time.RFC3339, query.From)
+		filter.From = &from
+	}
+	if query.To != "" {
+		to, _ := time.Parse(time.RFC3339, query.To)
+		filter.To = &to
+	}
+
+	entries, total, err := h.service.ListEntries(filter)
+	if err != nil {
+		return writeError(c, err, "Failed to get audit entries")
+	}
+
+	return c.JSON(AuditListResponse{
+		Data:     entries,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// FieldError describes a single rejected request field.
+type FieldError = domain.FieldError
+
+// ErrorResponse is the body of every error response: a machine-readable
+// code in Error, a human-readable Message and, for validation failures, one
+// Details entry per rejected field.
+type ErrorResponse struct {
+	Error   string       `json:"error"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// validationFailed responds 400 VALIDATION_ERROR listing the rejected fields.
+func validationFailed(c *fiber.Ctx, details ...FieldError) error {
+	return c.Status(400).JSON(ErrorResponse{
+		Error:   "VALIDATION_ERROR",
+		Message: "Request validation failed",
+		Details: details,
+	})
+}
+
+// kindStatus maps service error kinds to HTTP statuses.
+var kindStatus = map[service.ErrorKind]int{
+	service.KindValidation:         400,
+	service.KindNotFound:           404,
+	service.KindConflict:           409,
+	service.KindGone:               410,
+	service.KindUnprocessable:      422,
+	service.KindPreconditionFailed: 412,
+	service.KindForbidden:          403,
+}
+
+// writeError translates a service error into its HTTP response. Field
+// validation errors list the rejected fields; errors of unknown kind are
+// reported as 500 INTERNAL_ERROR with message.
+func writeError(c *fiber.Ctx, err error, message string) error {
+	status, ok := kindStatus[service.KindOf(err)]
+	if !ok {
+		return c.Status(500).JSON(ErrorResponse{
+			Error:   "INTERNAL_ERROR",
+			Message: message,
+		})
+	}
+
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		if status == 409 {
+			return c.Status(409).JSON(ErrorResponse{
+				Error:   "DUPLICATE_CONTACT",
+				Message: "Phone or email is already registered",
+				Details: verr.Fields,
+			})
+		}
+		return validationFailed(c, verr.Fields...)
+	}
+
+	var serr *service.Error
+	errors.As(err, &serr)
+	return c.Status(status).JSON(ErrorResponse{
+		Error:   serr.Code,
+		Message: serr.Message,
+	})
+}
+
+// ErrorHandler renders errors returned by routes and by Fiber itself, such
+// as unknown routes, in the ErrorResponse format. Service errors returned
+// directly by a route are translated by writeError.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return c.Status(fe.Code).JSON(ErrorResponse{
+			Error:   strings.ToUpper(strings.ReplaceAll(utils.StatusMessage(fe.Code), " ", "_")),
+			Message: fe.Message,
+		})
+	}
+	return writeError(c, err, "Internal server error")
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteError(t *testing.T) {
+	invalid := &domain.ValidationError{}
+	invalid.Add("email", domain.FieldCodeInvalid, "must be a valid email address")
+	taken := &domain.ValidationError{}
+	taken.Add("phone", domain.FieldCodeTaken, "is already registered")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantFields int
+	}{
+		{"validation", invalid, 400, "VALIDATION_ERROR", 1},
+		{"invalid amount", service.ErrInvalidAmount, 400, "VALIDATION_ERROR", 1},
+		{"invalid member ID", service.ErrInvalidMemberID, 400, "VALIDATION_ERROR", 1},
+		{"duplicate contact", taken, 409, "DUPLICATE_CONTACT", 1},
+		{"user not found", service.ErrUserNotFound, 404, "USER_NOT_FOUND", 0},
+		{"transfer not found", service.ErrTransferNotFound, 404, "TRANSFER_NOT_FOUND", 0},
+		{"recipient not found", service.ErrRecipientNotFound, 404, "RECIPIENT_NOT_FOUND", 0},
+		{"confirmation not found", service.ErrConfirmationNotFound, 404, "CONFIRMATION_NOT_FOUND", 0},
+		{"insufficient balance", service.ErrInsufficientBalance, 409, "INSUFFICIENT_BALANCE", 0},
+		{"confirmation expired", service.ErrConfirmationExpired, 410, "CONFIRMATION_EXPIRED", 0},
+		{"self transfer", service.ErrSelfTransfer, 422, "SELF_TRANSFER", 0},
+		{"version mismatch", service.ErrVersionMismatch, 412, "VERSION_MISMATCH", 0},
+		{"account frozen", service.ErrAccountFrozen, 403, "ACCOUNT_FROZEN", 0},
+		{"wrapped not found", errors.Join(errors.New("lookup"), service.ErrUserNotFound), 404, "USER_NOT_FOUND", 0},
+		{"unknown", errors.New("disk full"), 500, "INTERNAL_ERROR", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				return writeError(c, tt.err, "Something failed")
+			})
+
+			resp, body := doRequest(t, app, newRequest("GET", "/", ""))
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantCode, body.Error)
+			assert.Len(t, body.Details, tt.wantFields)
+			if tt.wantStatus == 500 {
+				assert.Equal(t, "Something failed", body.Message)
+			}
+		})
+	}
+}
+
+func TestErrorHandler_UnknownRoute(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+
+	resp, body := doRequest(t, app, newRequest("GET", "/missing", ""))
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, "NOT_FOUND", body.Error)
+}
+
+// newRequest builds a request with an optional JSON body.
+func newRequest(method, target, body string) *http.Request {
+	if body == "" {
+		return httptest.NewRequest(method, target, nil)
+	}
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// doRequest sends req to app and decodes the ErrorResponse body.
+func doRequest(t *testing.T, app *fiber.App, req *http.Request) (*http.Response, ErrorResponse) {
+	t.Helper()
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var out ErrorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp, out
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type OutboxEventListQuery struct {
+	Status   string `query:"status" validate:"omitempty,oneof=pending published dead"`
+	Page     int    `query:"page" validate:"min=1"`
+	PageSize int    `query:"pageSize" validate:"min=1,max=200"`
+}
+
+// OutboxEventResponse is an outbox event with its dispatch state, which is
+// left out of the event as sinks receive it.
+type OutboxEventResponse struct {
+	domain.OutboxEvent
+	Status        domain.OutboxEventStatus `json:"status"`
+	Attempts      int                      `json:"attempts"`
+	LastError     *string                  `json:"lastError,omitempty"`
+	NextAttemptAt time.Time                `json:"nextAttemptAt"`
+	PublishedAt   *time.Time               `json:"publishedAt,omitempty"`
+	PublishedTo   []string                 `json:"publishedTo,omitempty"`
+}
+
+func newOutboxEventResponse(event domain.OutboxEvent) OutboxEventResponse {
+	return OutboxEventResponse{
+		OutboxEvent:   event,
+		Status:        event.Status,
+		Attempts:      event.Attempts,
+		LastError:     event.LastError,
+		NextAttemptAt: event.NextAttemptAt,
+		PublishedAt:   event.PublishedAt,
+		PublishedTo:   event.PublishedTo,
+	}
+}
+
+type OutboxEventListResponse struct {
+	Data     []OutboxEventResponse `json:"data"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"pageSize"`
+	Total    int                   `json:"total"`
+}
+
+type EventHandler struct {
+	dispatcher *service.EventDispatcher
+}
+
+func NewEventHandler(dispatcher *service.EventDispatcher) *EventHandler {
+	return &EventHandler{dispatcher: dispatcher}
+}
+
+func (h *EventHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/admin/events", h.ListEvents)
+	app.Post("/admin/events/:id/retry", h.RetryEvent)
+}
+
+// ListEvents returns the outbox, newest first, optionally narrowed to one
+// status, e.g. the dead events that need attention.
+func (h *EventHandler) ListEvents(c *fiber.Ctx) error {
+	query := OutboxEventListQuery{Page: 1, PageSize: 20}
+	if fieldErrs := bindQuery(c, &query); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	filter := port.OutboxFilter{
+		Status:   domain.OutboxEventStatus(query.Status),
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}
+	events, total, err := h.dispatcher.ListEvents(filter)
+	if err != nil {
+		return writeError(c, err, "Failed to get events")
+	}
+
+	data := make([]OutboxEventResponse, 0, len(events))
+	for _, event := range events {
+		data = append(data, newOutboxEventResponse(event))
+	}
+	return c.JSON(OutboxEventListResponse{
+		Data:     data,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	})
+}
+
+// RetryEvent queues a dead event again with a fresh set of attempts.
+func (h *EventHandler) RetryEvent(c *fiber.Ctx) error {
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	event, err := h.dispatcher.RetryEvent(c.UserContext(), id)
+	if err != nil {
+		return writeError(c, err, "Failed to retry event")
+	}
+	return c.JSON(newOutboxEventResponse(*event))
+}
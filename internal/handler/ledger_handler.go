@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LedgerVerifyQuery struct {
+	UserID int `query:"userId" validate:"omitempty,min=1"`
+}
+
+type LedgerHandler struct {
+	service *service.LedgerService
+}
+
+func NewLedgerHandler(service *service.LedgerService) *LedgerHandler {
+	return &LedgerHandler{service: service}
+}
+
+func (h *LedgerHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/admin/ledger/verify", h.VerifyChain)
+	app.Get("/admin/ledger/trial-balance", h.TrialBalance)
+}
+
+// VerifyChain reports whether the point_ledger hash chain is intact. It
+// responds 200 when valid and 409 with the first broken link otherwise.
+func (h *LedgerHandler) VerifyChain(c *fiber.Ctx) error {
+	var query LedgerVerifyQuery
+	if fieldErrs := bindQuery(c, &query); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+	var userID *int
+	if query.UserID != 0 {
+		userID = &query.UserID
+	}
+
+	result, err := h.service.VerifyChain(userID)
+	if err != nil {
+		return writeError(c, err, "Failed to verify ledger")
+	}
+
+	if !result.Valid {
+		return c.Status(409).JSON(result)
+	}
+	return c.JSON(result)
+}
+
+// TrialBalance lists the journal balance of every account and whether the
+// books balance.
+func (h *LedgerHandler) TrialBalance(c *fiber.Ctx) error {
+	result, err := h.service.TrialBalance()
+	if err != nil {
+		return writeError(c, err, "Failed to get trial balance")
+	}
+	return c.JSON(result)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"time"
+
+	"workshop4-backend/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// prefersThai reports whether the caller's Accept-Language prefers Thai over
+// English, the default.
+func prefersThai(c *fiber.Ctx) bool {
+	c.Vary(fiber.HeaderAcceptLanguage)
+	if c.AcceptsLanguages("en", "th") != "th" {
+		return false
+	}
+	c.Set(fiber.HeaderContentLanguage, "th")
+	return true
+}
+
+// localizeUser formats member_since for the caller: a Buddhist-era date such
+// as "15/6/2566" for Thai, otherwise the stored ISO 8601 date.
+func localizeUser(c *fiber.Ctx, user *domain.User) {
+	if prefersThai(c) {
+		formatMemberSince(user)
+	}
+}
+
+// localizeUsers is localizeUser for a list of users.
+func localizeUsers(c *fiber.Ctx, users []domain.User) {
+	if !prefersThai(c) {
+		return
+	}
+	for i := range users {
+		formatMemberSince(&users[i])
+	}
+}
+
+func formatMemberSince(user *domain.User) {
+	if t, err := time.Parse(domain.DateLayout, user.MemberSince); err == nil {
+		user.MemberSince = domain.FormatBuddhistDate(t)
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"workshop4-backend/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderActor     = "X-Actor-Id"
+	HeaderRequestID = "X-Request-Id"
+)
+
+// RequestMeta stores the caller identity, request ID and client IP in the
+// request's user context so services can attribute the changes they make.
+// A request ID is generated when the client does not supply one.
+func RequestMeta() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(HeaderRequestID)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		c.Set(HeaderRequestID, requestID)
+
+		actor := c.Get(HeaderActor)
+		if actor == "" {
+			actor = "anonymous"
+		}
+
+		c.SetUserContext(domain.WithRequestMeta(c.UserContext(), domain.RequestMeta{
+			Actor:     actor,
+			RequestID: requestID,
+			IP:        c.IP(),
+		}))
+		return c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationPreferencesRequest replaces a user's notification
+// preferences. Kinds listed in muted are not sent on any channel.
+type NotificationPreferencesRequest struct {
+	Email    *bool                     `json:"email" validate:"required"`
+	SMS      *bool                     `json:"sms" validate:"required"`
+	Language domain.Language           `json:"language" validate:"required,oneof=th en"`
+	Muted    []domain.NotificationKind `json:"muted"`
+}
+
+type NotificationHandler struct {
+	service *service.NotificationService
+}
+
+func NewNotificationHandler(service *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+func (h *NotificationHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/users/:id/notification-preferences", h.GetPreferences)
+	app.Put("/users/:id/notification-preferences", h.UpdatePreferences)
+}
+
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	userID, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	preferences, err := h.service.GetPreferences(userID)
+	if err != nil {
+		return writeError(c, err, "Failed to get notification preferences")
+	}
+	return c.JSON(preferences)
+}
+
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+	var req NotificationPreferencesRequest
+	if fieldErrs := bindBody(c, &req); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	preferences := &domain.NotificationPreferences{
+		UserID:   userID,
+		Email:    *req.Email,
+		SMS:      *req.SMS,
+		Language: req.Language,
+		Muted:    req.Muted,
+	}
+	if err := h.service.UpdatePreferences(c.UserContext(), preferences); err != nil {
+		return writeError(c, err, "Failed to update notification preferences")
+	}
+	return c.JSON(preferences)
+}
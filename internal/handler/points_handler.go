@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PointsRequest struct {
+	Amount    int     `json:"amount" validate:"required,min=1"`
+	Reference *string `json:"reference,omitempty"`
+}
+
+type ExpiringPointsQuery struct {
+	Days int `query:"days" validate:"min=1,max=3660"`
+}
+
+type PointsResponse struct {
+	Entry interface{} `json:"entry"`
+}
+
+type PointsHandler struct {
+	service service.PointsManager
+	expiry  *service.ExpiryService
+}
+
+func NewPointsHandler(service service.PointsManager, expiry *service.ExpiryService) *PointsHandler {
+	return &PointsHandler{service: service, expiry: expiry}
+}
+
+func (h *PointsHandler) RegisterRoutes(app *fiber.App) {
+	app.Post("/users/:id/points/earn", h.Earn)
+	app.Post("/users/:id/points/redeem", h.Redeem)
+	app.Get("/users/:id/points/expiring", h.GetExpiringPoints)
+	app.Post("/admin/points/expire", h.ExpireDuePoints)
+}
+
+func (h *PointsHandler) Earn(c *fiber.Ctx) error {
+	userID, req, fieldErrs := h.parseRequest(c)
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	entry, err := h.service.Earn(c.UserContext(), userID, req.Amount, req.Reference)
+	if err != nil {
+		return writeError(c, err, "Failed to earn points")
+	}
+	return c.Status(201).JSON(PointsResponse{Entry: entry})
+}
+
+func (h *PointsHandler) Redeem(c *fiber.Ctx) error {
+	userID, req, fieldErrs := h.parseRequest(c)
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	entry, err := h.service.Redeem(c.UserContext(), userID, req.Amount, req.Reference)
+	if err != nil {
+		return writeError(c, err, "Failed to redeem points")
+	}
+	return c.Status(201).JSON(PointsResponse{Entry: entry})
+}
+
+func (h *PointsHandler) parseRequest(c *fiber.Ctx) (int, *PointsRequest, []FieldError) {
+	userID, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return 0, nil, fieldErrs
+	}
+
+	var req PointsRequest
+	if fieldErrs := bindBody(c, &req); fieldErrs != nil {
+		return 0, nil, fieldErrs
+	}
+	return userID, &req, nil
+}
+
+// GetExpiringPoints lists the user's points expiring within the next `days`
+// days (default 90).
+func (h *PointsHandler) GetExpiringPoints(c *fiber.Ctx) error {
+	userID, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	query := ExpiringPointsQuery{Days: 90}
+	if fieldErrs := bindQuery(c, &query); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	result, err := h.expiry.GetUpcomingExpirations(userID, query.Days)
+	if err != nil {
+		return writeError(c, err, "Failed to get expiring points")
+	}
+	return c.JSON(result)
+}
+
+// ExpireDuePoints runs the expiry job immediately instead of waiting for the
+// scheduler.
+func (h *PointsHandler) ExpireDuePoints(c *fiber.Ctx) error {
+	run, err := h.expiry.ExpireDue()
+	if err != nil {
+		return writeError(c, err, "Failed to expire points")
+	}
+	return c.JSON(run)
+}
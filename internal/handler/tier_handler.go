@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TierHandler struct {
+	service *service.TierService
+}
+
+func NewTierHandler(service *service.TierService) *TierHandler {
+	return &TierHandler{service: service}
+}
+
+func (h *TierHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/users/:id/tier", h.GetTier)
+	app.Post("/admin/tiers/evaluate", h.EvaluateAll)
+}
+
+// GetTier returns the user's tier, progress towards the next tier and tier
+// history.
+func (h *TierHandler) GetTier(c *fiber.Ctx) error {
+	userID, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	progress, err := h.service.GetTierStatus(userID)
+	if err != nil {
+		return writeError(c, err, "Failed to get tier")
+	}
+	return c.JSON(progress)
+}
+
+// EvaluateAll runs the tier evaluation job immediately instead of waiting for
+// the scheduler.
+func (h *TierHandler) EvaluateAll(c *fiber.Ctx) error {
+	run, err := h.service.EvaluateAll(domain.TierChangeReasonScheduled)
+	if err != nil {
+		return writeError(c, err, "Failed to evaluate tiers")
+	}
+	return c.JSON(run)
+}
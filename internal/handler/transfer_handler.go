@@ -1,18 +1,33 @@
 package handler
 
 import (
-	"strconv"
-
+	"workshop4-backend/internal/domain"
 	"workshop4-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// TransferCreateRequest either names the recipient by toUserId, or confirms
+// a preview by confirmationId, in which case toUserId, amount and note are
+// taken from the preview.
 type TransferCreateRequest struct {
-	FromUserID int     `json:"fromUserId" validate:"required,min=1"`
-	ToUserID   int     `json:"toUserId" validate:"required,min=1"`
-	Amount     int     `json:"amount" validate:"required,min=1"`
-	Note       *string `json:"note,omitempty"`
+	FromUserID     int     `json:"fromUserId" validate:"required,min=1"`
+	ToUserID       int     `json:"toUserId" validate:"required_without=ConfirmationID,omitempty,min=1"`
+	Amount         int     `json:"amount" validate:"required_without=ConfirmationID,omitempty,min=1"`
+	Note           *string `json:"note,omitempty"`
+	ConfirmationID string  `json:"confirmationId,omitempty"`
+}
+
+// TransferPreviewRequest names the recipient by phone, email or member ID.
+type TransferPreviewRequest struct {
+	FromUserID int                        `json:"fromUserId" validate:"required,min=1"`
+	Recipient  domain.RecipientIdentifier `json:"recipient"`
+	Amount     int                        `json:"amount" validate:"required,min=1"`
+	Note       *string                    `json:"note,omitempty"`
+}
+
+type TransferPreviewResponse struct {
+	Confirmation interface{} `json:"confirmation"`
 }
 
 type TransferCreateResponse struct {
@@ -23,6 +38,12 @@ type TransferGetResponse struct {
 	Transfer interface{} `json:"transfer"`
 }
 
+type TransferListQuery struct {
+	UserID   int `query:"userId" validate:"required,min=1"`
+	Page     int `query:"page" validate:"omitempty,min=1"`
+	PageSize int `query:"pageSize" validate:"omitempty,min=1,max=200"`
+}
+
 type TransferListResponse struct {
 	Data     interface{} `json:"data"`
 	Page     int         `json:"page"`
@@ -30,21 +51,16 @@ type TransferListResponse struct {
 	Total    int         `json:"total"`
 }
 
-type ErrorResponse struct {
-	Error   string      `json:"error"`
-	Message string      `json:"message"`
-	Details interface{} `json:"details,omitempty"`
-}
-
 type TransferHandler struct {
-	service *service.TransferService
+	service service.TransferManager
 }
 
-func NewTransferHandler(service *service.TransferService) *TransferHandler {
+func NewTransferHandler(service service.TransferManager) *TransferHandler {
 	return &TransferHandler{service: service}
 }
 
 func (h *TransferHandler) RegisterRoutes(app *fiber.App) {
+	app.Post("/transfers/preview", h.PreviewTransfer)
 	app.Post("/transfers", h.CreateTransfer)
 	app.Get("/transfers", h.GetTransfers)
 	app.Get("/transfers/:id", h.GetTransferByID)
@@ -52,57 +68,17 @@ func (h *TransferHandler) RegisterRoutes(app *fiber.App) {
 
 func (h *TransferHandler) CreateTransfer(c *fiber.Ctx) error {
 	var req TransferCreateRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "VALIDATION_ERROR",
-			Message: "Invalid request body",
-		})
+	if fieldErrs := bindBody(c, &req); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
 	}
 
-	// Validate required fields
-	if req.FromUserID <= 0 {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "VALIDATION_ERROR",
-			Message: "fromUserId must be greater than 0",
-		})
-	}
-	if req.ToUserID <= 0 {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "VALIDATION_ERROR",
-			Message: "toUserId must be greater than 0",
-		})
-	}
-	if req.Amount <= 0 {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "VALIDATION_ERROR",
-			Message: "amount must be greater than 0",
-		})
+	if req.ConfirmationID != "" {
+		return h.confirmTransfer(c, req)
 	}
 
-	transfer, err := h.service.CreateTransfer(req.FromUserID, req.ToUserID, req.Amount, req.Note)
+	transfer, err := h.service.CreateTransfer(c.UserContext(), req.FromUserID, req.ToUserID, req.Amount, req.Note)
 	if err != nil {
-		switch err {
-		case service.ErrSelfTransfer:
-			return c.Status(422).JSON(ErrorResponse{
-				Error:   "SELF_TRANSFER",
-				Message: "Cannot transfer to yourself",
-			})
-		case service.ErrInsufficientBalance:
-			return c.Status(409).JSON(ErrorResponse{
-				Error:   "INSUFFICIENT_BALANCE",
-				Message: "Insufficient balance",
-			})
-		case service.ErrUserNotFound:
-			return c.Status(400).JSON(ErrorResponse{
-				Error:   "USER_NOT_FOUND",
-				Message: "User not found",
-			})
-		default:
-			return c.Status(500).JSON(ErrorResponse{
-				Error:   "INTERNAL_ERROR",
-				Message: "Failed to create transfer",
-			})
-		}
+		return writeError(c, err, "Failed to create transfer")
 	}
 
 	// Set idempotency key header
@@ -113,6 +89,39 @@ func (h *TransferHandler) CreateTransfer(c *fiber.Ctx) error {
 	})
 }
 
+// confirmTransfer makes a previewed transfer. The response names the
+// recipient by masked name, as the preview did, rather than by user ID.
+func (h *TransferHandler) confirmTransfer(c *fiber.Ctx, req TransferCreateRequest) error {
+	transfer, err := h.service.ConfirmTransfer(c.UserContext(), req.FromUserID, req.ConfirmationID)
+	if err != nil {
+		return writeError(c, err, "Failed to create transfer")
+	}
+
+	c.Set("Idempotency-Key", transfer.IdempotencyKey)
+
+	return c.Status(201).JSON(TransferCreateResponse{
+		Transfer: transfer,
+	})
+}
+
+// PreviewTransfer resolves the recipient and returns their masked name with
+// a confirmationId to pass to POST /transfers.
+func (h *TransferHandler) PreviewTransfer(c *fiber.Ctx) error {
+	var req TransferPreviewRequest
+	if fieldErrs := bindBody(c, &req); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	confirmation, err := h.service.PreviewTransfer(c.UserContext(), req.FromUserID, req.Recipient, req.Amount, req.Note)
+	if err != nil {
+		return writeError(c, err, "Failed to preview transfer")
+	}
+
+	return c.JSON(TransferPreviewResponse{
+		Confirmation: confirmation,
+	})
+}
+
 func (h *TransferHandler) GetTransferByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
@@ -124,16 +133,7 @@ func (h *TransferHandler) GetTransferByID(c *fiber.Ctx) error {
 
 	transfer, err := h.service.GetTransferByIdempotencyKey(id)
 	if err != nil {
-		if err == service.ErrTransferNotFound {
-			return c.Status(404).JSON(ErrorResponse{
-				Error:   "NOT_FOUND",
-				Message: "Transfer not found",
-			})
-		}
-		return c.Status(500).JSON(ErrorResponse{
-			Error:   "INTERNAL_ERROR",
-			Message: "Failed to get transfer",
-		})
+		return writeError(c, err, "Failed to get transfer")
 	}
 
 	return c.JSON(TransferGetResponse{
@@ -142,44 +142,15 @@ func (h *TransferHandler) GetTransferByID(c *fiber.Ctx) error {
 }
 
 func (h *TransferHandler) GetTransfers(c *fiber.Ctx) error {
-	// Get userID from query parameter
-	userIDStr := c.Query("userId")
-	if userIDStr == "" {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "VALIDATION_ERROR",
-			Message: "userId query parameter is required",
-		})
-	}
-
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil || userID <= 0 {
-		return c.Status(400).JSON(ErrorResponse{
-			Error:   "VALIDATION_ERROR",
-			Message: "userId must be a valid positive integer",
-		})
-	}
-
-	// Get pagination parameters
-	page := 1
-	if pageStr := c.Query("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	pageSize := 20
-	if pageSizeStr := c.Query("pageSize"); pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 200 {
-			pageSize = ps
-		}
+	query := TransferListQuery{Page: 1, PageSize: 20}
+	if fieldErrs := bindQuery(c, &query); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
 	}
+	userID, page, pageSize := query.UserID, query.Page, query.PageSize
 
 	transfers, total, err := h.service.GetTransfersByUserID(userID, page, pageSize)
 	if err != nil {
-		return c.Status(500).JSON(ErrorResponse{
-			Error:   "INTERNAL_ERROR",
-			Message: "Failed to get transfers",
-		})
+		return writeError(c, err, "Failed to get transfers")
 	}
 
 	return c.JSON(TransferListResponse{
@@ -1,91 +1,269 @@
 package handler
 
 import (
+	"encoding/json"
 	"strconv"
+	"strings"
 	"time"
 
 	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
 	"workshop4-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// UserListQuery filters GET /users. Dates are Gregorian YYYY-MM-DD.
+type UserListQuery struct {
+	Name            string `query:"name" validate:"omitempty,max=100"`
+	Level           string `query:"level" validate:"omitempty,max=50"`
+	MemberSinceFrom string `query:"memberSinceFrom" validate:"omitempty,datetime=2006-01-02"`
+	MemberSinceTo   string `query:"memberSinceTo" validate:"omitempty,datetime=2006-01-02"`
+	MinPoints       *int   `query:"minPoints" validate:"omitempty,min=0"`
+	MaxPoints       *int   `query:"maxPoints" validate:"omitempty,min=0"`
+	Sort            string `query:"sort" validate:"omitempty,oneof=id name points memberSince createdAt"`
+	Order           string `query:"order" validate:"omitempty,oneof=asc desc"`
+	Page            int    `query:"page" validate:"min=1"`
+	PageSize        int    `query:"pageSize" validate:"min=1,max=200"`
+}
+
+type UserSearchQuery struct {
+	Q     string `query:"q" validate:"required,max=100"`
+	Limit int    `query:"limit" validate:"min=1,max=100"`
+}
+
+type UserSearchResponse struct {
+	Data interface{} `json:"data"`
+}
+
+type UserListResponse struct {
+	Data     interface{} `json:"data"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"pageSize"`
+	Total    int         `json:"total"`
+}
+
+// CreateUserRequest holds the fields a client may set on a new user. Status,
+// tier, points and member ID are managed by the server.
+type CreateUserRequest struct {
+	Name        string `json:"name"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	MemberSince string `json:"member_since"`
+}
+
+type CloseUserQuery struct {
+	Forfeit bool `query:"forfeit"`
+}
+
 type UserHandler struct {
-	service *service.UserService
+	service service.UserManager
 }
 
-func NewUserHandler(service *service.UserService) *UserHandler {
+func NewUserHandler(service service.UserManager) *UserHandler {
 	return &UserHandler{service: service}
 }
 
 func (h *UserHandler) RegisterRoutes(app *fiber.App) {
-	app.Get("/users", h.GetAllUsers)
+	app.Get("/users", h.ListUsers)
+	app.Get("/users/search", h.SearchUsers)
+	app.Get("/users/by-member/:memberId", h.GetUserByMemberID)
 	app.Get("/users/:id", h.GetUserByID)
 	app.Post("/users", h.CreateUser)
 	app.Put("/users/:id", h.UpdateUser)
+	app.Patch("/users/:id", h.PatchUser)
 	app.Delete("/users/:id", h.DeleteUser)
 }
 
-func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
-	users, err := h.service.GetAllUsers()
+// ListUsers returns one page of the open accounts matching the query.
+func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
+	query := UserListQuery{Page: 1, PageSize: 20}
+	if fieldErrs := bindQuery(c, &query); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	filter := port.UserFilter{
+		NamePrefix:      strings.TrimSpace(query.Name),
+		MembershipLevel: query.Level,
+		MinPoints:       query.MinPoints,
+		MaxPoints:       query.MaxPoints,
+		Sort:            port.UserSort(query.Sort),
+		Descending:      query.Order == "desc",
+		Page:            query.Page,
+		PageSize:        query.PageSize,
+	}
+	// Already validated by the datetime tag
+	if query.MemberSinceFrom != "" {
+		from, _ := time.Parse("2006-01-02", query.MemberSinceFrom)
+		filter.MemberSinceFrom = &from
+	}
+	if query.MemberSinceTo != "" {
+		to, _ := time.Parse("2006-01-02", query.MemberSinceTo)
+		filter.MemberSinceTo = &to
+	}
+
+	users, total, err := h.service.ListUsers(filter)
+	if err != nil {
+		return writeError(c, err, "Failed to fetch users")
+	}
+
+	localizeUsers(c, users)
+	return c.JSON(UserListResponse{
+		Data:     users,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	})
+}
+
+// SearchUsers finds members by partial name, email, phone or member ID.
+func (h *UserHandler) SearchUsers(c *fiber.Ctx) error {
+	query := UserSearchQuery{Limit: 20}
+	if fieldErrs := bindQuery(c, &query); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	users, err := h.service.SearchUsers(query.Q, query.Limit)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch users"})
+		return writeError(c, err, "Failed to search users")
 	}
-	return c.JSON(users)
+	localizeUsers(c, users)
+	return c.JSON(UserSearchResponse{Data: users})
 }
 
 func (h *UserHandler) GetUserByID(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid user ID"})
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
 	}
 	user, err := h.service.GetUserByID(id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch user"})
+		return writeError(c, err, "Failed to fetch user")
 	}
-	if user == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+	setETag(c, user)
+	localizeUser(c, user)
+	return c.JSON(user)
+}
+
+// GetUserByMemberID finds a user by the member ID printed on their card.
+func (h *UserHandler) GetUserByMemberID(c *fiber.Ctx) error {
+	user, err := h.service.GetUserByMemberID(c.Params("memberId"))
+	if err != nil {
+		return writeError(c, err, "Failed to fetch user")
 	}
+	setETag(c, user)
+	localizeUser(c, user)
 	return c.JSON(user)
 }
 
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
-	var newUser domain.User
-	if err := c.BodyParser(&newUser); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	var req CreateUserRequest
+	if fieldErrs := bindBody(c, &req); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
 	}
-	newUser.CreatedAt = time.Now()
-	newUser.UpdatedAt = time.Now()
-	if err := h.service.CreateUser(&newUser); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create user"})
+	newUser := domain.User{Name: req.Name, Phone: req.Phone, Email: req.Email, MemberSince: req.MemberSince}
+	if err := h.service.CreateUser(c.UserContext(), &newUser); err != nil {
+		return writeError(c, err, "Failed to create user")
 	}
+	setETag(c, &newUser)
+	localizeUser(c, &newUser)
 	return c.Status(201).JSON(newUser)
 }
 
 func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+	version, err := ifMatchVersion(c)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 	var updateUser domain.User
-	if err := c.BodyParser(&updateUser); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	if fieldErrs := bindBody(c, &updateUser); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
 	}
 	updateUser.ID = id
-	updateUser.UpdatedAt = time.Now()
-	if err := h.service.UpdateUser(&updateUser); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update user"})
+	updateUser.Version = version
+	if err := h.service.UpdateUser(c.UserContext(), &updateUser); err != nil {
+		return writeError(c, err, "Failed to update user")
 	}
+	setETag(c, &updateUser)
+	localizeUser(c, &updateUser)
 	return c.JSON(updateUser)
 }
 
+// PatchUser changes only the fields present in a JSON Merge Patch body;
+// name, phone and email may be patched.
+func (h *UserHandler) PatchUser(c *fiber.Ctx) error {
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	var patch domain.UserPatch
+	if err := json.Unmarshal(c.Body(), &patch); err != nil || patch == nil {
+		return validationFailed(c, FieldError{Field: "body", Code: domain.FieldCodeInvalid, Message: "must be a JSON object"})
+	}
+	user, err := h.service.PatchUser(c.UserContext(), id, version, patch)
+	if err != nil {
+		return writeError(c, err, "Failed to update user")
+	}
+	setETag(c, user)
+	localizeUser(c, user)
+	return c.JSON(user)
+}
+
+// DeleteUser closes the account; the user is kept with status closed. An
+// account that still holds points is only closed with ?forfeit=true.
 func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+	var query CloseUserQuery
+	if fieldErrs := bindQuery(c, &query); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	user, err := h.service.CloseUser(c.UserContext(), id, version, query.Forfeit)
+	if err != nil {
+		return writeError(c, err, "Failed to close account")
+	}
+	setETag(c, user)
+	localizeUser(c, user)
+	return c.JSON(user)
+}
+
+// setETag reports the user's version as its ETag.
+func setETag(c *fiber.Ctx, user *domain.User) {
+	c.Set(fiber.HeaderETag, strconv.Quote(strconv.Itoa(user.Version)))
+}
+
+// ifMatchVersion reads the user version the client last saw from If-Match,
+// which PUT, PATCH and DELETE require. "*" matches any version and yields 0;
+// a value that is not one of our ETags can never match.
+func ifMatchVersion(c *fiber.Ctx) (int, error) {
+	value := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	switch value {
+	case "":
+		return 0, fiber.NewError(428, "If-Match header with the user's ETag is required")
+	case "*":
+		return 0, nil
+	}
+	unquoted, err := strconv.Unquote(value)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid user ID"})
+		return 0, service.ErrVersionMismatch
 	}
-	if err := h.service.DeleteUser(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete user"})
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, service.ErrVersionMismatch
 	}
-	return c.JSON(fiber.Map{"message": "User deleted successfully"})
+	return version, nil
 }
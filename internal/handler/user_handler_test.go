@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubUserManager returns err from every call and keeps the last list
+// filter and created user.
+type stubUserManager struct {
+	err     error
+	filter  port.UserFilter
+	created *domain.User
+}
+
+func (s *stubUserManager) ListUsers(filter port.UserFilter) ([]domain.User, int, error) {
+	s.filter = filter
+	return []domain.User{}, 0, s.err
+}
+
+func (s *stubUserManager) SearchUsers(query string, limit int) ([]domain.User, error) {
+	return []domain.User{}, s.err
+}
+
+func (s *stubUserManager) GetUserByID(id int) (*domain.User, error) {
+	return &domain.User{ID: id, Version: 7, MemberSince: "2023-06-15"}, s.err
+}
+
+func (s *stubUserManager) GetUserByMemberID(memberID string) (*domain.User, error) {
+	return &domain.User{MemberID: memberID}, s.err
+}
+
+func (s *stubUserManager) CreateUser(ctx context.Context, user *domain.User) error {
+	s.created = user
+	return s.err
+}
+
+func (s *stubUserManager) UpdateUser(ctx context.Context, user *domain.User) error { return s.err }
+
+func (s *stubUserManager) PatchUser(ctx context.Context, id, version int, patch domain.UserPatch) (*domain.User, error) {
+	return &domain.User{ID: id}, s.err
+}
+
+func (s *stubUserManager) CloseUser(ctx context.Context, id, version int, forfeit bool) (*domain.User, error) {
+	return &domain.User{ID: id, Status: domain.AccountStatusClosed}, s.err
+}
+
+func newUserApp(err error) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	NewUserHandler(&stubUserManager{err: err}).RegisterRoutes(app)
+	return app
+}
+
+// withIfMatch sets the If-Match header on req.
+func withIfMatch(req *http.Request, etag string) *http.Request {
+	req.Header.Set("If-Match", etag)
+	return req
+}
+
+func TestUserHandler_DeleteUser_NotFound(t *testing.T) {
+	resp, body := doRequest(t, newUserApp(service.ErrUserNotFound), withIfMatch(newRequest("DELETE", "/users/999", ""), `"1"`))
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, "USER_NOT_FOUND", body.Error)
+}
+
+func TestUserHandler_ListUsers_Filter(t *testing.T) {
+	stub := &stubUserManager{}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	NewUserHandler(stub).RegisterRoutes(app)
+
+	target := "/users?name=" + url.QueryEscape("สมช") + "&level=Gold&minPoints=100&memberSinceFrom=2023-01-01&sort=points&order=desc&page=2&pageSize=5"
+	resp, _ := doRequest(t, app, newRequest("GET", target, ""))
+	assert.Equal(t, 200, resp.StatusCode)
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	minPoints := 100
+	assert.Equal(t, port.UserFilter{
+		NamePrefix:      "สมช",
+		MembershipLevel: "Gold",
+		MemberSinceFrom: &from,
+		MinPoints:       &minPoints,
+		Sort:            port.UserSortPoints,
+		Descending:      true,
+		Page:            2,
+		PageSize:        5,
+	}, stub.filter)
+}
+
+func TestUserHandler_ListUsers_InvalidQuery(t *testing.T) {
+	for _, query := range []string{"sort=phone", "order=up", "minPoints=-1", "memberSinceTo=15/6/2566", "pageSize=500"} {
+		resp, body := doRequest(t, newUserApp(nil), newRequest("GET", "/users?"+query, ""))
+		assert.Equal(t, 400, resp.StatusCode, query)
+		assert.Equal(t, "VALIDATION_ERROR", body.Error, query)
+	}
+}
+
+func TestUserHandler_ListUsers_InvalidDate(t *testing.T) {
+	resp, body := doRequest(t, newUserApp(nil), newRequest("GET", "/users?memberSinceFrom=15/6/2566", ""))
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.Equal(t, []FieldError{
+		{Field: "memberSinceFrom", Code: domain.FieldCodeInvalid, Message: "must be a date (YYYY-MM-DD)"},
+	}, body.Details)
+}
+
+func TestUserHandler_SearchUsers(t *testing.T) {
+	resp, _ := doRequest(t, newUserApp(nil), newRequest("GET", "/users/search?q="+url.QueryEscape("สมชาย"), ""))
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, body := doRequest(t, newUserApp(nil), newRequest("GET", "/users/search", ""))
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.Equal(t, []FieldError{{Field: "q", Code: domain.FieldCodeRequired, Message: "is required"}}, body.Details)
+}
+
+func TestUserHandler_GetUserByID_NotFound(t *testing.T) {
+	resp, body := doRequest(t, newUserApp(service.ErrUserNotFound), newRequest("GET", "/users/999", ""))
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, "USER_NOT_FOUND", body.Error)
+}
+
+func TestUserHandler_GetUserByID_SetsETag(t *testing.T) {
+	resp, _ := doRequest(t, newUserApp(nil), newRequest("GET", "/users/1", ""))
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, `"7"`, resp.Header.Get("ETag"))
+}
+
+func TestUserHandler_GetUserByID_AcceptLanguage(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "2023-06-15"},
+		{"en-US,en;q=0.9", "2023-06-15"},
+		{"th", "15/6/2566"},
+		{"th-TH,th;q=0.9,en;q=0.8", "15/6/2566"},
+		{"en;q=0.9,th;q=0.5", "2023-06-15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			req := newRequest("GET", "/users/1", "")
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			resp, err := newUserApp(nil).Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var user domain.User
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+			assert.Equal(t, tt.want, user.MemberSince)
+			assert.Equal(t, "Accept-Language", resp.Header.Get("Vary"))
+		})
+	}
+}
+
+func TestUserHandler_CreateUser_ServiceValidation(t *testing.T) {
+	verr := &domain.ValidationError{}
+	verr.Add("phone", domain.FieldCodeInvalid, domain.ErrInvalidPhone.Error())
+
+	resp, body := doRequest(t, newUserApp(verr), newRequest("POST", "/users",
+		`{"name":"Somchai","email":"somchai@example.com","phone":"12345"}`))
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.Equal(t, "VALIDATION_ERROR", body.Error)
+	assert.Equal(t, []FieldError{{Field: "phone", Code: domain.FieldCodeInvalid, Message: domain.ErrInvalidPhone.Error()}}, body.Details)
+}
+
+func TestUserHandler_CreateUser_IgnoresServerManagedFields(t *testing.T) {
+	stub := &stubUserManager{}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	NewUserHandler(stub).RegisterRoutes(app)
+
+	resp, _ := doRequest(t, app, newRequest("POST", "/users",
+		`{"name":"Somchai","email":"somchai@example.com","phone":"0812345678","member_since":"2023-06-15",`+
+			`"status":"closed","version":9,"deleted_at":"2024-01-01T00:00:00Z","membership_level":"Diamond","points":500,"member_id":"LBK0000018"}`))
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, &domain.User{Name: "Somchai", Email: "somchai@example.com", Phone: "0812345678", MemberSince: "2023-06-15"}, stub.created)
+}
+
+func TestUserHandler_InvalidID(t *testing.T) {
+	resp, body := doRequest(t, newUserApp(nil), newRequest("DELETE", "/users/abc", ""))
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.Equal(t, []FieldError{{Field: "id", Code: domain.FieldCodeInvalid, Message: "must be a positive integer"}}, body.Details)
+}
+
+func TestUserHandler_PatchUser_NotFound(t *testing.T) {
+	resp, body := doRequest(t, newUserApp(service.ErrUserNotFound), withIfMatch(newRequest("PATCH", "/users/999", `{"name":"Somchai"}`), "*"))
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, "USER_NOT_FOUND", body.Error)
+}
+
+func TestUserHandler_PatchUser_RejectsNonObject(t *testing.T) {
+	for _, payload := range []string{`[]`, `"name"`, `null`, `{bad`} {
+		resp, body := doRequest(t, newUserApp(nil), withIfMatch(newRequest("PATCH", "/users/1", payload), `"1"`))
+		assert.Equal(t, 400, resp.StatusCode, payload)
+		if assert.Len(t, body.Details, 1, payload) {
+			assert.Equal(t, "body", body.Details[0].Field, payload)
+		}
+	}
+}
+
+func TestUserHandler_IfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		ifMatch    string
+		serviceErr error
+		wantStatus int
+		wantCode   string
+	}{
+		{"PUT without If-Match", "PUT", "", nil, 428, "PRECONDITION_REQUIRED"},
+		{"PATCH without If-Match", "PATCH", "", nil, 428, "PRECONDITION_REQUIRED"},
+		{"DELETE without If-Match", "DELETE", "", nil, 428, "PRECONDITION_REQUIRED"},
+		{"unquoted ETag", "PATCH", "7", nil, 412, "VERSION_MISMATCH"},
+		{"weak ETag", "PATCH", `W/"7"`, nil, 412, "VERSION_MISMATCH"},
+		{"stale version", "PUT", `"6"`, service.ErrVersionMismatch, 412, "VERSION_MISMATCH"},
+		{"current version", "PATCH", `"7"`, nil, 200, ""},
+		{"any version", "DELETE", "*", nil, 200, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(tt.method, "/users/1", `{"name":"Somchai","email":"somchai@example.com","phone":"0812345678"}`)
+			if tt.ifMatch != "" {
+				withIfMatch(req, tt.ifMatch)
+			}
+			resp, body := doRequest(t, newUserApp(tt.serviceErr), req)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantCode, body.Error)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamRetry is how long clients wait before reconnecting a dropped stream.
+const streamRetry = 3 * time.Second
+
+type UserStreamHandler struct {
+	service   *service.UserStreamService
+	heartbeat time.Duration
+}
+
+func NewUserStreamHandler(service *service.UserStreamService, heartbeat time.Duration) *UserStreamHandler {
+	return &UserStreamHandler{service: service, heartbeat: heartbeat}
+}
+
+func (h *UserStreamHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/users/:id/events", h.StreamEvents)
+}
+
+// StreamEvents streams the user's balance changes and incoming transfers as
+// Server-Sent Events. A client reconnecting with Last-Event-ID gets the
+// events it missed first.
+func (h *UserStreamHandler) StreamEvents(c *fiber.Ctx) error {
+	userID, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	subscription, err := h.service.Subscribe(c.UserContext(), userID, c.Get("Last-Event-ID"))
+	if err != nil {
+		return writeError(c, err, "Failed to open event stream")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Stop proxies such as nginx from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer subscription.Close()
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+		for _, event := range subscription.Replay {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		// A failed flush means the client went away
+		for w.Flush() == nil {
+			select {
+			case event, ok := <-subscription.Events:
+				// Closed when the client fell behind; it resumes on reconnect
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				w.WriteString(": keep-alive\n\n")
+			}
+		}
+	})
+	return nil
+}
+
+// writeEvent writes event in the text/event-stream format. Events without
+// an ID, such as the balance snapshot, leave the client's last ID alone.
+func writeEvent(w *bufio.Writer, event domain.UserEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	if event.ID != "" {
+		fmt.Fprintf(w, "id: %s\n", event.ID)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"workshop4-backend/internal/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// Report fields by their JSON (or query) names
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name := strings.Split(field.Tag.Get(tag), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	return v
+}
+
+// bindBody parses the JSON body into req and checks its validate tags. On
+// failure it returns the field errors to report; a malformed body is
+// reported against the "body" field.
+func bindBody(c *fiber.Ctx, req interface{}) []FieldError {
+	if err := c.BodyParser(req); err != nil {
+		return []FieldError{{Field: "body", Code: domain.FieldCodeInvalid, Message: "must be a valid JSON object"}}
+	}
+	return validateStruct(req)
+}
+
+// bindQuery parses the query string into req and checks its validate tags.
+func bindQuery(c *fiber.Ctx, req interface{}) []FieldError {
+	if err := c.QueryParser(req); err != nil {
+		return []FieldError{{Field: "query", Code: domain.FieldCodeInvalid, Message: "contains a malformed parameter"}}
+	}
+	return validateStruct(req)
+}
+
+// validateStruct checks req against its validate tags and returns one
+// FieldError per failing field.
+func validateStruct(req interface{}) []FieldError {
+	err := validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	verrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []FieldError{{Field: "body", Code: domain.FieldCodeInvalid, Message: err.Error()}}
+	}
+
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, toFieldError(fe))
+	}
+	return fields
+}
+
+func toFieldError(fe validator.FieldError) FieldError {
+	// Drop the struct name so nested fields read as "recipient.type"
+	field := fe.Namespace()
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+
+	switch fe.Tag() {
+	case "required", "required_without":
+		return FieldError{Field: field, Code: domain.FieldCodeRequired, Message: "is required"}
+	case "min":
+		return FieldError{Field: field, Code: domain.FieldCodeInvalid, Message: "must be at least " + fe.Param()}
+	case "max":
+		return FieldError{Field: field, Code: domain.FieldCodeInvalid, Message: "must be at most " + fe.Param()}
+	case "oneof":
+		return FieldError{Field: field, Code: domain.FieldCodeInvalid, Message: "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")}
+	case "datetime":
+		return FieldError{Field: field, Code: domain.FieldCodeInvalid, Message: datetimeMessage(fe.Param())}
+	case "email":
+		return FieldError{Field: field, Code: domain.FieldCodeInvalid, Message: domain.ErrInvalidEmail.Error()}
+	default:
+		return FieldError{Field: field, Code: domain.FieldCodeInvalid, Message: fmt.Sprintf("failed %s validation", fe.Tag())}
+	}
+}
+
+// datetimeMessage describes the layout a datetime field must match.
+func datetimeMessage(layout string) string {
+	switch layout {
+	case time.DateOnly:
+		return "must be a date (YYYY-MM-DD)"
+	case time.RFC3339:
+		return "must be an RFC3339 timestamp"
+	default:
+		return "must match the layout " + layout
+	}
+}
+
+// positiveIntParam reads the named route parameter as a positive integer.
+func positiveIntParam(c *fiber.Ctx, name string) (int, []FieldError) {
+	value, err := strconv.Atoi(c.Params(name))
+	if err != nil || value <= 0 {
+		return 0, []FieldError{{Field: name, Code: domain.FieldCodeInvalid, Message: "must be a positive integer"}}
+	}
+	return value, nil
+}
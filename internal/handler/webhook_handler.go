@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookSubscriptionRequest subscribes url to the named events, limited to
+// the members in userIds when given. Requests are signed with secret, which
+// is not shown again.
+type WebhookSubscriptionRequest struct {
+	URL        string             `json:"url" validate:"required,max=2048"`
+	EventTypes []domain.EventName `json:"eventTypes" validate:"required,min=1"`
+	UserIDs    []int              `json:"userIds"`
+	Secret     string             `json:"secret" validate:"required,max=256"`
+}
+
+type WebhookDeliveryListQuery struct {
+	Status   string `query:"status" validate:"omitempty,oneof=pending delivered dead"`
+	Page     int    `query:"page" validate:"min=1"`
+	PageSize int    `query:"pageSize" validate:"min=1,max=200"`
+}
+
+type WebhookSubscriptionListResponse struct {
+	Data interface{} `json:"data"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Data     interface{} `json:"data"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"pageSize"`
+	Total    int         `json:"total"`
+}
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+func (h *WebhookHandler) RegisterRoutes(app *fiber.App) {
+	app.Post("/admin/webhooks", h.CreateSubscription)
+	app.Get("/admin/webhooks", h.ListSubscriptions)
+	app.Get("/admin/webhooks/:id", h.GetSubscription)
+	app.Delete("/admin/webhooks/:id", h.DeleteSubscription)
+	app.Get("/admin/webhooks/:id/deliveries", h.ListDeliveries)
+	app.Post("/admin/webhooks/deliveries/:id/redeliver", h.Redeliver)
+}
+
+func (h *WebhookHandler) CreateSubscription(c *fiber.Ctx) error {
+	var req WebhookSubscriptionRequest
+	if fieldErrs := bindBody(c, &req); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	subscription := &domain.WebhookSubscription{URL: req.URL, EventTypes: req.EventTypes, UserIDs: req.UserIDs, Secret: req.Secret}
+	if err := h.service.CreateSubscription(c.UserContext(), subscription); err != nil {
+		return writeError(c, err, "Failed to create webhook subscription")
+	}
+	return c.Status(201).JSON(subscription)
+}
+
+func (h *WebhookHandler) ListSubscriptions(c *fiber.Ctx) error {
+	subscriptions, err := h.service.ListSubscriptions()
+	if err != nil {
+		return writeError(c, err, "Failed to get webhook subscriptions")
+	}
+	return c.JSON(WebhookSubscriptionListResponse{Data: subscriptions})
+}
+
+func (h *WebhookHandler) GetSubscription(c *fiber.Ctx) error {
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	subscription, err := h.service.GetSubscription(id)
+	if err != nil {
+		return writeError(c, err, "Failed to get webhook subscription")
+	}
+	return c.JSON(subscription)
+}
+
+// DeleteSubscription stops deliveries to the subscription and drops its
+// delivery log.
+func (h *WebhookHandler) DeleteSubscription(c *fiber.Ctx) error {
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	if err := h.service.DeleteSubscription(c.UserContext(), id); err != nil {
+		return writeError(c, err, "Failed to delete webhook subscription")
+	}
+	return c.SendStatus(204)
+}
+
+// ListDeliveries returns the subscription's delivery log, newest first,
+// optionally narrowed to one status.
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+	query := WebhookDeliveryListQuery{Page: 1, PageSize: 20}
+	if fieldErrs := bindQuery(c, &query); fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	filter := port.WebhookDeliveryFilter{
+		SubscriptionID: id,
+		Status:         domain.WebhookDeliveryStatus(query.Status),
+		Page:           query.Page,
+		PageSize:       query.PageSize,
+	}
+	deliveries, total, err := h.service.ListDeliveries(filter)
+	if err != nil {
+		return writeError(c, err, "Failed to get webhook deliveries")
+	}
+
+	return c.JSON(WebhookDeliveryListResponse{
+		Data:     deliveries,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	})
+}
+
+// Redeliver queues a dead delivery again with a fresh set of attempts.
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	id, fieldErrs := positiveIntParam(c, "id")
+	if fieldErrs != nil {
+		return validationFailed(c, fieldErrs...)
+	}
+
+	delivery, err := h.service.Redeliver(c.UserContext(), id)
+	if err != nil {
+		return writeError(c, err, "Failed to redeliver webhook")
+	}
+	return c.JSON(delivery)
+}
@@ -0,0 +1,8 @@
+package port
+
+import "workshop4-backend/internal/domain"
+
+type AccountStatusRepository interface {
+	CreateHistory(change *domain.AccountStatusChange) error
+	GetHistoryByUserID(userID int) ([]domain.AccountStatusChange, error)
+}
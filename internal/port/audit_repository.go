@@ -0,0 +1,24 @@
+package port
+
+import (
+	"time"
+
+	"workshop4-backend/internal/domain"
+)
+
+// AuditFilter narrows an audit log query. Zero values are ignored.
+type AuditFilter struct {
+	Actor      string
+	Action     domain.AuditAction
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PageSize   int
+}
+
+type AuditRepository interface {
+	Create(entry *domain.AuditEntry) error
+	List(filter AuditFilter) ([]domain.AuditEntry, int, error)
+}
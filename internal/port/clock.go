@@ -0,0 +1,8 @@
+package port
+
+import "time"
+
+// Clock tells services the current time, so tests can control it.
+type Clock interface {
+	Now() time.Time
+}
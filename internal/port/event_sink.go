@@ -0,0 +1,13 @@
+package port
+
+import (
+	"context"
+
+	"workshop4-backend/internal/domain"
+)
+
+// EventSink receives events published from the outbox. Delivery is at least
+// once, so sinks must tolerate an event they have already accepted.
+type EventSink interface {
+	Publish(ctx context.Context, event domain.OutboxEvent) error
+}
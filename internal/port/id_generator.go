@@ -0,0 +1,7 @@
+package port
+
+// IDGenerator issues the random identifiers services hand out, such as
+// transfer idempotency keys, so tests can make them predictable.
+type IDGenerator interface {
+	NewID() string
+}
@@ -0,0 +1,10 @@
+package port
+
+import "workshop4-backend/internal/domain"
+
+type JournalRepository interface {
+	Create(entry *domain.JournalEntry) error
+	GetByTransferID(transferID int) ([]domain.JournalEntry, error)
+	HasPostings(account string) (bool, error)
+	GetAccountBalances() ([]domain.AccountBalance, error)
+}
@@ -0,0 +1,18 @@
+package port
+
+import (
+	"time"
+
+	"workshop4-backend/internal/domain"
+)
+
+type LotRepository interface {
+	Create(lot *domain.PointLot) error
+	// GetOpenByUserID returns the user's lots with points remaining, in
+	// FIFO order (earliest expiry first).
+	GetOpenByUserID(userID int) ([]domain.PointLot, error)
+	// GetExpired returns lots with points remaining that expire at or
+	// before asOf.
+	GetExpired(asOf time.Time) ([]domain.PointLot, error)
+	UpdateRemaining(id int, remaining int) error
+}
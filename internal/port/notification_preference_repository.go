@@ -0,0 +1,11 @@
+package port
+
+import "workshop4-backend/internal/domain"
+
+type NotificationPreferenceRepository interface {
+	// Get returns the user's saved preferences, or nil if they never saved
+	// any.
+	Get(userID int) (*domain.NotificationPreferences, error)
+	// Save creates or replaces the user's preferences.
+	Save(preferences *domain.NotificationPreferences) error
+}
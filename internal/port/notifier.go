@@ -0,0 +1,12 @@
+package port
+
+import (
+	"context"
+
+	"workshop4-backend/internal/domain"
+)
+
+// Notifier delivers notifications over one channel, e.g. email or SMS.
+type Notifier interface {
+	Notify(ctx context.Context, notification domain.Notification) error
+}
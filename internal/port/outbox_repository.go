@@ -0,0 +1,36 @@
+package port
+
+import (
+	"time"
+
+	"workshop4-backend/internal/domain"
+)
+
+// OutboxFilter narrows an outbox query. Zero values are ignored.
+type OutboxFilter struct {
+	Status   domain.OutboxEventStatus
+	Page     int
+	PageSize int
+}
+
+type OutboxRepository interface {
+	Create(event *domain.OutboxEvent) error
+	GetByID(id int) (*domain.OutboxEvent, error)
+	// List returns one page of matching events, newest first, and the total
+	// number of matches.
+	List(filter OutboxFilter) ([]domain.OutboxEvent, int, error)
+	// ClaimDue returns up to limit pending events whose next attempt is due
+	// at asOf, oldest first, and postpones their next attempt to claimUntil
+	// in the same statement, so concurrent dispatchers never claim the same
+	// event.
+	ClaimDue(asOf, claimUntil time.Time, limit int) ([]domain.OutboxEvent, error)
+	MarkPublished(id int, at time.Time) error
+	// MarkFailed counts a failed attempt, records the sinks that accepted
+	// the event so far and schedules the next attempt.
+	MarkFailed(id int, lastError string, nextAttemptAt time.Time, publishedTo []string) error
+	// MarkDead counts the last failed attempt, records the sinks that
+	// accepted the event and stops retrying it.
+	MarkDead(id int, lastError string, publishedTo []string) error
+	// Requeue makes a dead event pending again with no attempts, due at.
+	Requeue(id int, at time.Time) error
+}
@@ -0,0 +1,1104 @@
+// Package porttest is a contract test suite for implementations of the
+// repository ports. Every adapter runs the same suite, so services behave
+// the same whichever storage they are given.
+package porttest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// Backend is one empty store under test.
+type Backend struct {
+	// Repos are used outside a transaction.
+	Repos         port.Repositories
+	Webhooks      port.WebhookRepository
+	Notifications port.NotificationPreferenceRepository
+	Transactor    port.Transactor
+}
+
+// RunRepositoryTests runs the contract against backends made by newBackend,
+// which is called once per test and must return an empty store.
+func RunRepositoryTests(t *testing.T, newBackend func(t *testing.T) Backend) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, b Backend)
+	}{
+		{"Users/CreateAndGet", testUsersCreateAndGet},
+		{"Users/Update", testUsersUpdate},
+		{"Users/Close", testUsersClose},
+		{"Users/Points", testUsersPoints},
+		{"Users/List", testUsersList},
+		{"Users/Search", testUsersSearch},
+		{"Transfers", testTransfers},
+		{"Ledger", testLedger},
+		{"Journal", testJournal},
+		{"Lots", testLots},
+		{"Confirmations", testConfirmations},
+		{"Sequences", testSequences},
+		{"History", testHistory},
+		{"Audit", testAudit},
+		{"Outbox", testOutbox},
+		{"Outbox/ClaimConcurrent", testOutboxClaimConcurrent},
+		{"Webhooks/Subscriptions", testWebhookSubscriptions},
+		{"Webhooks/Deliveries", testWebhookDeliveries},
+		{"NotificationPreferences", testNotificationPreferences},
+		{"Transactions/Commit", testTransactionCommit},
+		{"Transactions/Rollback", testTransactionRollback},
+		{"Transactions/Concurrent", testTransactionsConcurrent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newBackend(t))
+		})
+	}
+}
+
+// t0 is a whole second in UTC, the precision every adapter keeps.
+var t0 = time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+func newUser(n int, name string, points int) *domain.User {
+	return &domain.User{
+		Name:            name,
+		Phone:           fmt.Sprintf("+6681000%04d", n),
+		Email:           fmt.Sprintf("user%d@example.com", n),
+		MemberSince:     "2023-06-15",
+		MembershipLevel: "Bronze",
+		MemberID:        fmt.Sprintf("LBK%06d", n),
+		Points:          points,
+		CreatedAt:       t0,
+		UpdatedAt:       t0,
+	}
+}
+
+func createUsers(t *testing.T, b Backend, users ...*domain.User) {
+	t.Helper()
+	for _, user := range users {
+		require.NoError(t, b.Repos.Users.Create(user))
+	}
+}
+
+// createMembers stores n users for tests whose rows reference users, which
+// stores with foreign keys require.
+func createMembers(t *testing.T, b Backend, n int) []*domain.User {
+	t.Helper()
+	users := make([]*domain.User, n)
+	for i := range users {
+		users[i] = newUser(i+1, fmt.Sprintf("Member %d", i+1), 1000)
+		createUsers(t, b, users[i])
+	}
+	return users
+}
+
+// createTransfer stores a completed transfer for rows that reference one.
+func createTransfer(t *testing.T, b Backend, from, to *domain.User) *domain.Transfer {
+	t.Helper()
+	transfer := &domain.Transfer{
+		FromUserID: from.ID, ToUserID: to.ID, Amount: 300, Status: domain.TransferStatusCompleted,
+		IdempotencyKey: fmt.Sprintf("transfer-%d-%d", from.ID, to.ID), CreatedAt: t0, UpdatedAt: t0,
+	}
+	require.NoError(t, b.Repos.Transfers.Create(transfer))
+	return transfer
+}
+
+func testUsersCreateAndGet(t *testing.T, b Backend) {
+	user := newUser(1, "สมชาย ใจดี", 100)
+	createUsers(t, b, user)
+	assert.NotZero(t, user.ID)
+	assert.Equal(t, 1, user.Version)
+	assert.Equal(t, domain.AccountStatusActive, user.Status)
+
+	got, err := b.Repos.Users.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	got, err = b.Repos.Users.GetByMemberID(user.MemberID)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	got, err = b.Repos.Users.GetByPhone(user.Phone)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	got, err = b.Repos.Users.GetByEmail(user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	got, err = b.Repos.Users.GetByID(user.ID + 1)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	got, err = b.Repos.Users.GetByMemberID("LBK999999")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	// Member IDs are unique
+	assert.Error(t, b.Repos.Users.Create(newUser(1, "ซ้ำ", 0)))
+}
+
+func testUsersUpdate(t *testing.T, b Backend) {
+	user := newUser(1, "Somchai", 100)
+	createUsers(t, b, user)
+
+	stale := *user
+	user.Name = "Somchai Jaidee"
+	user.UpdatedAt = t0.Add(time.Hour)
+	require.NoError(t, b.Repos.Users.Update(user))
+	assert.Equal(t, 2, user.Version)
+
+	got, err := b.Repos.Users.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	stale.Name = "Lost update"
+	assert.ErrorIs(t, b.Repos.Users.Update(&stale), port.ErrVersionConflict)
+	assert.Equal(t, 1, stale.Version)
+
+	missing := newUser(2, "Nobody", 0)
+	missing.ID, missing.Version = user.ID+1, 1
+	assert.ErrorIs(t, b.Repos.Users.Update(missing), port.ErrVersionConflict)
+}
+
+func testUsersClose(t *testing.T, b Backend) {
+	user := newUser(1, "Somchai", 0)
+	other := newUser(2, "Somying", 0)
+	createUsers(t, b, user, other)
+
+	require.NoError(t, b.Repos.Users.UpdateStatus(user.ID, domain.AccountStatusSuspended))
+	got, err := b.Repos.Users.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.AccountStatusSuspended, got.Status)
+	assert.Nil(t, got.DeletedAt)
+	assert.Equal(t, 2, got.Version)
+
+	require.NoError(t, b.Repos.Users.UpdateStatus(user.ID, domain.AccountStatusClosed))
+	got, err = b.Repos.Users.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.AccountStatusClosed, got.Status)
+	assert.NotNil(t, got.DeletedAt)
+	assert.Equal(t, 3, got.Version)
+
+	// Closed accounts keep their member ID but free their contacts
+	got, err = b.Repos.Users.GetByMemberID(user.MemberID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	got, err = b.Repos.Users.GetByPhone(user.Phone)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	got, err = b.Repos.Users.GetByEmail(user.Email)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	all, err := b.Repos.Users.GetAll()
+	require.NoError(t, err)
+	assert.Equal(t, []int{other.ID}, userIDs(all))
+
+	listed, total, err := b.Repos.Users.List(port.UserFilter{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []int{other.ID}, userIDs(listed))
+
+	found, err := b.Repos.Users.Search([]string{"Som"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{other.ID}, userIDs(found))
+
+	reused := newUser(3, "Somchai again", 0)
+	reused.Phone, reused.Email = user.Phone, user.Email
+	createUsers(t, b, reused)
+	got, err = b.Repos.Users.GetByPhone(user.Phone)
+	require.NoError(t, err)
+	assert.Equal(t, reused.ID, got.ID)
+
+	// Open accounts may not share contacts
+	taken := newUser(4, "Somchai twin", 0)
+	taken.Phone = other.Phone
+	assert.ErrorIs(t, b.Repos.Users.Create(taken), port.ErrPhoneTaken)
+	taken = newUser(4, "Somchai twin", 0)
+	taken.Email = reused.Email
+	assert.ErrorIs(t, b.Repos.Users.Create(taken), port.ErrEmailTaken)
+
+	moved := *other
+	moved.Email = reused.Email
+	assert.ErrorIs(t, b.Repos.Users.Update(&moved), port.ErrEmailTaken)
+	got, err = b.Repos.Users.GetByID(other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, other.Email, got.Email)
+}
+
+func testUsersPoints(t *testing.T, b Backend) {
+	user := newUser(1, "Somchai", 100)
+	createUsers(t, b, user)
+
+	balance, err := b.Repos.Users.GetUserBalance(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, balance)
+
+	require.NoError(t, b.Repos.Users.UpdatePoints(user.ID, 250))
+	require.NoError(t, b.Repos.Users.UpdateMembershipLevel(user.ID, "Silver"))
+
+	balance, err = b.Repos.Users.GetUserBalance(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 250, balance)
+
+	got, err := b.Repos.Users.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 250, got.Points)
+	assert.Equal(t, "Silver", got.MembershipLevel)
+	assert.Equal(t, 3, got.Version)
+	assert.True(t, got.UpdatedAt.After(t0))
+
+	_, err = b.Repos.Users.GetUserBalance(user.ID + 1)
+	assert.Error(t, err)
+}
+
+func testUsersList(t *testing.T, b Backend) {
+	somchai := newUser(1, "สมชาย ใจดี", 15000)
+	somchai.MembershipLevel = "Gold"
+	somying := newUser(2, "สมหญิง ดีใจ", 8500)
+	somying.MembershipLevel = "Silver"
+	somying.MemberSince = "2023-07-20"
+	john := newUser(3, "John Smith", 500)
+	john.MemberSince = "2024-01-05"
+	jane := newUser(4, "jane_doe", 500)
+	jane.MemberSince = "2024-02-10"
+	createUsers(t, b, somchai, somying, john, jane)
+
+	date := func(s string) *time.Time {
+		d, err := time.Parse(domain.DateLayout, s)
+		require.NoError(t, err)
+		return &d
+	}
+	points := func(n int) *int { return &n }
+
+	tests := []struct {
+		name   string
+		filter port.UserFilter
+		want   []*domain.User
+	}{
+		{"all in ID order", port.UserFilter{}, []*domain.User{somchai, somying, john, jane}},
+		{"Thai name prefix", port.UserFilter{NamePrefix: "สมช"}, []*domain.User{somchai}},
+		{"name prefix ignores ASCII case", port.UserFilter{NamePrefix: "jo"}, []*domain.User{john}},
+		{"name prefix is literal", port.UserFilter{NamePrefix: "jane_"}, []*domain.User{jane}},
+		{"wildcards match nothing", port.UserFilter{NamePrefix: "%"}, nil},
+		{"level ignores case", port.UserFilter{MembershipLevel: "gold"}, []*domain.User{somchai}},
+		{"member since range", port.UserFilter{MemberSinceFrom: date("2023-07-20"), MemberSinceTo: date("2024-01-05")}, []*domain.User{somying, john}},
+		{"points range", port.UserFilter{MinPoints: points(500), MaxPoints: points(8500)}, []*domain.User{somying, john, jane}},
+		{"by points descending, ties by ID", port.UserFilter{Sort: port.UserSortPoints, Descending: true}, []*domain.User{somchai, somying, jane, john}},
+		{"by name", port.UserFilter{Sort: port.UserSortName}, []*domain.User{john, jane, somchai, somying}},
+		{"by member since descending", port.UserFilter{Sort: port.UserSortMemberSince, Descending: true}, []*domain.User{jane, john, somying, somchai}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Page, tt.filter.PageSize = 1, 10
+			got, total, err := b.Repos.Users.List(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.want), total)
+			assert.Equal(t, userIDs(derefUsers(tt.want)), userIDs(got))
+		})
+	}
+
+	got, total, err := b.Repos.Users.List(port.UserFilter{Page: 2, PageSize: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Equal(t, []int{jane.ID}, userIDs(got))
+
+	got, total, err = b.Repos.Users.List(port.UserFilter{Page: 3, PageSize: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Empty(t, got)
+}
+
+func testUsersSearch(t *testing.T, b Backend) {
+	somchai := newUser(1, "สมชาย ใจดี", 0)
+	somchai.Email = "somchai@example.com"
+	somying := newUser(2, "สมหญิง ดีใจ", 0)
+	john := newUser(3, "John Smith", 0)
+	createUsers(t, b, somchai, somying, john)
+
+	tests := []struct {
+		name  string
+		terms []string
+		want  []int
+	}{
+		{"Thai name", []string{"สมชาย"}, []int{somchai.ID}},
+		{"short term", []string{"ใจ"}, []int{somchai.ID, somying.ID}},
+		{"email ignores case", []string{"SOMCHAI@"}, []int{somchai.ID}},
+		{"phone digits", []string{"810000002"}, []int{somying.ID}},
+		{"member ID", []string{"LBK000003"}, []int{john.ID}},
+		{"every term must match", []string{"สม", "ดีใจ"}, []int{somying.ID}},
+		{"no match", []string{"nobody"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.Repos.Users.Search(tt.terms, 10)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, userIDs(got))
+		})
+	}
+
+	got, err := b.Repos.Users.Search([]string{"example"}, 2)
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+}
+
+func testTransfers(t *testing.T, b Backend) {
+	users := createMembers(t, b, 3)
+	note := "ค่าข้าว"
+	completedAt := t0
+	first := &domain.Transfer{
+		FromUserID: users[0].ID, ToUserID: users[1].ID, Amount: 100, Status: domain.TransferStatusCompleted,
+		Note: &note, IdempotencyKey: "key-1", CreatedAt: t0, UpdatedAt: t0, CompletedAt: &completedAt,
+	}
+	second := &domain.Transfer{
+		FromUserID: users[1].ID, ToUserID: users[0].ID, Amount: 50, Status: domain.TransferStatusPending,
+		IdempotencyKey: "key-2", CreatedAt: t0.Add(time.Minute), UpdatedAt: t0.Add(time.Minute),
+	}
+	third := &domain.Transfer{
+		FromUserID: users[0].ID, ToUserID: users[2].ID, Amount: 10, Status: domain.TransferStatusPending,
+		IdempotencyKey: "key-3", CreatedAt: t0.Add(2 * time.Minute), UpdatedAt: t0.Add(2 * time.Minute),
+	}
+	for _, transfer := range []*domain.Transfer{first, second, third} {
+		require.NoError(t, b.Repos.Transfers.Create(transfer))
+		assert.NotZero(t, transfer.ID)
+	}
+
+	got, err := b.Repos.Transfers.GetByIdempotencyKey("key-1")
+	require.NoError(t, err)
+	assert.Equal(t, first, got)
+
+	got, err = b.Repos.Transfers.GetByIdempotencyKey("missing")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	duplicate := *second
+	assert.Error(t, b.Repos.Transfers.Create(&duplicate))
+
+	// Newest first, sent and received
+	page, total, err := b.Repos.Transfers.GetByUserID(users[0].ID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []int{third.ID, second.ID}, transferIDs(page))
+
+	page, total, err = b.Repos.Transfers.GetByUserID(users[0].ID, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []int{first.ID}, transferIDs(page))
+
+	page, total, err = b.Repos.Transfers.GetByUserID(users[2].ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []int{third.ID}, transferIDs(page))
+
+	reason := "recipient closed"
+	require.NoError(t, b.Repos.Transfers.UpdateStatus(second.ID, domain.TransferStatusFailed, nil, &reason))
+	got, err = b.Repos.Transfers.GetByIdempotencyKey("key-2")
+	require.NoError(t, err)
+	assert.Equal(t, domain.TransferStatusFailed, got.Status)
+	assert.Equal(t, &reason, got.FailReason)
+	assert.Nil(t, got.CompletedAt)
+	assert.True(t, got.UpdatedAt.After(second.UpdatedAt))
+}
+
+func testLedger(t *testing.T, b Backend) {
+	user := newUser(1, "Somchai", 100)
+	idle := newUser(2, "Somying", 40)
+	createUsers(t, b, user, idle)
+
+	transferID := createTransfer(t, b, user, idle).ID
+	entries := []*domain.PointLedger{
+		{UserID: user.ID, Change: 500, BalanceAfter: 600, EventType: domain.EventTypeEarn, CreatedAt: t0},
+		{UserID: user.ID, Change: -200, BalanceAfter: 400, EventType: domain.EventTypeTransferOut, TransferID: &transferID, CreatedAt: t0.Add(time.Hour)},
+		{UserID: user.ID, Change: 50, BalanceAfter: 450, EventType: domain.EventTypeEarn, CreatedAt: t0.Add(48 * time.Hour)},
+	}
+	for _, entry := range entries {
+		require.NoError(t, b.Repos.Ledger.Create(entry))
+		assert.NotZero(t, entry.ID)
+	}
+
+	// Each entry chains to the user's previous one
+	assert.Empty(t, entries[0].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	assert.Equal(t, entries[1].Hash, entries[2].PrevHash)
+	assert.Equal(t, entries[2].ComputeHash(entries[2].PrevHash), entries[2].Hash)
+
+	chain, err := b.Repos.Ledger.GetChain(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PointLedger{*entries[0], *entries[1], *entries[2]}, chain)
+
+	history, err := b.Repos.Ledger.GetByUserID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PointLedger{*entries[2], *entries[1], *entries[0]}, history)
+
+	balance, err := b.Repos.Ledger.GetUserBalance(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 450, balance)
+
+	// Without entries the balance is the user's starting points
+	balance, err = b.Repos.Ledger.GetUserBalance(idle.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 40, balance)
+
+	userIDs, err := b.Repos.Ledger.GetUserIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []int{user.ID}, userIDs)
+
+	earned, err := b.Repos.Ledger.GetEarnedSince(user.ID, t0)
+	require.NoError(t, err)
+	assert.Equal(t, 550, earned)
+	earned, err = b.Repos.Ledger.GetEarnedSince(user.ID, t0.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 50, earned)
+}
+
+func testJournal(t *testing.T, b Backend) {
+	users := createMembers(t, b, 2)
+	transferID := createTransfer(t, b, users[0], users[1]).ID
+	transfer := domain.NewJournalEntry(domain.JournalEntryTransfer, domain.MemberAccount(1), domain.MemberAccount(2), 300, t0)
+	transfer.TransferID = &transferID
+	opening := domain.NewJournalEntry(domain.JournalEntryOpening, domain.AccountIssuance, domain.MemberAccount(1), 1000, t0)
+	for _, entry := range []*domain.JournalEntry{opening, transfer} {
+		require.NoError(t, b.Repos.Journal.Create(entry))
+		assert.NotZero(t, entry.ID)
+		for _, posting := range entry.Postings {
+			assert.NotZero(t, posting.ID)
+			assert.Equal(t, entry.ID, posting.JournalEntryID)
+		}
+	}
+
+	unbalanced := domain.NewJournalEntry(domain.JournalEntryTransfer, domain.MemberAccount(1), domain.MemberAccount(2), 10, t0)
+	unbalanced.Postings[1].Amount = 20
+	assert.Error(t, b.Repos.Journal.Create(unbalanced))
+
+	got, err := b.Repos.Journal.GetByTransferID(transferID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.JournalEntry{*transfer}, got)
+
+	got, err = b.Repos.Journal.GetByTransferID(transferID + 1)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	has, err := b.Repos.Journal.HasPostings(domain.MemberAccount(2))
+	require.NoError(t, err)
+	assert.True(t, has)
+	has, err = b.Repos.Journal.HasPostings(domain.MemberAccount(3))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	balances, err := b.Repos.Journal.GetAccountBalances()
+	require.NoError(t, err)
+	assert.Equal(t, []domain.AccountBalance{
+		{Account: domain.MemberAccount(1), Balance: 700},
+		{Account: domain.MemberAccount(2), Balance: 300},
+		{Account: domain.AccountIssuance, Balance: -1000},
+	}, balances)
+}
+
+func testLots(t *testing.T, b Backend) {
+	users := createMembers(t, b, 2)
+	earn := &domain.PointLedger{UserID: users[0].ID, Change: 50, BalanceAfter: 1050, EventType: domain.EventTypeEarn, CreatedAt: t0}
+	require.NoError(t, b.Repos.Ledger.Create(earn))
+
+	ledgerEntryID := earn.ID
+	later := &domain.PointLot{UserID: users[0].ID, Amount: 100, Remaining: 100, EarnedAt: t0, ExpiresAt: t0.AddDate(2, 0, 0)}
+	sooner := &domain.PointLot{UserID: users[0].ID, LedgerEntryID: &ledgerEntryID, Amount: 50, Remaining: 50, EarnedAt: t0, ExpiresAt: t0.AddDate(1, 0, 0)}
+	other := &domain.PointLot{UserID: users[1].ID, Amount: 70, Remaining: 70, EarnedAt: t0, ExpiresAt: t0.AddDate(1, 0, 0)}
+	for _, lot := range []*domain.PointLot{later, sooner, other} {
+		require.NoError(t, b.Repos.Lots.Create(lot))
+		assert.NotZero(t, lot.ID)
+	}
+
+	open, err := b.Repos.Lots.GetOpenByUserID(users[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PointLot{*sooner, *later}, open)
+
+	expired, err := b.Repos.Lots.GetExpired(t0.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PointLot{*sooner, *other}, expired)
+
+	require.NoError(t, b.Repos.Lots.UpdateRemaining(sooner.ID, 0))
+	require.NoError(t, b.Repos.Lots.UpdateRemaining(later.ID, 60))
+	open, err = b.Repos.Lots.GetOpenByUserID(users[0].ID)
+	require.NoError(t, err)
+	if assert.Len(t, open, 1) {
+		assert.Equal(t, later.ID, open[0].ID)
+		assert.Equal(t, 60, open[0].Remaining)
+	}
+}
+
+func testConfirmations(t *testing.T, b Backend) {
+	users := createMembers(t, b, 2)
+	note := "ค่าข้าว"
+	confirmation := &domain.TransferConfirmation{
+		ID:            "c-1",
+		FromUserID:    users[0].ID,
+		ToUserID:      users[1].ID,
+		Recipient:     domain.RecipientIdentifier{Type: domain.RecipientTypeMemberID, Value: "LBK000002"},
+		RecipientName: "สม*** ดี***",
+		Amount:        300,
+		Note:          &note,
+		CreatedAt:     t0,
+		ExpiresAt:     t0.Add(domain.TransferConfirmationTTL),
+	}
+	require.NoError(t, b.Repos.Confirmations.Create(confirmation))
+	assert.Error(t, b.Repos.Confirmations.Create(confirmation))
+
+	// The identifier the recipient was looked up by is not kept
+	want := *confirmation
+	want.Recipient = domain.RecipientIdentifier{}
+	got, err := b.Repos.Confirmations.GetByID("c-1")
+	require.NoError(t, err)
+	assert.Equal(t, &want, got)
+
+	consumedAt := t0.Add(time.Minute)
+	require.NoError(t, b.Repos.Confirmations.MarkConsumed("c-1", consumedAt))
+	got, err = b.Repos.Confirmations.GetByID("c-1")
+	require.NoError(t, err)
+	assert.Equal(t, &consumedAt, got.ConsumedAt)
+	assert.True(t, got.Expired(t0))
+
+	// A confirmation is consumed once; the second attempt changes nothing
+	err = b.Repos.Confirmations.MarkConsumed("c-1", consumedAt.Add(time.Second))
+	assert.ErrorIs(t, err, port.ErrConfirmationConsumed)
+	got, err = b.Repos.Confirmations.GetByID("c-1")
+	require.NoError(t, err)
+	assert.Equal(t, &consumedAt, got.ConsumedAt)
+	assert.ErrorIs(t, b.Repos.Confirmations.MarkConsumed("missing", consumedAt), port.ErrConfirmationConsumed)
+
+	got, err = b.Repos.Confirmations.GetByID("missing")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func testNotificationPreferences(t *testing.T, b Backend) {
+	users := createMembers(t, b, 2)
+	got, err := b.Notifications.Get(users[0].ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	preferences := &domain.NotificationPreferences{
+		UserID:    users[0].ID,
+		Email:     true,
+		Language:  domain.LanguageEnglish,
+		Muted:     []domain.NotificationKind{domain.NotificationPointsEarned},
+		UpdatedAt: t0,
+	}
+	require.NoError(t, b.Notifications.Save(preferences))
+	got, err = b.Notifications.Get(users[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, preferences, got)
+
+	// Saving again replaces the preferences
+	replaced := &domain.NotificationPreferences{
+		UserID:    users[0].ID,
+		SMS:       true,
+		Language:  domain.LanguageThai,
+		Muted:     []domain.NotificationKind{},
+		UpdatedAt: t0.Add(time.Minute),
+	}
+	require.NoError(t, b.Notifications.Save(replaced))
+	got, err = b.Notifications.Get(users[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, replaced, got)
+
+	got, err = b.Notifications.Get(users[1].ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func testSequences(t *testing.T, b Backend) {
+	for want := 1; want <= 3; want++ {
+		got, err := b.Repos.Sequences.Next("a")
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	got, err := b.Repos.Sequences.Next("b")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+}
+
+func testHistory(t *testing.T, b Backend) {
+	users := createMembers(t, b, 2)
+	for i, level := range []string{"Silver", "Gold"} {
+		require.NoError(t, b.Repos.Tiers.CreateHistory(&domain.TierHistory{
+			UserID: users[0].ID, FromLevel: "Bronze", ToLevel: level, EarnedInWindow: 5000 * (i + 1), Reason: "earn", CreatedAt: t0,
+		}))
+	}
+	tiers, err := b.Repos.Tiers.GetHistoryByUserID(users[0].ID)
+	require.NoError(t, err)
+	if assert.Len(t, tiers, 2) {
+		assert.Equal(t, "Gold", tiers[0].ToLevel)
+		assert.Equal(t, 10000, tiers[0].EarnedInWindow)
+		assert.Equal(t, t0, tiers[0].CreatedAt)
+		assert.Equal(t, "Silver", tiers[1].ToLevel)
+	}
+	tiers, err = b.Repos.Tiers.GetHistoryByUserID(users[1].ID)
+	require.NoError(t, err)
+	assert.NotNil(t, tiers)
+	assert.Empty(t, tiers)
+
+	for _, to := range []domain.AccountStatus{domain.AccountStatusSuspended, domain.AccountStatusActive} {
+		require.NoError(t, b.Repos.StatusHistory.CreateHistory(&domain.AccountStatusChange{
+			UserID: users[0].ID, FromStatus: domain.AccountStatusActive, ToStatus: to, Reason: "review", Actor: "admin", CreatedAt: t0,
+		}))
+	}
+	changes, err := b.Repos.StatusHistory.GetHistoryByUserID(users[0].ID)
+	require.NoError(t, err)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, domain.AccountStatusActive, changes[0].ToStatus)
+		assert.Equal(t, "admin", changes[0].Actor)
+		assert.Equal(t, domain.AccountStatusSuspended, changes[1].ToStatus)
+	}
+	changes, err = b.Repos.StatusHistory.GetHistoryByUserID(users[1].ID)
+	require.NoError(t, err)
+	assert.NotNil(t, changes)
+	assert.Empty(t, changes)
+}
+
+func testAudit(t *testing.T, b Backend) {
+	after := `{"id":1}`
+	entries := []*domain.AuditEntry{
+		{Actor: "admin", Action: domain.AuditActionUserCreate, EntityType: "user", EntityID: "1", After: &after, RequestID: "r-1", IP: "127.0.0.1", CreatedAt: t0},
+		{Actor: "admin", Action: domain.AuditActionUserUpdate, EntityType: "user", EntityID: "1", CreatedAt: t0.Add(time.Hour)},
+		{Actor: "system", Action: domain.AuditActionUserCreate, EntityType: "user", EntityID: "2", CreatedAt: t0.Add(2 * time.Hour)},
+	}
+	for _, entry := range entries {
+		require.NoError(t, b.Repos.Audit.Create(entry))
+		assert.NotZero(t, entry.ID)
+	}
+
+	from, to := t0.Add(time.Hour), t0.Add(2*time.Hour)
+	tests := []struct {
+		name   string
+		filter port.AuditFilter
+		want   []*domain.AuditEntry
+	}{
+		{"newest first", port.AuditFilter{}, []*domain.AuditEntry{entries[2], entries[1], entries[0]}},
+		{"by actor", port.AuditFilter{Actor: "admin"}, []*domain.AuditEntry{entries[1], entries[0]}},
+		{"by action", port.AuditFilter{Action: domain.AuditActionUserCreate}, []*domain.AuditEntry{entries[2], entries[0]}},
+		{"by entity", port.AuditFilter{EntityType: "user", EntityID: "1"}, []*domain.AuditEntry{entries[1], entries[0]}},
+		{"from is inclusive", port.AuditFilter{From: &from}, []*domain.AuditEntry{entries[2], entries[1]}},
+		{"to is inclusive", port.AuditFilter{To: &from}, []*domain.AuditEntry{entries[1], entries[0]}},
+		{"range", port.AuditFilter{From: &from, To: &to, Actor: "system"}, []*domain.AuditEntry{entries[2]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Page, tt.filter.PageSize = 1, 10
+			got, total, err := b.Repos.Audit.List(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.want), total)
+			want := make([]domain.AuditEntry, len(tt.want))
+			for i, entry := range tt.want {
+				want[i] = *entry
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+
+	got, total, err := b.Repos.Audit.List(port.AuditFilter{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, entries[0].ID, got[0].ID)
+	}
+}
+
+func testOutbox(t *testing.T, b Backend) {
+	var events []*domain.OutboxEvent
+	for i := 1; i <= 3; i++ {
+		event, err := domain.NewOutboxEvent(domain.EventPointsEarned, "user", i, domain.PointsChangedEvent{UserID: i, Change: 10 * i}, t0)
+		require.NoError(t, err)
+		require.NoError(t, b.Repos.Outbox.Create(event))
+		assert.NotZero(t, event.ID)
+		events = append(events, event)
+	}
+
+	due, err := b.Repos.Outbox.ClaimDue(t0.Add(-time.Second), t0.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "not due before NextAttemptAt")
+
+	due, err = b.Repos.Outbox.ClaimDue(t0, t0.Add(time.Minute), 2)
+	require.NoError(t, err)
+	if assert.Len(t, due, 2) {
+		claimed := *events[0]
+		claimed.NextAttemptAt = t0.Add(time.Minute)
+		assert.Equal(t, claimed, due[0])
+		assert.Equal(t, events[1].ID, due[1].ID)
+		assert.JSONEq(t, `{"userId":1,"ledgerEntryId":0,"change":10,"balanceAfter":0}`, string(due[0].Payload))
+	}
+	due, err = b.Repos.Outbox.ClaimDue(t0, t0.Add(time.Minute), 10)
+	require.NoError(t, err)
+	if assert.Len(t, due, 1, "claimed events are not due until the claim runs out") {
+		assert.Equal(t, events[2].ID, due[0].ID)
+	}
+
+	require.NoError(t, b.Repos.Outbox.MarkPublished(events[0].ID, t0))
+	require.NoError(t, b.Repos.Outbox.MarkFailed(events[1].ID, "sink unavailable", t0.Add(2*time.Minute), []string{"log"}))
+	due, err = b.Repos.Outbox.ClaimDue(t0.Add(time.Minute), t0.Add(3*time.Minute), 10)
+	require.NoError(t, err)
+	if assert.Len(t, due, 1, "an unsettled claim runs out") {
+		assert.Equal(t, events[2].ID, due[0].ID)
+	}
+
+	require.NoError(t, b.Repos.Outbox.MarkDead(events[2].ID, "gone", []string{"log"}))
+	due, err = b.Repos.Outbox.ClaimDue(t0.Add(5*time.Minute), t0.Add(6*time.Minute), 10)
+	require.NoError(t, err)
+	if assert.Len(t, due, 1) {
+		assert.Equal(t, events[1].ID, due[0].ID)
+		assert.Equal(t, domain.OutboxEventPending, due[0].Status)
+		assert.Equal(t, 1, due[0].Attempts)
+		assert.Equal(t, "sink unavailable", *due[0].LastError)
+		assert.Equal(t, t0.Add(6*time.Minute), due[0].NextAttemptAt)
+		assert.Equal(t, []string{"log"}, due[0].PublishedTo)
+		assert.Nil(t, due[0].PublishedAt)
+	}
+
+	published, err := b.Repos.Outbox.GetByID(events[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OutboxEventPublished, published.Status)
+	assert.Equal(t, t0, *published.PublishedAt)
+	missing, err := b.Repos.Outbox.GetByID(events[2].ID + 100)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	dead, total, err := b.Repos.Outbox.List(port.OutboxFilter{Status: domain.OutboxEventDead, Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, events[2].ID, dead[0].ID)
+		assert.Equal(t, 1, dead[0].Attempts)
+		assert.Equal(t, "gone", *dead[0].LastError)
+		assert.Equal(t, []string{"log"}, dead[0].PublishedTo)
+	}
+	page, total, err := b.Repos.Outbox.List(port.OutboxFilter{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []int{events[2].ID, events[1].ID}, []int{page[0].ID, page[1].ID}, "newest first")
+
+	require.NoError(t, b.Repos.Outbox.Requeue(events[2].ID, t0.Add(10*time.Minute)))
+	requeued, err := b.Repos.Outbox.GetByID(events[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OutboxEventPending, requeued.Status)
+	assert.Zero(t, requeued.Attempts)
+	assert.Equal(t, t0.Add(10*time.Minute), requeued.NextAttemptAt)
+	assert.Equal(t, []string{"log"}, requeued.PublishedTo, "sinks that accepted it are still skipped")
+}
+
+// testOutboxClaimConcurrent claims one outbox from many goroutines; every
+// event must be claimed exactly once.
+func testOutboxClaimConcurrent(t *testing.T, b Backend) {
+	const events, workers = 30, 5
+	for i := 1; i <= events; i++ {
+		event, err := domain.NewOutboxEvent(domain.EventPointsEarned, "user", i, domain.PointsChangedEvent{UserID: i}, t0)
+		require.NoError(t, err)
+		require.NoError(t, b.Repos.Outbox.Create(event))
+	}
+
+	var mu sync.Mutex
+	claims := map[int]int{}
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				due, err := b.Repos.Outbox.ClaimDue(t0, t0.Add(time.Minute), 4)
+				if err != nil || len(due) == 0 {
+					errs <- err
+					return
+				}
+				mu.Lock()
+				for _, event := range due {
+					claims[event.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	assert.Len(t, claims, events)
+	for id, n := range claims {
+		assert.Equal(t, 1, n, "event %d claimed %d times", id, n)
+	}
+}
+
+func createSubscription(t *testing.T, b Backend, url string, eventTypes ...domain.EventName) *domain.WebhookSubscription {
+	t.Helper()
+	subscription := &domain.WebhookSubscription{URL: url, EventTypes: eventTypes, Secret: "0123456789abcdef", CreatedAt: t0}
+	require.NoError(t, b.Webhooks.CreateSubscription(subscription))
+	return subscription
+}
+
+func newDelivery(subscription *domain.WebhookSubscription, eventID int) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        eventID,
+		EventName:      domain.EventPointsEarned,
+		Body:           []byte(fmt.Sprintf(`{"eventId":%d}`, eventID)),
+		Status:         domain.WebhookDeliveryPending,
+		NextAttemptAt:  t0,
+		CreatedAt:      t0,
+	}
+}
+
+func testWebhookSubscriptions(t *testing.T, b Backend) {
+	subscriptions, err := b.Webhooks.ListSubscriptions()
+	require.NoError(t, err)
+	assert.NotNil(t, subscriptions)
+	assert.Empty(t, subscriptions)
+
+	first := createSubscription(t, b, "https://partner.example.com/hooks", domain.EventPointsEarned, domain.EventPointsRedeemed)
+	second := &domain.WebhookSubscription{URL: "https://other.example.com/hooks", EventTypes: []domain.EventName{domain.EventTransferCompleted},
+		UserIDs: []int{3, 5}, Secret: "0123456789abcdef", CreatedAt: t0}
+	require.NoError(t, b.Webhooks.CreateSubscription(second))
+	assert.NotZero(t, first.ID)
+
+	got, err := b.Webhooks.GetSubscription(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first, got)
+
+	subscriptions, err = b.Webhooks.ListSubscriptions()
+	require.NoError(t, err)
+	assert.Equal(t, []domain.WebhookSubscription{*first, *second}, subscriptions)
+
+	require.NoError(t, b.Webhooks.CreateDelivery(newDelivery(first, 1)))
+	require.NoError(t, b.Webhooks.CreateDelivery(newDelivery(second, 1)))
+	require.NoError(t, b.Webhooks.DeleteSubscription(first.ID))
+
+	got, err = b.Webhooks.GetSubscription(first.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	_, total, err := b.Webhooks.ListDeliveries(port.WebhookDeliveryFilter{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "deliveries are deleted with their subscription")
+}
+
+func testWebhookDeliveries(t *testing.T, b Backend) {
+	first := createSubscription(t, b, "https://partner.example.com/hooks", domain.EventPointsEarned)
+	second := createSubscription(t, b, "https://other.example.com/hooks", domain.EventPointsEarned)
+
+	var deliveries []*domain.WebhookDelivery
+	for _, delivery := range []*domain.WebhookDelivery{newDelivery(first, 1), newDelivery(second, 1), newDelivery(first, 2)} {
+		require.NoError(t, b.Webhooks.CreateDelivery(delivery))
+		assert.NotZero(t, delivery.ID)
+		deliveries = append(deliveries, delivery)
+	}
+
+	duplicate := newDelivery(first, 1)
+	require.NoError(t, b.Webhooks.CreateDelivery(duplicate))
+	assert.Zero(t, duplicate.ID, "one delivery per event and subscription")
+
+	got, err := b.Webhooks.GetDelivery(deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, deliveries[0], got)
+	got, err = b.Webhooks.GetDelivery(999)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	due, err := b.Webhooks.GetDueDeliveries(t0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.WebhookDelivery{*deliveries[0], *deliveries[1]}, due)
+
+	// Record a delivered, a retrying and a dead delivery
+	ok, unavailable := 200, 503
+	lastError := "receiver responded with status 503"
+	deliveredAt := t0.Add(time.Second)
+	deliveries[0].Status, deliveries[0].Attempts, deliveries[0].ResponseStatus, deliveries[0].DeliveredAt = domain.WebhookDeliveryDelivered, 1, &ok, &deliveredAt
+	deliveries[1].Attempts, deliveries[1].ResponseStatus, deliveries[1].LastError, deliveries[1].NextAttemptAt = 1, &unavailable, &lastError, t0.Add(time.Minute)
+	deliveries[2].Status, deliveries[2].Attempts, deliveries[2].LastError = domain.WebhookDeliveryDead, 5, &lastError
+	for _, delivery := range deliveries {
+		require.NoError(t, b.Webhooks.UpdateDelivery(delivery))
+	}
+
+	due, err = b.Webhooks.GetDueDeliveries(t0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	due, err = b.Webhooks.GetDueDeliveries(t0.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.WebhookDelivery{*deliveries[1]}, due)
+
+	got, err = b.Webhooks.GetDelivery(deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, deliveries[0], got)
+
+	tests := []struct {
+		name   string
+		filter port.WebhookDeliveryFilter
+		want   []*domain.WebhookDelivery
+	}{
+		{"newest first", port.WebhookDeliveryFilter{}, []*domain.WebhookDelivery{deliveries[2], deliveries[1], deliveries[0]}},
+		{"by subscription", port.WebhookDeliveryFilter{SubscriptionID: first.ID}, []*domain.WebhookDelivery{deliveries[2], deliveries[0]}},
+		{"by status", port.WebhookDeliveryFilter{Status: domain.WebhookDeliveryDead}, []*domain.WebhookDelivery{deliveries[2]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Page, tt.filter.PageSize = 1, 10
+			got, total, err := b.Webhooks.ListDeliveries(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.want), total)
+			want := make([]domain.WebhookDelivery, len(tt.want))
+			for i, delivery := range tt.want {
+				want[i] = *delivery
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+
+	page, total, err := b.Webhooks.ListDeliveries(port.WebhookDeliveryFilter{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, deliveries[0].ID, page[0].ID)
+	}
+}
+
+func testTransactionCommit(t *testing.T, b Backend) {
+	user := newUser(1, "Somchai", 100)
+	err := b.Transactor.WithinTransaction(func(repos port.Repositories) error {
+		if err := repos.Users.Create(user); err != nil {
+			return err
+		}
+		// Writes are visible inside the transaction
+		got, err := repos.Users.GetByID(user.ID)
+		if err != nil {
+			return err
+		}
+		if got == nil {
+			return errors.New("user not visible inside its transaction")
+		}
+		return repos.Users.UpdatePoints(user.ID, 300)
+	})
+	require.NoError(t, err)
+
+	balance, err := b.Repos.Users.GetUserBalance(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 300, balance)
+}
+
+func testTransactionRollback(t *testing.T, b Backend) {
+	user := newUser(1, "Somchai", 100)
+	createUsers(t, b, user)
+
+	errAbort := errors.New("abort")
+	err := b.Transactor.WithinTransaction(func(repos port.Repositories) error {
+		if _, err := repos.Sequences.Next("member_id"); err != nil {
+			return err
+		}
+		if err := repos.Users.Create(newUser(2, "Somying", 0)); err != nil {
+			return err
+		}
+		if err := repos.Users.UpdatePoints(user.ID, 0); err != nil {
+			return err
+		}
+		if err := repos.Ledger.Create(&domain.PointLedger{UserID: user.ID, Change: -100, EventType: domain.EventTypeRedeem, CreatedAt: t0}); err != nil {
+			return err
+		}
+		event, err := domain.NewOutboxEvent(domain.EventPointsRedeemed, "user", user.ID, domain.PointsChangedEvent{UserID: user.ID, Change: -100}, t0)
+		if err != nil {
+			return err
+		}
+		if err := repos.Outbox.Create(event); err != nil {
+			return err
+		}
+		if err := repos.Audit.Create(&domain.AuditEntry{Actor: "admin", Action: domain.AuditActionPointsRedeem, EntityType: "user", EntityID: "1", CreatedAt: t0}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	got, err := b.Repos.Users.GetByMemberID(newUser(2, "", 0).MemberID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	balance, err := b.Repos.Users.GetUserBalance(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, balance)
+
+	chain, err := b.Repos.Ledger.GetChain(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, chain)
+
+	_, total, err := b.Repos.Outbox.List(port.OutboxFilter{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	_, total, err = b.Repos.Audit.List(port.AuditFilter{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	next, err := b.Repos.Sequences.Next("member_id")
+	require.NoError(t, err)
+	assert.Equal(t, 1, next)
+}
+
+// testTransactionsConcurrent increments one counter from many goroutines;
+// transactions must not lose updates.
+func testTransactionsConcurrent(t *testing.T, b Backend) {
+	user := newUser(1, "Somchai", 0)
+	createUsers(t, b, user)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- b.Transactor.WithinTransaction(func(repos port.Repositories) error {
+				balance, err := repos.Users.GetUserBalance(user.ID)
+				if err != nil {
+					return err
+				}
+				return repos.Users.UpdatePoints(user.ID, balance+1)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	balance, err := b.Repos.Users.GetUserBalance(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, workers, balance)
+}
+
+func userIDs(users []domain.User) []int {
+	var ids []int
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+func derefUsers(users []*domain.User) []domain.User {
+	var values []domain.User
+	for _, user := range users {
+		values = append(values, *user)
+	}
+	return values
+}
+
+func transferIDs(transfers []domain.Transfer) []int {
+	var ids []int
+	for _, transfer := range transfers {
+		ids = append(ids, transfer.ID)
+	}
+	return ids
+}
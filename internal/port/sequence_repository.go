@@ -0,0 +1,7 @@
+package port
+
+// SequenceRepository hands out monotonically increasing numbers per named
+// sequence.
+type SequenceRepository interface {
+	Next(name string) (int, error)
+}
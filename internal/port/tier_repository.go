@@ -0,0 +1,8 @@
+package port
+
+import "workshop4-backend/internal/domain"
+
+type TierRepository interface {
+	CreateHistory(history *domain.TierHistory) error
+	GetHistoryByUserID(userID int) ([]domain.TierHistory, error)
+}
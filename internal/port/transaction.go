@@ -0,0 +1,23 @@
+package port
+
+// Repositories groups the repositories that take part in a transaction.
+type Repositories struct {
+	Users         UserRepositoryWithBalance
+	Transfers     TransferRepository
+	Ledger        PointLedgerRepository
+	Journal       JournalRepository
+	Lots          LotRepository
+	Tiers         TierRepository
+	Sequences     SequenceRepository
+	Confirmations TransferConfirmationRepository
+	StatusHistory AccountStatusRepository
+	Outbox        OutboxRepository
+	Audit         AuditRepository
+}
+
+// Transactor runs fn atomically. The repositories passed to fn share one
+// transaction, which is committed when fn returns nil and rolled back
+// otherwise.
+type Transactor interface {
+	WithinTransaction(fn func(repos Repositories) error) error
+}
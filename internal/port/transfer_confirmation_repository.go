@@ -0,0 +1,20 @@
+package port
+
+import (
+	"errors"
+	"time"
+
+	"workshop4-backend/internal/domain"
+)
+
+// ErrConfirmationConsumed is returned by MarkConsumed when the confirmation
+// was already used, or does not exist.
+var ErrConfirmationConsumed = errors.New("confirmation already consumed")
+
+type TransferConfirmationRepository interface {
+	Create(confirmation *domain.TransferConfirmation) error
+	GetByID(id string) (*domain.TransferConfirmation, error)
+	// MarkConsumed sets consumed_at only if it is still unset, so two
+	// concurrent confirms cannot both post the transfer.
+	MarkConsumed(id string, at time.Time) error
+}
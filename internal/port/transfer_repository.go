@@ -1,23 +1,32 @@
 package port
 
-import "workshop4-backend/internal/domain"
+import (
+	"time"
+
+	"workshop4-backend/internal/domain"
+)
 
 type TransferRepository interface {
 	Create(transfer *domain.Transfer) error
 	GetByIdempotencyKey(key string) (*domain.Transfer, error)
 	GetByUserID(userID int, page, pageSize int) ([]domain.Transfer, int, error)
-	UpdateStatus(id int, status domain.TransferStatus, completedAt *string, failReason *string) error
+	UpdateStatus(id int, status domain.TransferStatus, completedAt *time.Time, failReason *string) error
 }
 
 type PointLedgerRepository interface {
 	Create(entry *domain.PointLedger) error
 	GetByUserID(userID int) ([]domain.PointLedger, error)
+	GetChain(userID int) ([]domain.PointLedger, error)
+	GetUserIDs() ([]int, error)
 	GetUserBalance(userID int) (int, error)
+	// GetEarnedSince sums the user's earn entries created at or after since.
+	GetEarnedSince(userID int, since time.Time) (int, error)
 }
 
 // Additional methods for UserRepository to support transfers
 type UserRepositoryWithBalance interface {
 	UserRepository
 	UpdatePoints(userID int, newBalance int) error
+	UpdateMembershipLevel(userID int, level string) error
 	GetUserBalance(userID int) (int, error)
 }
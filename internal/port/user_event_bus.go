@@ -0,0 +1,28 @@
+package port
+
+import "workshop4-backend/internal/domain"
+
+// UserEventSubscription is an open subscription to one user's events.
+type UserEventSubscription struct {
+	// Replay holds the buffered events after the last event ID given to
+	// Subscribe, oldest first.
+	Replay []domain.UserEvent
+	// Missed reports that events after that ID are no longer buffered, or
+	// that the ID is unknown, so Replay alone cannot bring the client up
+	// to date.
+	Missed bool
+	// Events delivers events published after Subscribe. It is closed when
+	// the subscriber falls too far behind or Close is called.
+	Events <-chan domain.UserEvent
+	Close  func()
+}
+
+// UserEventBus fans events out to the open streams of the user they concern.
+type UserEventBus interface {
+	// Publish assigns the event its ID and delivers it to the user's
+	// subscribers without blocking.
+	Publish(event domain.UserEvent)
+	// Subscribe opens a subscription to the user's events. lastEventID is
+	// the ID of the last event the client saw, or empty for a new stream.
+	Subscribe(userID int, lastEventID string) *UserEventSubscription
+}
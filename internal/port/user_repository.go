@@ -1,11 +1,71 @@
 package port
 
-import "workshop4-backend/internal/domain"
+import (
+	"errors"
+	"time"
+
+	"workshop4-backend/internal/domain"
+)
+
+// ErrVersionConflict is returned by UserRepository.Update when the stored
+// version no longer matches the user's.
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrPhoneTaken and ErrEmailTaken are returned by UserRepository.Create and
+// Update when another open account already holds the phone or email.
+var (
+	ErrPhoneTaken = errors.New("phone already registered")
+	ErrEmailTaken = errors.New("email already registered")
+)
+
+// UserSort is the field a user listing is ordered by.
+type UserSort string
+
+const (
+	UserSortID          UserSort = "id"
+	UserSortName        UserSort = "name"
+	UserSortPoints      UserSort = "points"
+	UserSortMemberSince UserSort = "memberSince"
+	UserSortCreatedAt   UserSort = "createdAt"
+)
+
+// UserFilter narrows a user listing. Zero values are ignored; closed
+// accounts are never listed.
+type UserFilter struct {
+	// NamePrefix matches the start of the name, e.g. "สมช" or "Som".
+	NamePrefix      string
+	MembershipLevel string
+	MemberSinceFrom *time.Time
+	MemberSinceTo   *time.Time
+	MinPoints       *int
+	MaxPoints       *int
+	Sort            UserSort
+	Descending      bool
+	Page            int
+	PageSize        int
+}
 
 type UserRepository interface {
+	// GetAll lists users whose accounts are not closed.
 	GetAll() ([]domain.User, error)
+	// List returns one page of the users matching filter and the total
+	// number of matches.
+	List(filter UserFilter) ([]domain.User, int, error)
+	// Search returns up to limit open accounts whose name, email, phone or
+	// member ID contains every term, best matches first.
+	Search(terms []string, limit int) ([]domain.User, error)
 	GetByID(id int) (*domain.User, error)
+	GetByMemberID(memberID string) (*domain.User, error)
+	// GetByPhone and GetByEmail expect values normalized by
+	// domain.NormalizePhone and domain.NormalizeEmail. Closed accounts are
+	// ignored so their contacts can be registered again.
+	GetByPhone(phone string) (*domain.User, error)
+	GetByEmail(email string) (*domain.User, error)
 	Create(user *domain.User) error
+	// Update stores user only if the stored version still equals
+	// user.Version, and increments user.Version on success.
 	Update(user *domain.User) error
-	Delete(id int) error
+	// UpdateStatus changes the account status. Closing an account sets
+	// deleted_at; users are never deleted.
+	UpdateStatus(id int, status domain.AccountStatus) error
 }
@@ -0,0 +1,40 @@
+package port
+
+import (
+	"time"
+
+	"workshop4-backend/internal/domain"
+)
+
+// WebhookDeliveryFilter narrows a delivery log query. Zero values are
+// ignored.
+type WebhookDeliveryFilter struct {
+	SubscriptionID int
+	Status         domain.WebhookDeliveryStatus
+	Page           int
+	PageSize       int
+}
+
+type WebhookRepository interface {
+	CreateSubscription(subscription *domain.WebhookSubscription) error
+	GetSubscription(id int) (*domain.WebhookSubscription, error)
+	// ListSubscriptions returns every subscription, oldest first.
+	ListSubscriptions() ([]domain.WebhookSubscription, error)
+	// DeleteSubscription removes the subscription and its deliveries.
+	DeleteSubscription(id int) error
+
+	// CreateDelivery stores a delivery and sets its ID. A delivery of the
+	// same event to the same subscription already stored is kept instead,
+	// and delivery.ID is left zero.
+	CreateDelivery(delivery *domain.WebhookDelivery) error
+	GetDelivery(id int) (*domain.WebhookDelivery, error)
+	// GetDueDeliveries returns up to limit pending deliveries due by asOf,
+	// oldest first.
+	GetDueDeliveries(asOf time.Time, limit int) ([]domain.WebhookDelivery, error)
+	// UpdateDelivery saves the status, attempt count, outcome and schedule
+	// of the delivery.
+	UpdateDelivery(delivery *domain.WebhookDelivery) error
+	// ListDeliveries returns one page of matching deliveries, newest first,
+	// and the total number of matches.
+	ListDeliveries(filter WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error)
+}
@@ -0,0 +1,9 @@
+package port
+
+import "context"
+
+// WebhookSender POSTs a webhook body to url with the given headers and
+// returns the response status. An error means no response was received.
+type WebhookSender interface {
+	Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error)
+}
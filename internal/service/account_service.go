@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// AccountManager lets fraud ops freeze and reinstate member accounts.
+type AccountManager interface {
+	SuspendUser(ctx context.Context, id int, reason string) (*domain.User, error)
+	UnsuspendUser(ctx context.Context, id int, reason string) (*domain.User, error)
+	GetStatusHistory(id int) ([]domain.AccountStatusChange, error)
+}
+
+// AccountService changes account status and records every change in the
+// account status history. A suspended account can neither send nor receive
+// points until it is unsuspended.
+type AccountService struct {
+	userRepo    port.UserRepository
+	historyRepo port.AccountStatusRepository
+	tx          port.Transactor
+	clock       port.Clock
+}
+
+func NewAccountService(userRepo port.UserRepository, historyRepo port.AccountStatusRepository, tx port.Transactor, clock port.Clock) *AccountService {
+	return &AccountService{userRepo: userRepo, historyRepo: historyRepo, tx: tx, clock: clock}
+}
+
+// SuspendUser freezes an active account pending review.
+func (s *AccountService) SuspendUser(ctx context.Context, id int, reason string) (*domain.User, error) {
+	return s.transition(ctx, id, domain.AccountStatusActive, domain.AccountStatusSuspended, reason, domain.AuditActionUserSuspend)
+}
+
+// UnsuspendUser lifts the freeze on a suspended account.
+func (s *AccountService) UnsuspendUser(ctx context.Context, id int, reason string) (*domain.User, error) {
+	return s.transition(ctx, id, domain.AccountStatusSuspended, domain.AccountStatusActive, reason, domain.AuditActionUserUnsuspend)
+}
+
+// GetStatusHistory returns the user's status changes, newest first.
+func (s *AccountService) GetStatusHistory(id int) ([]domain.AccountStatusChange, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.historyRepo.GetHistoryByUserID(id)
+}
+
+// transition moves the account from status from to status to and audits it
+// as action.
+func (s *AccountService) transition(ctx context.Context, id int, from, to domain.AccountStatus, reason string, action domain.AuditAction) (*domain.User, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		verr := &domain.ValidationError{}
+		verr.Add("reason", domain.FieldCodeRequired, "is required")
+		return nil, verr
+	}
+
+	var updated *domain.User
+	err := s.tx.WithinTransaction(func(repos port.Repositories) error {
+		user, err := getForWrite(repos, id, 0)
+		if err != nil {
+			return err
+		}
+		switch user.Status {
+		case to:
+			return ErrStatusUnchanged
+		case from:
+		default:
+			return ErrAccountClosed
+		}
+
+		now := s.clock.Now()
+		if err := changeStatus(ctx, repos, user, to, reason, now); err != nil {
+			return err
+		}
+		if updated, err = repos.Users.GetByID(id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos, action, domain.AuditEntityUser, strconv.Itoa(id), user, updated, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// changeStatus sets the user's status and records the change at now, with
+// the caller from ctx as its actor.
+func changeStatus(ctx context.Context, repos port.Repositories, user *domain.User, to domain.AccountStatus, reason string, now time.Time) error {
+	if err := repos.Users.UpdateStatus(user.ID, to); err != nil {
+		return fmt.Errorf("failed to update account status: %w", err)
+	}
+	change := &domain.AccountStatusChange{
+		UserID:     user.ID,
+		FromStatus: user.Status,
+		ToStatus:   to,
+		Reason:     reason,
+		Actor:      domain.RequestMetaFromContext(ctx).Actor,
+		CreatedAt:  now,
+	}
+	if err := repos.StatusHistory.CreateHistory(change); err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAccountStatusRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccountStatusRepository) CreateHistory(change *domain.AccountStatusChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockAccountStatusRepository) GetHistoryByUserID(userID int) ([]domain.AccountStatusChange, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]domain.AccountStatusChange), args.Error(1)
+}
+
+func newAccountServiceWithMocks() (*AccountService, *MockUserRepository, *MockAccountStatusRepository) {
+	userRepo := new(MockUserRepository)
+	historyRepo := new(MockAccountStatusRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: userRepo, StatusHistory: historyRepo, Audit: newTestAuditRepository()}}
+	return NewAccountService(userRepo, historyRepo, tx, testutil.NewClock(testNow)), userRepo, historyRepo
+}
+
+func TestAccountService_SuspendUser(t *testing.T) {
+	service, userRepo, historyRepo := newAccountServiceWithMocks()
+	ctx := domain.WithRequestMeta(context.Background(), domain.RequestMeta{Actor: "fraud-ops"})
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusActive}, nil).Once()
+	userRepo.On("UpdateStatus", 1, domain.AccountStatusSuspended).Return(nil)
+	historyRepo.On("CreateHistory", mock.MatchedBy(func(c *domain.AccountStatusChange) bool {
+		return c.FromStatus == domain.AccountStatusActive &&
+			c.ToStatus == domain.AccountStatusSuspended &&
+			c.Reason == "chargeback review" &&
+			c.Actor == "fraud-ops"
+	})).Return(nil)
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusSuspended}, nil)
+
+	user, err := service.SuspendUser(ctx, 1, "  chargeback review ")
+	assert.NoError(t, err)
+	assert.True(t, user.Suspended())
+	userRepo.AssertExpectations(t)
+	historyRepo.AssertExpectations(t)
+}
+
+func TestAccountService_UnsuspendUser(t *testing.T) {
+	service, userRepo, historyRepo := newAccountServiceWithMocks()
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusSuspended}, nil).Once()
+	userRepo.On("UpdateStatus", 1, domain.AccountStatusActive).Return(nil)
+	historyRepo.On("CreateHistory", mock.MatchedBy(func(c *domain.AccountStatusChange) bool {
+		return c.FromStatus == domain.AccountStatusSuspended && c.ToStatus == domain.AccountStatusActive
+	})).Return(nil)
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusActive}, nil)
+
+	user, err := service.UnsuspendUser(context.Background(), 1, "cleared")
+	assert.NoError(t, err)
+	assert.False(t, user.Suspended())
+	historyRepo.AssertExpectations(t)
+}
+
+func TestAccountService_Transition_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    *domain.User
+		suspend bool
+		reason  string
+		wantErr error
+	}{
+		{"already suspended", &domain.User{ID: 1, Status: domain.AccountStatusSuspended}, true, "review", ErrStatusUnchanged},
+		{"not suspended", &domain.User{ID: 1, Status: domain.AccountStatusActive}, false, "review", ErrStatusUnchanged},
+		{"closed", &domain.User{ID: 1, Status: domain.AccountStatusClosed}, true, "review", ErrAccountClosed},
+		{"not found", nil, true, "review", ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, userRepo, historyRepo := newAccountServiceWithMocks()
+			userRepo.On("GetByID", 1).Return(tt.user, nil)
+
+			var err error
+			if tt.suspend {
+				_, err = service.SuspendUser(context.Background(), 1, tt.reason)
+			} else {
+				_, err = service.UnsuspendUser(context.Background(), 1, tt.reason)
+			}
+			assert.Equal(t, tt.wantErr, err)
+			userRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+			historyRepo.AssertNotCalled(t, "CreateHistory", mock.Anything)
+		})
+	}
+}
+
+func TestAccountService_SuspendUser_ReasonRequired(t *testing.T) {
+	service, userRepo, _ := newAccountServiceWithMocks()
+
+	_, err := service.SuspendUser(context.Background(), 1, "   ")
+	assert.Equal(t, KindValidation, KindOf(err))
+	userRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// AuditService serves the audit log. Entries are written by the services
+// that change state, with recordAudit, in the same transaction as the change.
+type AuditService struct {
+	repo port.AuditRepository
+}
+
+func NewAuditService(repo port.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+func (s *AuditService) ListEntries(filter port.AuditFilter) ([]domain.AuditEntry, int, error) {
+	// Validate pagination
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 || filter.PageSize > 200 {
+		filter.PageSize = 20
+	}
+
+	entries, total, err := s.repo.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, total, nil
+}
+
+// recordAudit writes an audit entry for action on the given entity within
+// repos' transaction, so it is kept exactly when the change it describes
+// commits. before and after are snapshots of the entity and may be nil for
+// creates and deletes; the actor, request ID and IP come from ctx.
+func recordAudit(ctx context.Context, repos port.Repositories, action domain.AuditAction, entityType, entityID string, before, after interface{}, at time.Time) error {
+	meta := domain.RequestMetaFromContext(ctx)
+
+	entry := &domain.AuditEntry{
+		Actor:      meta.Actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		RequestID:  meta.RequestID,
+		IP:         meta.IP,
+		CreatedAt:  at,
+	}
+
+	var err error
+	if entry.Before, err = marshalSnapshot(before); err != nil {
+		return fmt.Errorf("failed to encode before snapshot: %w", err)
+	}
+	if entry.After, err = marshalSnapshot(after); err != nil {
+		return fmt.Errorf("failed to encode after snapshot: %w", err)
+	}
+	if entry.Before != nil && entry.After != nil {
+		if entry.Diff, err = diffSnapshots(*entry.Before, *entry.After); err != nil {
+			return fmt.Errorf("failed to compute diff: %w", err)
+		}
+	}
+
+	if err := repos.Audit.Create(entry); err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+	return nil
+}
+
+func marshalSnapshot(v interface{}) (*string, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := string(data)
+	return &s, nil
+}
+
+// diffSnapshots returns a JSON object mapping each top-level field that
+// differs between before and after to its {"from", "to"} values.
+func diffSnapshots(before, after string) (*string, error) {
+	var b, a map[string]interface{}
+	if err := json.Unmarshal([]byte(before), &b); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(after), &a); err != nil {
+		return nil, err
+	}
+
+	diff := map[string]map[string]interface{}{}
+	for key, from := range b {
+		if to, ok := a[key]; !ok || !reflect.DeepEqual(from, to) {
+			diff[key] = map[string]interface{}{"from": from, "to": a[key]}
+		}
+	}
+	for key, to := range a {
+		if _, ok := b[key]; !ok {
+			diff[key] = map[string]interface{}{"from": nil, "to": to}
+		}
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return nil, err
+	}
+	s := string(data)
+	return &s, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Create(entry *domain.AuditEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) List(filter port.AuditFilter) ([]domain.AuditEntry, int, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]domain.AuditEntry), args.Get(1).(int), args.Error(2)
+}
+
+// newTestAuditRepository accepts any audit entry, for tests that do not
+// inspect the audit log.
+func newTestAuditRepository() *MockAuditRepository {
+	auditRepo := new(MockAuditRepository)
+	auditRepo.On("Create", mock.Anything).Return(nil).Maybe()
+	return auditRepo
+}
+
+// newAuditedUserService returns a UserService whose transactions write the
+// audit log to auditRepo.
+func newAuditedUserService(repo *MockUserRepository, ledgerRepo *MockPointLedgerRepository, auditRepo *MockAuditRepository) *UserService {
+	repo.On("GetByPhone", mock.Anything).Return(nil, nil).Maybe()
+	repo.On("GetByEmail", mock.Anything).Return(nil, nil).Maybe()
+	outboxRepo := new(MockOutboxRepository)
+	outboxRepo.On("Create", mock.Anything).Return(nil).Maybe()
+	historyRepo := new(MockAccountStatusRepository)
+	historyRepo.On("CreateHistory", mock.Anything).Return(nil).Maybe()
+	tx := &stubTransactor{repos: port.Repositories{
+		Users: repo, Ledger: ledgerRepo, Sequences: &stubSequence{}, Outbox: outboxRepo, StatusHistory: historyRepo, Audit: auditRepo,
+	}}
+	return NewUserService(repo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, testutil.NewClock(testNow))
+}
+
+func TestUserService_CreateUser_RecordsAuditEntry(t *testing.T) {
+	repo := new(MockUserRepository)
+	auditRepo := new(MockAuditRepository)
+	svc := newAuditedUserService(repo, new(MockPointLedgerRepository), auditRepo)
+
+	user := &domain.User{Name: "Test User", Email: "test@example.com", Phone: "081-234-5678"}
+	repo.On("Create", user).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.User).ID = 7
+	})
+	auditRepo.On("Create", mock.AnythingOfType("*domain.AuditEntry")).Return(nil)
+
+	ctx := domain.WithRequestMeta(context.Background(), domain.RequestMeta{
+		Actor:     "agent-1",
+		RequestID: "req-1",
+		IP:        "10.0.0.1",
+	})
+	err := svc.CreateUser(ctx, user)
+	assert.NoError(t, err)
+
+	entry := auditRepo.Calls[0].Arguments.Get(0).(*domain.AuditEntry)
+	assert.Equal(t, "agent-1", entry.Actor)
+	assert.Equal(t, domain.AuditActionUserCreate, entry.Action)
+	assert.Equal(t, domain.AuditEntityUser, entry.EntityType)
+	assert.Equal(t, "7", entry.EntityID)
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.Equal(t, "10.0.0.1", entry.IP)
+	assert.Equal(t, testNow, entry.CreatedAt)
+	assert.Nil(t, entry.Before)
+	assert.NotNil(t, entry.After)
+	assert.Nil(t, entry.Diff)
+}
+
+func TestUserService_UpdateUser_RecordsAuditDiff(t *testing.T) {
+	repo := new(MockUserRepository)
+	auditRepo := new(MockAuditRepository)
+	svc := newAuditedUserService(repo, new(MockPointLedgerRepository), auditRepo)
+
+	before := &domain.User{ID: 1, Name: "Old Name", Email: "test@example.com", Phone: "+66812345678"}
+	after := &domain.User{ID: 1, Name: "New Name", Email: "test@example.com", Phone: "081-234-5678"}
+	repo.On("GetByID", 1).Return(before, nil)
+	repo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+	auditRepo.On("Create", mock.AnythingOfType("*domain.AuditEntry")).Return(nil)
+
+	err := svc.UpdateUser(context.Background(), after)
+	assert.NoError(t, err)
+
+	entry := auditRepo.Calls[0].Arguments.Get(0).(*domain.AuditEntry)
+	assert.Equal(t, "system", entry.Actor)
+	assert.Equal(t, domain.AuditActionUserUpdate, entry.Action)
+	if assert.NotNil(t, entry.Diff) {
+		var diff map[string]map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(*entry.Diff), &diff))
+		assert.NotContains(t, diff, "phone")
+		assert.Equal(t, "Old Name", diff["name"]["from"])
+		assert.Equal(t, "New Name", diff["name"]["to"])
+	}
+}
+
+func TestUserService_CloseUser_Failure_NotAudited(t *testing.T) {
+	repo := new(MockUserRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	auditRepo := new(MockAuditRepository)
+	svc := newAuditedUserService(repo, ledgerRepo, auditRepo)
+
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(0, nil)
+	repo.On("UpdateStatus", 1, domain.AccountStatusClosed).Return(errors.New("db error"))
+
+	_, err := svc.CloseUser(context.Background(), 1, 0, false)
+	assert.Error(t, err)
+	auditRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUserService_CloseUser_AuditFailure_FailsClose(t *testing.T) {
+	repo := new(MockUserRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	auditRepo := new(MockAuditRepository)
+	svc := newAuditedUserService(repo, ledgerRepo, auditRepo)
+
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(0, nil)
+	repo.On("UpdateStatus", 1, domain.AccountStatusClosed).Return(nil)
+	auditRepo.On("Create", mock.AnythingOfType("*domain.AuditEntry")).Return(errors.New("db error"))
+
+	// The transaction is rolled back, so the account stays open
+	user, err := svc.CloseUser(context.Background(), 1, 0, false)
+	assert.Error(t, err)
+	assert.Nil(t, user)
+}
+
+func TestAuditService_ListEntries_DefaultsPagination(t *testing.T) {
+	auditRepo := new(MockAuditRepository)
+	svc := NewAuditService(auditRepo)
+
+	auditRepo.On("List", port.AuditFilter{Actor: "agent-1", Page: 1, PageSize: 20}).
+		Return([]domain.AuditEntry{{ID: 1}}, 1, nil)
+
+	entries, total, err := svc.ListEntries(port.AuditFilter{Actor: "agent-1", PageSize: 500})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 1, total)
+	auditRepo.AssertExpectations(t)
+}
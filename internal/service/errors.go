@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+
+	"workshop4-backend/internal/domain"
+)
+
+// ErrorKind classifies service errors so callers such as the HTTP handlers
+// can react to a whole class of errors without knowing every sentinel.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnprocessable
+	KindGone
+	KindPreconditionFailed
+	KindForbidden
+)
+
+// Error is a service error with a kind and a stable, machine-readable code.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// invalidField returns a validation error for a single field.
+func invalidField(field, message string) *domain.ValidationError {
+	verr := &domain.ValidationError{}
+	verr.Add(field, domain.FieldCodeInvalid, message)
+	return verr
+}
+
+var (
+	ErrInsufficientBalance  = newError(KindConflict, "INSUFFICIENT_BALANCE", "insufficient balance")
+	ErrSelfTransfer         = newError(KindUnprocessable, "SELF_TRANSFER", "cannot transfer to yourself")
+	ErrInvalidAmount        = invalidField("amount", "must be greater than 0")
+	ErrUserNotFound         = newError(KindNotFound, "USER_NOT_FOUND", "user not found")
+	ErrTransferNotFound     = newError(KindNotFound, "TRANSFER_NOT_FOUND", "transfer not found")
+	ErrInvalidMemberID      = invalidField("memberId", "has an invalid check digit")
+	ErrRecipientNotFound    = newError(KindNotFound, "RECIPIENT_NOT_FOUND", "no member matches the recipient")
+	ErrConfirmationNotFound = newError(KindNotFound, "CONFIRMATION_NOT_FOUND", "transfer confirmation not found")
+	ErrConfirmationExpired  = newError(KindGone, "CONFIRMATION_EXPIRED", "transfer confirmation expired or already used")
+	ErrVersionMismatch      = newError(KindPreconditionFailed, "VERSION_MISMATCH", "user was changed since it was read")
+	ErrAccountClosed        = newError(KindUnprocessable, "ACCOUNT_CLOSED", "account is closed")
+	ErrBalanceNotZero       = newError(KindConflict, "BALANCE_NOT_ZERO", "account still holds points; close it with forfeit to write them off")
+	ErrAccountFrozen        = newError(KindForbidden, "ACCOUNT_FROZEN", "account is frozen pending review")
+	ErrStatusUnchanged      = newError(KindConflict, "STATUS_UNCHANGED", "account already has this status")
+	ErrWebhookNotFound      = newError(KindNotFound, "WEBHOOK_NOT_FOUND", "webhook subscription not found")
+	ErrDeliveryNotFound     = newError(KindNotFound, "DELIVERY_NOT_FOUND", "webhook delivery not found")
+	ErrDeliveryNotDead      = newError(KindConflict, "DELIVERY_NOT_DEAD", "only dead deliveries can be redelivered")
+	ErrEventNotFound        = newError(KindNotFound, "EVENT_NOT_FOUND", "event not found")
+	ErrEventNotDead         = newError(KindConflict, "EVENT_NOT_DEAD", "only dead events can be retried")
+)
+
+// KindOf reports the kind of err. Field validation errors are KindValidation,
+// or KindConflict when every field failed a uniqueness check; errors that
+// are not service errors are KindInternal.
+func KindOf(err error) ErrorKind {
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		if verr.Conflict() {
+			return KindConflict
+		}
+		return KindValidation
+	}
+	var serr *Error
+	if errors.As(err, &serr) {
+		return serr.Kind
+	}
+	return KindInternal
+}
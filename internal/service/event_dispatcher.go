@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// EventDispatcher publishes outbox events to every sink. An event is marked
+// published once all sinks accept it. A sink that fails is sent the event
+// again after a backoff, so each sink sees it at least once; sinks that
+// already accepted it are skipped. An event that still fails after the
+// policy's attempts is dead until it is retried on request. Retries may
+// reorder events, so consumers should order by CreatedAt and ignore repeated
+// IDs.
+type EventDispatcher struct {
+	outbox    port.OutboxRepository
+	sinks     map[string]port.EventSink
+	names     []string
+	clock     port.Clock
+	policy    domain.DispatchPolicy
+	batchSize int
+}
+
+// NewEventDispatcher publishes to sinks in the order of their names, which
+// are recorded against each event they accept.
+func NewEventDispatcher(outbox port.OutboxRepository, sinks map[string]port.EventSink, clock port.Clock, policy domain.DispatchPolicy, batchSize int) *EventDispatcher {
+	names := slices.Sorted(maps.Keys(sinks))
+	return &EventDispatcher{outbox: outbox, sinks: sinks, names: names, clock: clock, policy: policy, batchSize: batchSize}
+}
+
+// DispatchDue claims up to one batch of events that are due and publishes
+// them, oldest first. Claimed events are not due for other dispatchers
+// until the policy's claim timeout has passed.
+func (d *EventDispatcher) DispatchDue(ctx context.Context) (*domain.DispatchRun, error) {
+	now := d.clock.Now()
+	events, err := d.outbox.ClaimDue(now, now.Add(d.policy.ClaimTimeout), d.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due events: %w", err)
+	}
+
+	run := &domain.DispatchRun{}
+	for _, event := range events {
+		publishedTo, err := d.publish(ctx, event)
+		if err != nil {
+			attempts := event.Attempts + 1
+			if attempts >= d.policy.MaxAttempts {
+				if err := d.outbox.MarkDead(event.ID, err.Error(), publishedTo); err != nil {
+					return run, fmt.Errorf("failed to mark event %d dead: %w", event.ID, err)
+				}
+				run.Dead++
+				continue
+			}
+			next := d.clock.Now().Add(d.policy.Backoff.Delay(attempts))
+			if err := d.outbox.MarkFailed(event.ID, err.Error(), next, publishedTo); err != nil {
+				return run, fmt.Errorf("failed to reschedule event %d: %w", event.ID, err)
+			}
+			run.Failed++
+			continue
+		}
+		if err := d.outbox.MarkPublished(event.ID, d.clock.Now()); err != nil {
+			return run, fmt.Errorf("failed to mark event %d published: %w", event.ID, err)
+		}
+		run.Published++
+	}
+	return run, nil
+}
+
+// publish sends the event to every sink that has not accepted it yet and
+// returns the names of all sinks that have, with the failures joined.
+func (d *EventDispatcher) publish(ctx context.Context, event domain.OutboxEvent) ([]string, error) {
+	publishedTo := slices.Clone(event.PublishedTo)
+	var errs []error
+	for _, name := range d.names {
+		if slices.Contains(publishedTo, name) {
+			continue
+		}
+		if err := d.sinks[name].Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		publishedTo = append(publishedTo, name)
+	}
+	return publishedTo, errors.Join(errs...)
+}
+
+// ListEvents returns one page of outbox events, newest first, and the total
+// number of matching events.
+func (d *EventDispatcher) ListEvents(filter port.OutboxFilter) ([]domain.OutboxEvent, int, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 || filter.PageSize > 200 {
+		filter.PageSize = 20
+	}
+	events, total, err := d.outbox.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list events: %w", err)
+	}
+	return events, total, nil
+}
+
+// RetryEvent puts a dead event back in the outbox with a fresh set of
+// attempts. Sinks that accepted it before are still skipped.
+func (d *EventDispatcher) RetryEvent(ctx context.Context, id int) (*domain.OutboxEvent, error) {
+	event, err := d.outbox.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, ErrEventNotFound
+	}
+	if event.Status != domain.OutboxEventDead {
+		return nil, ErrEventNotDead
+	}
+
+	now := d.clock.Now()
+	if err := d.outbox.Requeue(id, now); err != nil {
+		return nil, fmt.Errorf("failed to requeue event: %w", err)
+	}
+	event.Status = domain.OutboxEventPending
+	event.Attempts = 0
+	event.NextAttemptAt = now
+	return event, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workshop4-backend/internal/adapter/memory"
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
+)
+
+// recordingSink records the events it accepts and rejects the next
+// failures deliveries.
+type recordingSink struct {
+	events   []domain.OutboxEvent
+	failures int
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func eventNames(events []domain.OutboxEvent) []domain.EventName {
+	var names []domain.EventName
+	for _, event := range events {
+		names = append(names, event.Name)
+	}
+	return names
+}
+
+var testBackoff = domain.Backoff{Base: time.Second, Max: time.Minute}
+
+var testDispatchPolicy = domain.DispatchPolicy{Backoff: testBackoff, MaxAttempts: 5, ClaimTimeout: time.Minute}
+
+func TestEventDispatcher_PublishesCommittedEvents(t *testing.T) {
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	tx := memory.NewTransactor(store)
+	clock := testutil.NewClock(testNow)
+
+	users := NewUserService(userRepo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, clock)
+	sender := &domain.User{Name: "สมชาย ใจดี", Phone: "0812345678", Email: "somchai@example.com", Points: 1000}
+	recipient := &domain.User{Name: "สมหญิง ดีใจ", Phone: "0815678901", Email: "somying@example.com"}
+	require.NoError(t, users.CreateUser(context.Background(), sender))
+	require.NoError(t, users.CreateUser(context.Background(), recipient))
+
+	transfers := NewTransferService(memory.NewTransferRepository(store), memory.NewPointLedgerRepository(store), userRepo, tx, clock, testutil.NewIDs())
+	_, err := transfers.CreateTransfer(context.Background(), sender.ID, recipient.ID, 300, nil)
+	require.NoError(t, err)
+
+	// A rolled-back transfer leaves no event behind
+	_, err = transfers.CreateTransfer(context.Background(), recipient.ID, sender.ID, 5000, nil)
+	require.Equal(t, ErrInsufficientBalance, err)
+
+	first, second := &recordingSink{}, &recordingSink{}
+	dispatcher := NewEventDispatcher(memory.NewOutboxRepository(store), map[string]port.EventSink{"first": first, "second": second}, clock, testDispatchPolicy, 100)
+	run, err := dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &domain.DispatchRun{Published: 3}, run)
+
+	want := []domain.EventName{domain.EventUserCreated, domain.EventUserCreated, domain.EventTransferCompleted}
+	assert.Equal(t, want, eventNames(first.events))
+	assert.Equal(t, want, eventNames(second.events))
+
+	var completed domain.TransferCompletedEvent
+	require.NoError(t, json.Unmarshal(first.events[2].Payload, &completed))
+	assert.Equal(t, 700, completed.FromBalanceAfter)
+	assert.Equal(t, 300, completed.ToBalanceAfter)
+
+	// Published events are not sent again
+	run, err = dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &domain.DispatchRun{}, run)
+}
+
+func TestEventDispatcher_RetriesWithBackoff(t *testing.T) {
+	store := memory.NewStore()
+	outbox := memory.NewOutboxRepository(store)
+	clock := testutil.NewClock(testNow)
+
+	event, err := domain.NewOutboxEvent(domain.EventPointsEarned, domain.AuditEntityUser, 1, domain.PointsChangedEvent{UserID: 1, Change: 50}, testNow)
+	require.NoError(t, err)
+	require.NoError(t, outbox.Create(event))
+
+	healthy, flaky := &recordingSink{}, &recordingSink{failures: 2}
+	dispatcher := NewEventDispatcher(outbox, map[string]port.EventSink{"healthy": healthy, "flaky": flaky}, clock, testDispatchPolicy, 100)
+	dispatch := func() *domain.DispatchRun {
+		t.Helper()
+		run, err := dispatcher.DispatchDue(context.Background())
+		require.NoError(t, err)
+		return run
+	}
+
+	assert.Equal(t, &domain.DispatchRun{Failed: 1}, dispatch())
+	// The retry waits for the backoff: 1s, then 2s
+	assert.Equal(t, &domain.DispatchRun{}, dispatch())
+	clock.Advance(time.Second)
+	assert.Equal(t, &domain.DispatchRun{Failed: 1}, dispatch())
+	clock.Advance(time.Second)
+	assert.Equal(t, &domain.DispatchRun{}, dispatch())
+	clock.Advance(time.Second)
+	assert.Equal(t, &domain.DispatchRun{Published: 1}, dispatch())
+
+	// Only the failing sink was retried
+	assert.Len(t, healthy.events, 1)
+	if assert.Len(t, flaky.events, 1) {
+		assert.Equal(t, event.ID, flaky.events[0].ID)
+		assert.Equal(t, 2, flaky.events[0].Attempts)
+		assert.Equal(t, "flaky: sink unavailable", *flaky.events[0].LastError)
+		assert.Equal(t, []string{"healthy"}, flaky.events[0].PublishedTo)
+	}
+}
+
+func TestEventDispatcher_DeadAfterMaxAttempts_RetryRequeues(t *testing.T) {
+	store := memory.NewStore()
+	outbox := memory.NewOutboxRepository(store)
+	clock := testutil.NewClock(testNow)
+
+	event, err := domain.NewOutboxEvent(domain.EventPointsEarned, domain.AuditEntityUser, 1, domain.PointsChangedEvent{UserID: 1, Change: 50}, testNow)
+	require.NoError(t, err)
+	require.NoError(t, outbox.Create(event))
+
+	healthy, broken := &recordingSink{}, &recordingSink{failures: 3}
+	policy := domain.DispatchPolicy{Backoff: testBackoff, MaxAttempts: 2, ClaimTimeout: time.Minute}
+	dispatcher := NewEventDispatcher(outbox, map[string]port.EventSink{"healthy": healthy, "broken": broken}, clock, policy, 100)
+	dispatch := func() *domain.DispatchRun {
+		t.Helper()
+		run, err := dispatcher.DispatchDue(context.Background())
+		require.NoError(t, err)
+		return run
+	}
+
+	assert.Equal(t, &domain.DispatchRun{Failed: 1}, dispatch())
+	clock.Advance(time.Second)
+	assert.Equal(t, &domain.DispatchRun{Dead: 1}, dispatch())
+	// A dead event is not retried by the dispatcher
+	clock.Advance(time.Hour)
+	assert.Equal(t, &domain.DispatchRun{}, dispatch())
+
+	dead, total, err := dispatcher.ListEvents(port.OutboxFilter{Status: domain.OutboxEventDead})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, 2, dead[0].Attempts)
+		assert.Equal(t, "broken: sink unavailable", *dead[0].LastError)
+	}
+
+	_, err = dispatcher.RetryEvent(context.Background(), event.ID+1)
+	assert.Equal(t, ErrEventNotFound, err)
+	retried, err := dispatcher.RetryEvent(context.Background(), event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OutboxEventPending, retried.Status)
+	assert.Zero(t, retried.Attempts)
+	_, err = dispatcher.RetryEvent(context.Background(), event.ID)
+	assert.Equal(t, ErrEventNotDead, err)
+
+	// The third attempt fails again; the fourth goes through
+	assert.Equal(t, &domain.DispatchRun{Failed: 1}, dispatch())
+	clock.Advance(time.Second)
+	assert.Equal(t, &domain.DispatchRun{Published: 1}, dispatch())
+	assert.Len(t, healthy.events, 1, "sinks that accepted the event are skipped")
+	assert.Len(t, broken.events, 1)
+}
+
+func TestEventDispatcher_ClaimedEventsSkippedByOtherDispatchers(t *testing.T) {
+	store := memory.NewStore()
+	outbox := memory.NewOutboxRepository(store)
+	clock := testutil.NewClock(testNow)
+
+	event, err := domain.NewOutboxEvent(domain.EventPointsEarned, domain.AuditEntityUser, 1, domain.PointsChangedEvent{UserID: 1, Change: 50}, testNow)
+	require.NoError(t, err)
+	require.NoError(t, outbox.Create(event))
+	// Another dispatcher claimed the event and has not settled it yet
+	_, err = outbox.ClaimDue(testNow, testNow.Add(testDispatchPolicy.ClaimTimeout), 100)
+	require.NoError(t, err)
+
+	sink := &recordingSink{}
+	dispatcher := NewEventDispatcher(outbox, map[string]port.EventSink{"sink": sink}, clock, testDispatchPolicy, 100)
+	run, err := dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &domain.DispatchRun{}, run)
+
+	// It died without settling the event, so the claim runs out
+	clock.Advance(testDispatchPolicy.ClaimTimeout)
+	run, err = dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &domain.DispatchRun{Published: 1}, run)
+	assert.Len(t, sink.events, 1)
+}
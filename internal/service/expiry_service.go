@@ -0,0 +1,160 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type ExpiryService struct {
+	lotRepo  port.LotRepository
+	userRepo port.UserRepository
+	tx       port.Transactor
+	clock    port.Clock
+}
+
+func NewExpiryService(lotRepo port.LotRepository, userRepo port.UserRepository, tx port.Transactor, clock port.Clock) *ExpiryService {
+	return &ExpiryService{lotRepo: lotRepo, userRepo: userRepo, tx: tx, clock: clock}
+}
+
+// ExpireDue forfeits every lot that has expired by now, as told by the
+// service's clock. Each member is
+// processed in its own transaction: the remaining points are written off
+// with an expire ledger row and moved to system:breakage in the journal.
+// A member whose expiry fails is logged and counted in UsersFailed so the
+// rest of the run still goes ahead.
+func (s *ExpiryService) ExpireDue() (*domain.ExpiryRun, error) {
+	asOf := s.clock.Now()
+	due, err := s.lotRepo.GetExpired(asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired lots: %w", err)
+	}
+
+	run := &domain.ExpiryRun{}
+	seen := map[int]bool{}
+	for _, lot := range due {
+		if seen[lot.UserID] {
+			continue
+		}
+		seen[lot.UserID] = true
+
+		lots, points, err := s.expireUser(lot.UserID, asOf)
+		if err != nil {
+			log.Printf("expiry: user %d: %v", lot.UserID, err)
+			run.UsersFailed++
+			continue
+		}
+		if lots > 0 {
+			run.UsersAffected++
+			run.LotsExpired += lots
+			run.PointsExpired += points
+		}
+	}
+	return run, nil
+}
+
+func (s *ExpiryService) expireUser(userID int, asOf time.Time) (int, int, error) {
+	lotsExpired, pointsExpired := 0, 0
+	err := s.tx.WithinTransaction(func(repos port.Repositories) error {
+		// Re-read inside the transaction so lots consumed since the scan
+		// are not expired twice
+		open, err := repos.Lots.GetOpenByUserID(userID)
+		if err != nil {
+			return err
+		}
+
+		var lotIDs []int
+		total := 0
+		for _, lot := range open {
+			if lot.ExpiresAt.After(asOf) {
+				continue
+			}
+			if err := repos.Lots.UpdateRemaining(lot.ID, 0); err != nil {
+				return err
+			}
+			lotIDs = append(lotIDs, lot.ID)
+			total += lot.Remaining
+		}
+		if len(lotIDs) == 0 {
+			return nil
+		}
+
+		balance, err := repos.Ledger.GetUserBalance(userID)
+		if err != nil {
+			return err
+		}
+		// Never expire more than the member holds
+		if total > balance {
+			total = balance
+		}
+		lotsExpired = len(lotIDs)
+		if total <= 0 {
+			return nil
+		}
+
+		metadata, err := json.Marshal(map[string]interface{}{"lotIds": lotIDs})
+		if err != nil {
+			return err
+		}
+		metadataStr := string(metadata)
+
+		now := s.clock.Now()
+		entry := &domain.PointLedger{
+			UserID:       userID,
+			Change:       -total,
+			BalanceAfter: balance - total,
+			EventType:    domain.EventTypeExpire,
+			Metadata:     &metadataStr,
+			CreatedAt:    now,
+		}
+		if err := postLedgerEntry(repos, entry); err != nil {
+			return err
+		}
+
+		pointsExpired = total
+		err = postJournalEntry(repos, domain.NewJournalEntry(domain.JournalEntryExpire,
+			domain.MemberAccount(userID), domain.AccountBreakage, total, now))
+		if err != nil {
+			return err
+		}
+		return recordEvent(repos, domain.EventPointsExpired, domain.AuditEntityUser, userID, domain.PointsChangedEvent{
+			UserID:        userID,
+			LedgerEntryID: entry.ID,
+			Change:        entry.Change,
+			BalanceAfter:  entry.BalanceAfter,
+		}, now)
+	})
+	return lotsExpired, pointsExpired, err
+}
+
+// GetUpcomingExpirations lists the member's lots that expire within the
+// next days days.
+func (s *ExpiryService) GetUpcomingExpirations(userID, days int) (*domain.ExpiringPoints, error) {
+	until := s.clock.Now().AddDate(0, 0, days)
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	lots, err := s.lotRepo.GetOpenByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get point lots: %w", err)
+	}
+
+	result := &domain.ExpiringPoints{UserID: userID, Until: until, Lots: []domain.PointLot{}}
+	for _, lot := range lots {
+		if lot.ExpiresAt.After(until) {
+			break
+		}
+		result.Lots = append(result.Lots, lot)
+		result.Total += lot.Remaining
+	}
+	return result, nil
+}
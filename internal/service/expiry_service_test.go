@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
+)
+
+func TestExpiryService_ExpireDue_WritesOffExpiredLots(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	journalRepo := new(MockJournalRepository)
+	lotRepo := new(MockLotRepository)
+	outboxRepo := new(MockOutboxRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: userRepo, Ledger: ledgerRepo, Journal: journalRepo, Lots: lotRepo, Outbox: outboxRepo, Audit: newTestAuditRepository()}}
+	asOf := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	service := NewExpiryService(lotRepo, userRepo, tx, testutil.NewClock(asOf))
+	expired := domain.PointLot{ID: 1, UserID: 7, Amount: 100, Remaining: 40, ExpiresAt: asOf.Add(-time.Hour)}
+	current := domain.PointLot{ID: 2, UserID: 7, Amount: 500, Remaining: 500, ExpiresAt: asOf.AddDate(1, 0, 0)}
+
+	lotRepo.On("GetExpired", asOf).Return([]domain.PointLot{expired}, nil)
+	lotRepo.On("GetOpenByUserID", 7).Return([]domain.PointLot{expired, current}, nil)
+	lotRepo.On("UpdateRemaining", 1, 0).Return(nil)
+	ledgerRepo.On("GetUserBalance", 7).Return(540, nil)
+	ledgerRepo.On("Create", mock.AnythingOfType("*domain.PointLedger")).Return(nil)
+	userRepo.On("UpdatePoints", 7, 500).Return(nil)
+	journalRepo.On("Create", mock.AnythingOfType("*domain.JournalEntry")).Return(nil)
+	outboxRepo.On("Create", eventNamed(domain.EventPointsExpired)).Return(nil)
+
+	run, err := service.ExpireDue()
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.ExpiryRun{UsersAffected: 1, LotsExpired: 1, PointsExpired: 40}, run)
+	lotRepo.AssertNotCalled(t, "UpdateRemaining", 2, mock.Anything)
+
+	entry := ledgerRepo.Calls[1].Arguments.Get(0).(*domain.PointLedger)
+	assert.Equal(t, domain.EventTypeExpire, entry.EventType)
+	assert.Equal(t, -40, entry.Change)
+	assert.Equal(t, `{"lotIds":[1]}`, *entry.Metadata)
+
+	journalEntry := journalRepo.Calls[0].Arguments.Get(0).(*domain.JournalEntry)
+	assert.Equal(t, []domain.Posting{
+		{Account: "member:7", Amount: -40},
+		{Account: domain.AccountBreakage, Amount: 40},
+	}, journalEntry.Postings)
+	outboxRepo.AssertExpectations(t)
+}
+
+func TestExpiryService_ExpireDue_ContinuesPastFailedUsers(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	journalRepo := new(MockJournalRepository)
+	lotRepo := new(MockLotRepository)
+	outboxRepo := new(MockOutboxRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: userRepo, Ledger: ledgerRepo, Journal: journalRepo, Lots: lotRepo, Outbox: outboxRepo, Audit: newTestAuditRepository()}}
+	asOf := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	service := NewExpiryService(lotRepo, userRepo, tx, testutil.NewClock(asOf))
+	broken := domain.PointLot{ID: 1, UserID: 7, Amount: 100, Remaining: 40, ExpiresAt: asOf.Add(-time.Hour)}
+	expired := domain.PointLot{ID: 2, UserID: 8, Amount: 100, Remaining: 60, ExpiresAt: asOf.Add(-time.Hour)}
+
+	lotRepo.On("GetExpired", asOf).Return([]domain.PointLot{broken, expired}, nil)
+	lotRepo.On("GetOpenByUserID", 7).Return([]domain.PointLot(nil), errors.New("corrupt row"))
+	lotRepo.On("GetOpenByUserID", 8).Return([]domain.PointLot{expired}, nil)
+	lotRepo.On("UpdateRemaining", 2, 0).Return(nil)
+	ledgerRepo.On("GetUserBalance", 8).Return(60, nil)
+	ledgerRepo.On("Create", mock.AnythingOfType("*domain.PointLedger")).Return(nil)
+	userRepo.On("UpdatePoints", 8, 0).Return(nil)
+	journalRepo.On("Create", mock.AnythingOfType("*domain.JournalEntry")).Return(nil)
+	outboxRepo.On("Create", eventNamed(domain.EventPointsExpired)).Return(nil)
+
+	run, err := service.ExpireDue()
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.ExpiryRun{UsersAffected: 1, LotsExpired: 1, PointsExpired: 60, UsersFailed: 1}, run)
+	outboxRepo.AssertExpectations(t)
+}
+
+func TestExpiryService_GetUpcomingExpirations(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	lotRepo := new(MockLotRepository)
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	service := NewExpiryService(lotRepo, userRepo, nil, testutil.NewClock(now))
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	lotRepo.On("GetOpenByUserID", 1).Return([]domain.PointLot{
+		{ID: 1, Remaining: 30, ExpiresAt: now.AddDate(0, 0, 10)},
+		{ID: 2, Remaining: 20, ExpiresAt: now.AddDate(0, 0, 60)},
+		{ID: 3, Remaining: 90, ExpiresAt: now.AddDate(1, 0, 0)},
+	}, nil)
+
+	result, err := service.GetUpcomingExpirations(1, 90)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 90), result.Until)
+	assert.Equal(t, 50, result.Total)
+	assert.Len(t, result.Lots, 2)
+
+	userRepo.On("GetByID", 2).Return(nil, nil)
+	_, err = service.GetUpcomingExpirations(2, 90)
+	assert.Equal(t, ErrUserNotFound, err)
+}
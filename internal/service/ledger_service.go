@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+type LedgerService struct {
+	ledgerRepo  port.PointLedgerRepository
+	journalRepo port.JournalRepository
+}
+
+func NewLedgerService(ledgerRepo port.PointLedgerRepository, journalRepo port.JournalRepository) *LedgerService {
+	return &LedgerService{ledgerRepo: ledgerRepo, journalRepo: journalRepo}
+}
+
+// VerifyChain walks the ledger hash chain of a single user, or of every user
+// when userID is nil, and stops at the first broken link.
+func (s *LedgerService) VerifyChain(userID *int) (*domain.LedgerVerification, error) {
+	var userIDs []int
+	if userID != nil {
+		userIDs = []int{*userID}
+	} else {
+		ids, err := s.ledgerRepo.GetUserIDs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ledger users: %w", err)
+		}
+		userIDs = ids
+	}
+
+	result := &domain.LedgerVerification{Valid: true}
+	for _, id := range userIDs {
+		entries, err := s.ledgerRepo.GetChain(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ledger chain for user %d: %w", id, err)
+		}
+
+		checked, brk := domain.VerifyLedgerChain(entries)
+		result.EntriesChecked += checked
+		if brk != nil {
+			result.Valid = false
+			result.FirstBreak = brk
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
+// TrialBalance sums the postings of every journal account. Because each
+// journal entry is balanced, the total across accounts must be zero.
+func (s *LedgerService) TrialBalance() (*domain.TrialBalance, error) {
+	balances, err := s.journalRepo.GetAccountBalances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account balances: %w", err)
+	}
+	return domain.NewTrialBalance(balances), nil
+}
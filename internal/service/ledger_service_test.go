@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"workshop4-backend/internal/domain"
+)
+
+func TestLedgerService_VerifyChain_StopsAtFirstBreak(t *testing.T) {
+	ledgerRepo := new(MockPointLedgerRepository)
+	service := NewLedgerService(ledgerRepo, new(MockJournalRepository))
+
+	valid := domain.PointLedger{ID: 1, UserID: 1, Change: 100, BalanceAfter: 100, EventType: domain.EventTypeEarn, CreatedAt: time.Now()}
+	valid.Hash = valid.ComputeHash("")
+	tampered := domain.PointLedger{ID: 2, UserID: 2, Change: 50, BalanceAfter: 50, EventType: domain.EventTypeEarn, CreatedAt: time.Now()}
+	tampered.Hash = "not-a-hash"
+
+	ledgerRepo.On("GetUserIDs").Return([]int{1, 2, 3}, nil)
+	ledgerRepo.On("GetChain", 1).Return([]domain.PointLedger{valid}, nil)
+	ledgerRepo.On("GetChain", 2).Return([]domain.PointLedger{tampered}, nil)
+
+	result, err := service.VerifyChain(nil)
+	assert.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, 2, result.EntriesChecked)
+	if assert.NotNil(t, result.FirstBreak) {
+		assert.Equal(t, 2, result.FirstBreak.UserID)
+		assert.Equal(t, 2, result.FirstBreak.EntryID)
+	}
+	ledgerRepo.AssertNotCalled(t, "GetChain", 3)
+}
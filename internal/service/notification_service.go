@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// NotificationService tells members about transfers and point changes in
+// their language, over the channels they chose. As an EventSink it turns
+// published events into notifications. Everything an event needs is looked
+// up and rendered before the first message is sent, so an event that fails
+// is retried without repeating messages. Sending itself is best effort: a
+// message a provider rejects is logged and not retried, so a flaky provider
+// neither holds up the other sinks nor repeats messages already sent.
+type NotificationService struct {
+	userRepo    port.UserRepository
+	preferences port.NotificationPreferenceRepository
+	notifiers   map[domain.NotificationChannel]port.Notifier
+	clock       port.Clock
+}
+
+// NewNotificationService sends over the channels in notifiers; a channel
+// without a notifier is never used.
+func NewNotificationService(userRepo port.UserRepository, preferences port.NotificationPreferenceRepository, notifiers map[domain.NotificationChannel]port.Notifier, clock port.Clock) *NotificationService {
+	return &NotificationService{userRepo: userRepo, preferences: preferences, notifiers: notifiers, clock: clock}
+}
+
+// GetPreferences returns the user's notification preferences, or the
+// defaults if they never saved any.
+func (s *NotificationService) GetPreferences(userID int) (*domain.NotificationPreferences, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+	return s.preferencesOf(userID)
+}
+
+// UpdatePreferences replaces the user's notification preferences.
+func (s *NotificationService) UpdatePreferences(ctx context.Context, preferences *domain.NotificationPreferences) error {
+	if err := preferences.Validate(); err != nil {
+		return err
+	}
+	user, err := s.getUser(preferences.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Closed() {
+		return ErrAccountClosed
+	}
+
+	if preferences.Muted == nil {
+		preferences.Muted = []domain.NotificationKind{}
+	}
+	preferences.UpdatedAt = s.clock.Now()
+	if err := s.preferences.Save(preferences); err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return nil
+}
+
+// Publish notifies the members an event concerns: both sides of a
+// completed transfer, and the member who earned or redeemed points.
+func (s *NotificationService) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	var messages []*message
+	switch event.Name {
+	case domain.EventTransferCompleted:
+		var payload domain.TransferCompletedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to decode event %d: %w", event.ID, err)
+		}
+		var err error
+		if messages, err = s.transferMessages(payload); err != nil {
+			return err
+		}
+
+	case domain.EventPointsEarned, domain.EventPointsRedeemed:
+		var payload domain.PointsChangedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to decode event %d: %w", event.ID, err)
+		}
+		user, err := s.userRepo.GetByID(payload.UserID)
+		if err != nil || user == nil {
+			return err
+		}
+		kind, amount := domain.NotificationPointsEarned, payload.Change
+		if event.Name == domain.EventPointsRedeemed {
+			kind, amount = domain.NotificationPointsRedeemed, -payload.Change
+		}
+		m, err := s.render(user, kind, domain.NotificationData{Amount: amount, Balance: payload.BalanceAfter})
+		if err != nil {
+			return err
+		}
+		messages = append(messages, m)
+	}
+
+	for _, m := range messages {
+		s.send(ctx, m)
+	}
+	return nil
+}
+
+// message is a notification rendered for one member, ready to send over
+// the channels they want it on.
+type message struct {
+	user        *domain.User
+	kind        domain.NotificationKind
+	preferences *domain.NotificationPreferences
+	subject     string
+	body        string
+}
+
+func (s *NotificationService) transferMessages(transfer domain.TransferCompletedEvent) ([]*message, error) {
+	sender, err := s.userRepo.GetByID(transfer.FromUserID)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := s.userRepo.GetByID(transfer.ToUserID)
+	if err != nil {
+		return nil, err
+	}
+	if sender == nil || recipient == nil {
+		return nil, nil
+	}
+
+	var note string
+	if transfer.Note != nil {
+		note = *transfer.Note
+	}
+	sent, err := s.render(sender, domain.NotificationTransferSent, domain.NotificationData{
+		Amount: transfer.Amount, Balance: transfer.FromBalanceAfter, Counterparty: recipient.Name, Note: note,
+	})
+	if err != nil {
+		return nil, err
+	}
+	received, err := s.render(recipient, domain.NotificationTransferReceived, domain.NotificationData{
+		Amount: transfer.Amount, Balance: transfer.ToBalanceAfter, Counterparty: sender.Name, Note: note,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []*message{sent, received}, nil
+}
+
+// render prepares a kind notification for the user in their language. It
+// returns nil for closed accounts, which get nothing.
+func (s *NotificationService) render(user *domain.User, kind domain.NotificationKind, data domain.NotificationData) (*message, error) {
+	if user.Closed() {
+		return nil, nil
+	}
+	preferences, err := s.preferencesOf(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	subject, body, err := domain.RenderNotification(kind, preferences.Language, data)
+	if err != nil {
+		return nil, err
+	}
+	return &message{user: user, kind: kind, preferences: preferences, subject: subject, body: body}, nil
+}
+
+// send delivers m over every channel the member wants it on and has an
+// address for, logging the failures.
+func (s *NotificationService) send(ctx context.Context, m *message) {
+	if m == nil {
+		return
+	}
+	for _, channel := range []domain.NotificationChannel{domain.NotificationEmail, domain.NotificationSMS} {
+		notifier, ok := s.notifiers[channel]
+		if !ok || !m.preferences.Wants(channel, m.kind) {
+			continue
+		}
+		notification := domain.Notification{Channel: channel, To: m.user.Email, Subject: m.subject, Body: m.body}
+		if channel == domain.NotificationSMS {
+			notification.To, notification.Subject = m.user.Phone, ""
+		}
+		if notification.To == "" {
+			continue
+		}
+		if err := notifier.Notify(ctx, notification); err != nil {
+			log.Printf("notification: %s by %s to user %d: %v", m.kind, channel, m.user.ID, err)
+		}
+	}
+}
+
+func (s *NotificationService) getUser(userID int) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *NotificationService) preferencesOf(userID int) (*domain.NotificationPreferences, error) {
+	preferences, err := s.preferences.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if preferences == nil {
+		return domain.DefaultNotificationPreferences(userID), nil
+	}
+	return preferences, nil
+}
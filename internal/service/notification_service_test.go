@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workshop4-backend/internal/adapter"
+	"workshop4-backend/internal/adapter/memory"
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
+)
+
+// recordingNotifier records the notifications it is given and fails them
+// all while err is set.
+type recordingNotifier struct {
+	notifications []domain.Notification
+	err           error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification domain.Notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+// flakyPreferences fails to load the preferences of failUser, if set.
+type flakyPreferences struct {
+	port.NotificationPreferenceRepository
+	failUser int
+}
+
+func (p *flakyPreferences) Get(userID int) (*domain.NotificationPreferences, error) {
+	if userID == p.failUser {
+		return nil, errors.New("database is locked")
+	}
+	return p.NotificationPreferenceRepository.Get(userID)
+}
+
+// smsGateway is a local SMS gateway that records the messages POSTed to it.
+type smsGateway struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []map[string]string
+	auth     []string
+}
+
+func newSMSGateway(t *testing.T) *smsGateway {
+	g := &smsGateway{}
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var message map[string]string
+		if err := json.NewDecoder(req.Body).Decode(&message); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.messages = append(g.messages, message)
+		g.auth = append(g.auth, req.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(g.Close)
+	return g
+}
+
+type notificationFixture struct {
+	notifications *NotificationService
+	transfers     TransferManager
+	points        PointsManager
+	dispatcher    *EventDispatcher
+	clock         *testutil.Clock
+	preferences   *flakyPreferences
+	email         *recordingNotifier
+	gateway       *smsGateway
+	sender        *domain.User
+	recipient     *domain.User
+}
+
+func newNotificationFixture(t *testing.T) *notificationFixture {
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	ledgerRepo := memory.NewPointLedgerRepository(store)
+	tx := memory.NewTransactor(store)
+	clock := testutil.NewClock(testNow)
+
+	users := NewUserService(userRepo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, clock)
+	sender := &domain.User{Name: "สมชาย ใจดี", Phone: "0812345678", Email: "somchai@example.com", Points: 15420}
+	recipient := &domain.User{Name: "Jane Doe", Phone: "0815678901", Email: "jane@example.com", Points: 100}
+	require.NoError(t, users.CreateUser(context.Background(), sender))
+	require.NoError(t, users.CreateUser(context.Background(), recipient))
+
+	email, gateway := &recordingNotifier{}, newSMSGateway(t)
+	preferences := &flakyPreferences{NotificationPreferenceRepository: memory.NewNotificationPreferenceRepository(store)}
+	notifications := NewNotificationService(userRepo, preferences, map[domain.NotificationChannel]port.Notifier{
+		domain.NotificationEmail: email,
+		domain.NotificationSMS:   adapter.NewHTTPSMSNotifier(gateway.URL, "sms-key", "LBK", time.Second),
+	}, clock)
+
+	return &notificationFixture{
+		notifications: notifications,
+		transfers:     NewTransferService(memory.NewTransferRepository(store), ledgerRepo, userRepo, tx, clock, testutil.NewIDs()),
+		points:        NewPointsService(tx, domain.ExpiryPolicy{Months: 24}, clock),
+		dispatcher:    NewEventDispatcher(memory.NewOutboxRepository(store), map[string]port.EventSink{"notifications": notifications}, clock, testDispatchPolicy, 100),
+		clock:         clock,
+		preferences:   preferences,
+		email:         email,
+		gateway:       gateway,
+		sender:        sender,
+		recipient:     recipient,
+	}
+}
+
+func (f *notificationFixture) dispatch(t *testing.T) *domain.DispatchRun {
+	t.Helper()
+	run, err := f.dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+	return run
+}
+
+func TestNotificationService_TransferReceipts(t *testing.T) {
+	f := newNotificationFixture(t)
+	require.NoError(t, f.notifications.UpdatePreferences(context.Background(), &domain.NotificationPreferences{
+		UserID: f.recipient.ID, Email: true, SMS: true, Language: domain.LanguageEnglish,
+	}))
+
+	note := "ค่าข้าว"
+	_, err := f.transfers.CreateTransfer(context.Background(), f.sender.ID, f.recipient.ID, 1500, &note)
+	require.NoError(t, err)
+	assert.Equal(t, 3, f.dispatch(t).Published)
+
+	// The sender has the defaults: email only, in Thai
+	assert.Equal(t, []domain.Notification{
+		{
+			Channel: domain.NotificationEmail,
+			To:      "somchai@example.com",
+			Subject: "โอนคะแนนสำเร็จ",
+			Body:    "คุณโอน 1,500 คะแนนให้ Jane Doe แล้ว (หมายเหตุ: ค่าข้าว) คะแนนคงเหลือ 13,920 คะแนน",
+		},
+		{
+			Channel: domain.NotificationEmail,
+			To:      "jane@example.com",
+			Subject: "You received points",
+			Body:    "You received 1,500 points from สมชาย ใจดี. Note: ค่าข้าว Your balance is 1,600 points.",
+		},
+	}, f.email.notifications)
+
+	assert.Equal(t, []map[string]string{{
+		"to":   "+66815678901",
+		"from": "LBK",
+		"text": "You received 1,500 points from สมชาย ใจดี. Note: ค่าข้าว Your balance is 1,600 points.",
+	}}, f.gateway.messages)
+	assert.Equal(t, []string{"Bearer sms-key"}, f.gateway.auth)
+}
+
+func TestNotificationService_PointsRespectMutedKinds(t *testing.T) {
+	f := newNotificationFixture(t)
+	require.NoError(t, f.notifications.UpdatePreferences(context.Background(), &domain.NotificationPreferences{
+		UserID: f.sender.ID, Email: true, Language: domain.LanguageEnglish, Muted: []domain.NotificationKind{domain.NotificationPointsEarned},
+	}))
+
+	_, err := f.points.Earn(context.Background(), f.sender.ID, 200, nil)
+	require.NoError(t, err)
+	_, err = f.points.Redeem(context.Background(), f.sender.ID, 620, nil)
+	require.NoError(t, err)
+	_, err = f.points.Earn(context.Background(), f.recipient.ID, 50, nil)
+	require.NoError(t, err)
+	f.dispatch(t)
+
+	assert.Equal(t, []domain.Notification{
+		{Channel: domain.NotificationEmail, To: "somchai@example.com", Subject: "Points redeemed", Body: "You redeemed 620 points. Your balance is 15,000 points."},
+		{Channel: domain.NotificationEmail, To: "jane@example.com", Subject: "ได้รับคะแนนสะสม", Body: "คุณได้รับ 50 คะแนนสะสม คะแนนคงเหลือ 150 คะแนน"},
+	}, f.email.notifications)
+	assert.Empty(t, f.gateway.messages)
+}
+
+func TestNotificationService_ProviderFailuresAreNotRetried(t *testing.T) {
+	f := newNotificationFixture(t)
+	f.email.err = errors.New("smtp unavailable")
+
+	_, err := f.transfers.CreateTransfer(context.Background(), f.sender.ID, f.recipient.ID, 100, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.DispatchRun{Published: 3}, f.dispatch(t))
+
+	f.email.err = nil
+	assert.Zero(t, f.dispatch(t).Published)
+	assert.Empty(t, f.email.notifications)
+}
+
+func TestNotificationService_RetriedTransferIsNotResent(t *testing.T) {
+	f := newNotificationFixture(t)
+	f.preferences.failUser = f.recipient.ID
+
+	_, err := f.transfers.CreateTransfer(context.Background(), f.sender.ID, f.recipient.ID, 100, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.DispatchRun{Published: 2, Failed: 1}, f.dispatch(t))
+	// Nothing is sent until both sides can be notified
+	assert.Empty(t, f.email.notifications)
+
+	f.preferences.failUser = 0
+	f.clock.Advance(time.Second)
+	assert.Equal(t, &domain.DispatchRun{Published: 1}, f.dispatch(t))
+	if assert.Len(t, f.email.notifications, 2) {
+		assert.Equal(t, "somchai@example.com", f.email.notifications[0].To)
+		assert.Equal(t, "jane@example.com", f.email.notifications[1].To)
+	}
+}
+
+func TestNotificationService_Preferences(t *testing.T) {
+	f := newNotificationFixture(t)
+
+	got, err := f.notifications.GetPreferences(f.sender.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultNotificationPreferences(f.sender.ID), got)
+
+	_, err = f.notifications.GetPreferences(999)
+	assert.Equal(t, ErrUserNotFound, err)
+	err = f.notifications.UpdatePreferences(context.Background(), &domain.NotificationPreferences{UserID: 999, Language: domain.LanguageThai})
+	assert.Equal(t, ErrUserNotFound, err)
+
+	invalid := &domain.NotificationPreferences{UserID: f.sender.ID, Language: "fr", Muted: []domain.NotificationKind{"newsletter"}}
+	err = f.notifications.UpdatePreferences(context.Background(), invalid)
+	var verr *domain.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Len(t, verr.Fields, 2)
+		assert.Equal(t, "language", verr.Fields[0].Field)
+		assert.Equal(t, "muted[0]", verr.Fields[1].Field)
+	}
+
+	preferences := &domain.NotificationPreferences{UserID: f.sender.ID, SMS: true, Language: domain.LanguageEnglish}
+	require.NoError(t, f.notifications.UpdatePreferences(context.Background(), preferences))
+	got, err = f.notifications.GetPreferences(f.sender.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.NotificationPreferences{
+		UserID: f.sender.ID, SMS: true, Language: domain.LanguageEnglish, Muted: []domain.NotificationKind{}, UpdatedAt: testNow,
+	}, got)
+}
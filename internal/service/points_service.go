@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// PointsManager is the earn/redeem API consumed by handlers. It is
+// implemented by PointsService and by decorators such as TieredPointsService.
+type PointsManager interface {
+	Earn(ctx context.Context, userID, amount int, reference *string) (*domain.PointLedger, error)
+	Redeem(ctx context.Context, userID, amount int, reference *string) (*domain.PointLedger, error)
+}
+
+// PointsService moves points between a member and the system accounts:
+// earned points are issued by system:issuance and redeemed points are
+// returned to system:redemption.
+type PointsService struct {
+	tx     port.Transactor
+	expiry domain.ExpiryPolicy
+	clock  port.Clock
+}
+
+func NewPointsService(tx port.Transactor, expiry domain.ExpiryPolicy, clock port.Clock) *PointsService {
+	return &PointsService{tx: tx, expiry: expiry, clock: clock}
+}
+
+func (s *PointsService) Earn(ctx context.Context, userID, amount int, reference *string) (*domain.PointLedger, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	return s.post(ctx, userID, amount, reference, domain.EventTypeEarn)
+}
+
+func (s *PointsService) Redeem(ctx context.Context, userID, amount int, reference *string) (*domain.PointLedger, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	return s.post(ctx, userID, -amount, reference, domain.EventTypeRedeem)
+}
+
+// post applies a signed change to the member's balance together with its
+// ledger row, balanced journal entry and audit entry.
+func (s *PointsService) post(ctx context.Context, userID, change int, reference *string, eventType domain.EventType) (*domain.PointLedger, error) {
+	var entry *domain.PointLedger
+	err := s.tx.WithinTransaction(func(repos port.Repositories) error {
+		if _, err := getActiveAccount(repos, userID); err != nil {
+			return err
+		}
+
+		balance, err := repos.Ledger.GetUserBalance(userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user balance: %w", err)
+		}
+		if balance+change < 0 {
+			return ErrInsufficientBalance
+		}
+
+		now := s.clock.Now()
+		entry = &domain.PointLedger{
+			UserID:       userID,
+			Change:       change,
+			BalanceAfter: balance + change,
+			EventType:    eventType,
+			Reference:    reference,
+			CreatedAt:    now,
+		}
+		if err := postLedgerEntry(repos, entry); err != nil {
+			return err
+		}
+
+		var journalEntry *domain.JournalEntry
+		if eventType == domain.EventTypeEarn {
+			if err := creditLot(repos, userID, change, &entry.ID, now, s.expiry.ExpiresAt(now)); err != nil {
+				return err
+			}
+			journalEntry = domain.NewJournalEntry(domain.JournalEntryEarn,
+				domain.AccountIssuance, domain.MemberAccount(userID), change, now)
+		} else {
+			if _, err := consumeLots(repos, userID, -change); err != nil {
+				return err
+			}
+			journalEntry = domain.NewJournalEntry(domain.JournalEntryRedeem,
+				domain.MemberAccount(userID), domain.AccountRedemption, -change, now)
+		}
+		journalEntry.Reference = reference
+		if err := postJournalEntry(repos, journalEntry); err != nil {
+			return err
+		}
+
+		name, action := domain.EventPointsEarned, domain.AuditActionPointsEarn
+		if eventType == domain.EventTypeRedeem {
+			name, action = domain.EventPointsRedeemed, domain.AuditActionPointsRedeem
+		}
+		err = recordEvent(repos, name, domain.AuditEntityUser, userID, domain.PointsChangedEvent{
+			UserID:        userID,
+			LedgerEntryID: entry.ID,
+			Change:        change,
+			BalanceAfter:  entry.BalanceAfter,
+			Reference:     reference,
+		}, now)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos, action, domain.AuditEntityLedgerEntry, strconv.Itoa(entry.ID), nil, entry, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
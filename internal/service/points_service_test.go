@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
+)
+
+func newPointsServiceWithMocks() (*PointsService, *MockUserRepository, *MockPointLedgerRepository, *MockJournalRepository, *MockLotRepository, *MockOutboxRepository) {
+	userRepo := new(MockUserRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	journalRepo := new(MockJournalRepository)
+	lotRepo := new(MockLotRepository)
+	outboxRepo := new(MockOutboxRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: userRepo, Ledger: ledgerRepo, Journal: journalRepo, Lots: lotRepo, Outbox: outboxRepo, Audit: newTestAuditRepository()}}
+	return NewPointsService(tx, domain.ExpiryPolicy{Months: 24}, testutil.NewClock(testNow)), userRepo, ledgerRepo, journalRepo, lotRepo, outboxRepo
+}
+
+func TestPointsService_Earn_IssuesPoints(t *testing.T) {
+	service, userRepo, ledgerRepo, journalRepo, lotRepo, outboxRepo := newPointsServiceWithMocks()
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(100, nil)
+	ledgerRepo.On("Create", mock.AnythingOfType("*domain.PointLedger")).Return(nil)
+	userRepo.On("UpdatePoints", 1, 150).Return(nil)
+	journalRepo.On("Create", mock.AnythingOfType("*domain.JournalEntry")).Return(nil)
+	lotRepo.On("Create", mock.AnythingOfType("*domain.PointLot")).Return(nil)
+	outboxRepo.On("Create", eventNamed(domain.EventPointsEarned)).Return(nil)
+
+	entry, err := service.Earn(context.Background(), 1, 50, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.EventTypeEarn, entry.EventType)
+	assert.Equal(t, 50, entry.Change)
+	assert.Equal(t, 150, entry.BalanceAfter)
+
+	lot := lotRepo.Calls[0].Arguments.Get(0).(*domain.PointLot)
+	assert.Equal(t, 50, lot.Amount)
+	assert.Equal(t, entry.CreatedAt.AddDate(0, 24, 0), lot.ExpiresAt)
+
+	journalEntry := journalRepo.Calls[0].Arguments.Get(0).(*domain.JournalEntry)
+	assert.Equal(t, []domain.Posting{
+		{Account: domain.AccountIssuance, Amount: -50},
+		{Account: "member:1", Amount: 50},
+	}, journalEntry.Postings)
+
+	event := outboxRepo.Calls[0].Arguments.Get(0).(*domain.OutboxEvent)
+	assert.Equal(t, domain.AuditEntityUser, event.AggregateType)
+	assert.Equal(t, "1", event.AggregateID)
+	assert.JSONEq(t, `{"userId":1,"ledgerEntryId":0,"change":50,"balanceAfter":150}`, string(event.Payload))
+}
+
+func TestPointsService_Redeem_MovesPointsToRedemption(t *testing.T) {
+	service, userRepo, ledgerRepo, journalRepo, lotRepo, outboxRepo := newPointsServiceWithMocks()
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(100, nil)
+	ledgerRepo.On("Create", mock.AnythingOfType("*domain.PointLedger")).Return(nil)
+	userRepo.On("UpdatePoints", 1, 60).Return(nil)
+	lotRepo.On("GetOpenByUserID", 1).Return([]domain.PointLot{{ID: 5, Amount: 100, Remaining: 100}}, nil)
+	lotRepo.On("UpdateRemaining", 5, 60).Return(nil)
+	journalRepo.On("Create", mock.AnythingOfType("*domain.JournalEntry")).Return(nil)
+	outboxRepo.On("Create", eventNamed(domain.EventPointsRedeemed)).Return(nil)
+
+	entry, err := service.Redeem(context.Background(), 1, 40, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, -40, entry.Change)
+
+	journalEntry := journalRepo.Calls[0].Arguments.Get(0).(*domain.JournalEntry)
+	assert.Equal(t, []domain.Posting{
+		{Account: "member:1", Amount: -40},
+		{Account: domain.AccountRedemption, Amount: 40},
+	}, journalEntry.Postings)
+}
+
+func TestPointsService_Redeem_InsufficientBalance(t *testing.T) {
+	service, userRepo, ledgerRepo, journalRepo, _, _ := newPointsServiceWithMocks()
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(10, nil)
+
+	entry, err := service.Redeem(context.Background(), 1, 40, nil)
+	assert.Nil(t, entry)
+	assert.Equal(t, ErrInsufficientBalance, err)
+	journalRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestPointsService_InvalidAmount(t *testing.T) {
+	service, _, _, _, _, _ := newPointsServiceWithMocks()
+
+	_, err := service.Earn(context.Background(), 1, 0, nil)
+	assert.Equal(t, ErrInvalidAmount, err)
+
+	_, err = service.Redeem(context.Background(), 1, -5, nil)
+	assert.Equal(t, ErrInvalidAmount, err)
+}
+
+func TestPointsService_Earn_ClosedAccount(t *testing.T) {
+	service, userRepo, ledgerRepo, _, _, _ := newPointsServiceWithMocks()
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusClosed}, nil)
+
+	_, err := service.Earn(context.Background(), 1, 100, nil)
+	assert.Equal(t, ErrAccountClosed, err)
+	ledgerRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestPointsService_Redeem_FrozenAccount(t *testing.T) {
+	service, userRepo, ledgerRepo, _, _, _ := newPointsServiceWithMocks()
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusSuspended, Points: 500}, nil)
+
+	_, err := service.Redeem(context.Background(), 1, 100, nil)
+	assert.Equal(t, ErrAccountFrozen, err)
+	ledgerRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
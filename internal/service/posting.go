@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// getActiveAccount loads the user within repos' transaction and rejects
+// closed and frozen accounts, which can neither send nor receive points.
+func getActiveAccount(repos port.Repositories, userID int) (*domain.User, error) {
+	user, err := repos.Users.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if err := checkActive(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkActive reports why user's account cannot move points, if it cannot.
+func checkActive(user *domain.User) error {
+	switch {
+	case user.Closed():
+		return ErrAccountClosed
+	case user.Suspended():
+		return ErrAccountFrozen
+	}
+	return nil
+}
+
+// postLedgerEntry appends entry to the user's point ledger and keeps the
+// denormalized users.points column in step with it.
+func postLedgerEntry(repos port.Repositories, entry *domain.PointLedger) error {
+	if err := repos.Ledger.Create(entry); err != nil {
+		return fmt.Errorf("failed to create %s ledger entry: %w", entry.EventType, err)
+	}
+	if err := repos.Users.UpdatePoints(entry.UserID, entry.BalanceAfter); err != nil {
+		return fmt.Errorf("failed to update user points: %w", err)
+	}
+	return nil
+}
+
+// postJournalEntry records the balanced double-entry counterpart of a ledger
+// movement.
+func postJournalEntry(repos port.Repositories, entry *domain.JournalEntry) error {
+	if err := repos.Journal.Create(entry); err != nil {
+		return fmt.Errorf("failed to create %s journal entry: %w", entry.EntryType, err)
+	}
+	return nil
+}
+
+// creditLot tracks points credited to a member so they can expire at
+// expiresAt.
+func creditLot(repos port.Repositories, userID, amount int, ledgerEntryID *int, earnedAt, expiresAt time.Time) error {
+	lot := &domain.PointLot{
+		UserID:        userID,
+		LedgerEntryID: ledgerEntryID,
+		Amount:        amount,
+		Remaining:     amount,
+		EarnedAt:      earnedAt,
+		ExpiresAt:     expiresAt,
+	}
+	if err := repos.Lots.Create(lot); err != nil {
+		return fmt.Errorf("failed to create point lot: %w", err)
+	}
+	return nil
+}
+
+// consumeLots debits amount from the member's lots, earliest expiry first,
+// and returns what was taken from each lot.
+func consumeLots(repos port.Repositories, userID, amount int) ([]domain.LotConsumption, error) {
+	lots, err := repos.Lots.GetOpenByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get point lots: %w", err)
+	}
+
+	consumed := domain.ConsumeFIFO(lots, amount)
+	for _, c := range consumed {
+		if err := repos.Lots.UpdateRemaining(c.LotID, c.Remaining); err != nil {
+			return nil, fmt.Errorf("failed to update point lot: %w", err)
+		}
+	}
+	return consumed, nil
+}
+
+// recordEvent writes a domain event to the outbox within repos' transaction,
+// so it is published exactly when the change it reports commits.
+func recordEvent(repos port.Repositories, name domain.EventName, aggregateType string, aggregateID int, payload interface{}, at time.Time) error {
+	event, err := domain.NewOutboxEvent(name, aggregateType, aggregateID, payload, at)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", name, err)
+	}
+	if err := repos.Outbox.Create(event); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", name, err)
+	}
+	return nil
+}
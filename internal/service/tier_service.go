@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// TierService keeps each member's MembershipLevel in line with the points
+// they earned over the policy's rolling window.
+type TierService struct {
+	userRepo   port.UserRepository
+	ledgerRepo port.PointLedgerRepository
+	tierRepo   port.TierRepository
+	tx         port.Transactor
+	policy     domain.TierPolicy
+	clock      port.Clock
+}
+
+func NewTierService(userRepo port.UserRepository, ledgerRepo port.PointLedgerRepository, tierRepo port.TierRepository, tx port.Transactor, policy domain.TierPolicy, clock port.Clock) *TierService {
+	return &TierService{userRepo: userRepo, ledgerRepo: ledgerRepo, tierRepo: tierRepo, tx: tx, policy: policy, clock: clock}
+}
+
+// EvaluateUser moves the member to the tier their earned points qualify for.
+// It returns the recorded history row, or nil when the tier is unchanged.
+// Earning only adds points to the window, so an evaluation for a ledger
+// change never downgrades; downgrades wait for the scheduled run. Members
+// with no earn history at all, such as those seeded or migrated with an
+// opening balance, keep their level until they first earn points.
+func (s *TierService) EvaluateUser(userID int, reason string) (*domain.TierHistory, error) {
+	if len(s.policy.Rules) == 0 {
+		return nil, nil
+	}
+
+	var change *domain.TierHistory
+	err := s.tx.WithinTransaction(func(repos port.Repositories) error {
+		user, err := repos.Users.GetByID(userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil {
+			return ErrUserNotFound
+		}
+
+		now := s.clock.Now()
+		earned, err := repos.Ledger.GetEarnedSince(userID, s.policy.WindowStart(now))
+		if err != nil {
+			return fmt.Errorf("failed to sum earned points: %w", err)
+		}
+
+		qualified := s.policy.Rules[s.policy.Qualify(earned)].Name
+		if s.policy.Rank(user.MembershipLevel) == s.policy.Rank(qualified) {
+			return nil
+		}
+		if s.policy.Rank(qualified) < s.policy.Rank(user.MembershipLevel) {
+			if reason == domain.TierChangeReasonLedger {
+				return nil
+			}
+			everEarned, err := repos.Ledger.GetEarnedSince(userID, time.Time{})
+			if err != nil {
+				return fmt.Errorf("failed to sum earned points: %w", err)
+			}
+			if everEarned == 0 {
+				return nil
+			}
+		}
+
+		if err := repos.Users.UpdateMembershipLevel(userID, qualified); err != nil {
+			return fmt.Errorf("failed to update membership level: %w", err)
+		}
+
+		change = &domain.TierHistory{
+			UserID:         userID,
+			FromLevel:      user.MembershipLevel,
+			ToLevel:        qualified,
+			EarnedInWindow: earned,
+			Reason:         reason,
+			CreatedAt:      now,
+		}
+		return repos.Tiers.CreateHistory(change)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// EvaluateAll re-evaluates every member, e.g. from the nightly job, so tiers
+// also drop once old earnings leave the window. A member that fails is
+// logged and counted in UsersFailed, and the rest are still evaluated.
+func (s *TierService) EvaluateAll(reason string) (*domain.TierEvaluationRun, error) {
+	users, err := s.userRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	run := &domain.TierEvaluationRun{}
+	for _, user := range users {
+		change, err := s.EvaluateUser(user.ID, reason)
+		if err != nil {
+			log.Printf("tier: evaluate user %d: %v", user.ID, err)
+			run.UsersFailed++
+			continue
+		}
+		run.UsersEvaluated++
+		if change == nil {
+			continue
+		}
+		if s.policy.Rank(change.ToLevel) > s.policy.Rank(change.FromLevel) {
+			run.Upgraded++
+		} else {
+			run.Downgraded++
+		}
+	}
+	return run, nil
+}
+
+// GetTierStatus returns the member's tier, progress to the next tier and
+// tier history, newest first.
+func (s *TierService) GetTierStatus(userID int) (*domain.TierProgress, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	now := s.clock.Now()
+	earned, err := s.ledgerRepo.GetEarnedSince(userID, s.policy.WindowStart(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum earned points: %w", err)
+	}
+
+	history, err := s.tierRepo.GetHistoryByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tier history: %w", err)
+	}
+
+	progress := s.policy.Progress(user.MembershipLevel, earned, now)
+	progress.UserID = userID
+	progress.History = history
+	return progress, nil
+}
+
+// TieredPointsService re-evaluates the member's tier after every successful
+// earn. Evaluation runs after the earn has committed, so a failure is logged
+// and left for the nightly job to retry.
+type TieredPointsService struct {
+	PointsManager
+	tiers *TierService
+}
+
+func NewTieredPointsService(inner PointsManager, tiers *TierService) *TieredPointsService {
+	return &TieredPointsService{PointsManager: inner, tiers: tiers}
+}
+
+func (s *TieredPointsService) Earn(ctx context.Context, userID, amount int, reference *string) (*domain.PointLedger, error) {
+	entry, err := s.PointsManager.Earn(ctx, userID, amount, reference)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.tiers.EvaluateUser(userID, domain.TierChangeReasonLedger); err != nil {
+		log.Printf("tier: evaluate user %d: %v", userID, err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
+)
+
+type MockTierRepository struct {
+	mock.Mock
+}
+
+func (m *MockTierRepository) CreateHistory(history *domain.TierHistory) error {
+	args := m.Called(history)
+	return args.Error(0)
+}
+
+func (m *MockTierRepository) GetHistoryByUserID(userID int) ([]domain.TierHistory, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]domain.TierHistory), args.Error(1)
+}
+
+func newTierServiceWithMocks() (*TierService, *MockUserRepository, *MockPointLedgerRepository, *MockTierRepository) {
+	userRepo := new(MockUserRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	tierRepo := new(MockTierRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: userRepo, Ledger: ledgerRepo, Tiers: tierRepo, Audit: newTestAuditRepository()}}
+	policy := domain.NewTierPolicy(12, []domain.TierRule{
+		{Name: "Silver", MinPoints: 5000},
+		{Name: "Bronze", MinPoints: 0},
+		{Name: "Gold", MinPoints: 15000},
+	})
+	return NewTierService(userRepo, ledgerRepo, tierRepo, tx, policy, testutil.NewClock(testNow)), userRepo, ledgerRepo, tierRepo
+}
+
+func TestTierService_EvaluateUser_Upgrade(t *testing.T) {
+	service, userRepo, ledgerRepo, tierRepo := newTierServiceWithMocks()
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, MembershipLevel: "Bronze"}, nil)
+	ledgerRepo.On("GetEarnedSince", 1, mock.AnythingOfType("time.Time")).Return(6000, nil)
+	userRepo.On("UpdateMembershipLevel", 1, "Silver").Return(nil)
+	tierRepo.On("CreateHistory", mock.AnythingOfType("*domain.TierHistory")).Return(nil)
+
+	change, err := service.EvaluateUser(1, domain.TierChangeReasonLedger)
+	assert.NoError(t, err)
+	if assert.NotNil(t, change) {
+		assert.Equal(t, "Bronze", change.FromLevel)
+		assert.Equal(t, "Silver", change.ToLevel)
+		assert.Equal(t, 6000, change.EarnedInWindow)
+		assert.Equal(t, domain.TierChangeReasonLedger, change.Reason)
+	}
+	userRepo.AssertExpectations(t)
+	tierRepo.AssertExpectations(t)
+}
+
+func TestTierService_EvaluateUser_Unchanged(t *testing.T) {
+	service, userRepo, ledgerRepo, tierRepo := newTierServiceWithMocks()
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, MembershipLevel: "gold"}, nil)
+	ledgerRepo.On("GetEarnedSince", 1, mock.AnythingOfType("time.Time")).Return(20000, nil)
+
+	change, err := service.EvaluateUser(1, domain.TierChangeReasonScheduled)
+	assert.NoError(t, err)
+	assert.Nil(t, change)
+	userRepo.AssertNotCalled(t, "UpdateMembershipLevel", mock.Anything, mock.Anything)
+	tierRepo.AssertNotCalled(t, "CreateHistory", mock.Anything)
+}
+
+func TestTierService_EvaluateAll_CountsDowngrades(t *testing.T) {
+	service, userRepo, ledgerRepo, tierRepo := newTierServiceWithMocks()
+
+	userRepo.On("GetAll").Return([]domain.User{{ID: 1}, {ID: 2}}, nil)
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, MembershipLevel: "Gold"}, nil)
+	userRepo.On("GetByID", 2).Return(&domain.User{ID: 2, MembershipLevel: "Bronze"}, nil)
+	ledgerRepo.On("GetEarnedSince", 1, mock.AnythingOfType("time.Time")).Return(100, nil)
+	ledgerRepo.On("GetEarnedSince", 2, mock.AnythingOfType("time.Time")).Return(0, nil)
+	userRepo.On("UpdateMembershipLevel", 1, "Bronze").Return(nil)
+	tierRepo.On("CreateHistory", mock.AnythingOfType("*domain.TierHistory")).Return(nil)
+
+	run, err := service.EvaluateAll(domain.TierChangeReasonScheduled)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.TierEvaluationRun{UsersEvaluated: 2, Downgraded: 1}, run)
+}
+
+func TestTierService_EvaluateUser_KeepsLevelWithoutEarnHistory(t *testing.T) {
+	service, userRepo, ledgerRepo, tierRepo := newTierServiceWithMocks()
+
+	// A migrated member: opening balance only, nothing ever earned
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, MembershipLevel: "Gold", Points: 15420}, nil)
+	ledgerRepo.On("GetEarnedSince", 1, mock.AnythingOfType("time.Time")).Return(0, nil)
+
+	change, err := service.EvaluateUser(1, domain.TierChangeReasonScheduled)
+	assert.NoError(t, err)
+	assert.Nil(t, change)
+	ledgerRepo.AssertCalled(t, "GetEarnedSince", 1, time.Time{})
+	userRepo.AssertNotCalled(t, "UpdateMembershipLevel", mock.Anything, mock.Anything)
+	tierRepo.AssertNotCalled(t, "CreateHistory", mock.Anything)
+}
+
+func TestTierService_EvaluateUser_LedgerChangeNeverDowngrades(t *testing.T) {
+	service, userRepo, ledgerRepo, tierRepo := newTierServiceWithMocks()
+
+	// A legacy Gold member whose first earn is small
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, MembershipLevel: "Gold"}, nil)
+	ledgerRepo.On("GetEarnedSince", 1, mock.AnythingOfType("time.Time")).Return(50, nil)
+
+	change, err := service.EvaluateUser(1, domain.TierChangeReasonLedger)
+	assert.NoError(t, err)
+	assert.Nil(t, change)
+	userRepo.AssertNotCalled(t, "UpdateMembershipLevel", mock.Anything, mock.Anything)
+	tierRepo.AssertNotCalled(t, "CreateHistory", mock.Anything)
+}
+
+func TestTierService_EvaluateAll_ContinuesPastFailedUsers(t *testing.T) {
+	service, userRepo, ledgerRepo, tierRepo := newTierServiceWithMocks()
+
+	userRepo.On("GetAll").Return([]domain.User{{ID: 1}, {ID: 2}}, nil)
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, MembershipLevel: "Bronze"}, nil)
+	userRepo.On("GetByID", 2).Return(&domain.User{ID: 2, MembershipLevel: "Bronze"}, nil)
+	ledgerRepo.On("GetEarnedSince", 1, mock.AnythingOfType("time.Time")).Return(0, errors.New("corrupt row"))
+	ledgerRepo.On("GetEarnedSince", 2, mock.AnythingOfType("time.Time")).Return(6000, nil)
+	userRepo.On("UpdateMembershipLevel", 2, "Silver").Return(nil)
+	tierRepo.On("CreateHistory", mock.AnythingOfType("*domain.TierHistory")).Return(nil)
+
+	run, err := service.EvaluateAll(domain.TierChangeReasonScheduled)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.TierEvaluationRun{UsersEvaluated: 1, Upgraded: 1, UsersFailed: 1}, run)
+}
+
+func TestTierService_EvaluateUser_RepoError(t *testing.T) {
+	service, userRepo, _, _ := newTierServiceWithMocks()
+
+	dbErr := errors.New("database is locked")
+	userRepo.On("GetByID", 1).Return(nil, dbErr)
+
+	change, err := service.EvaluateUser(1, domain.TierChangeReasonScheduled)
+	assert.Nil(t, change)
+	assert.ErrorIs(t, err, dbErr)
+	assert.NotErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestTierService_GetTierStatus_UserNotFound(t *testing.T) {
+	service, userRepo, _, _ := newTierServiceWithMocks()
+
+	userRepo.On("GetByID", 99).Return(nil, nil)
+
+	progress, err := service.GetTierStatus(99)
+	assert.Nil(t, progress)
+	assert.Equal(t, ErrUserNotFound, err)
+}
@@ -1,53 +1,59 @@
 package service
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"fmt"
-	"time"
 
 	"workshop4-backend/internal/domain"
 	"workshop4-backend/internal/port"
-
-	"github.com/google/uuid"
 )
 
-var (
-	ErrInsufficientBalance = errors.New("insufficient balance")
-	ErrSelfTransfer        = errors.New("cannot transfer to yourself")
-	ErrUserNotFound        = errors.New("user not found")
-	ErrTransferNotFound    = errors.New("transfer not found")
-)
+// TransferManager is the transfer API consumed by handlers. It is implemented
+// by TransferService and by decorators such as StreamingTransferService.
+type TransferManager interface {
+	CreateTransfer(ctx context.Context, fromUserID, toUserID, amount int, note *string) (*domain.Transfer, error)
+	PreviewTransfer(ctx context.Context, fromUserID int, recipient domain.RecipientIdentifier, amount int, note *string) (*domain.TransferConfirmation, error)
+	ConfirmTransfer(ctx context.Context, fromUserID int, confirmationID string) (*domain.ConfirmedTransfer, error)
+	GetTransferByIdempotencyKey(key string) (*domain.Transfer, error)
+	GetTransfersByUserID(userID int, page, pageSize int) ([]domain.Transfer, int, error)
+}
 
 type TransferService struct {
 	transferRepo port.TransferRepository
 	ledgerRepo   port.PointLedgerRepository
 	userRepo     port.UserRepository
-	db           *sql.DB // For transaction support
+	tx           port.Transactor
+	clock        port.Clock
+	ids          port.IDGenerator
 }
 
 func NewTransferService(
 	transferRepo port.TransferRepository,
 	ledgerRepo port.PointLedgerRepository,
 	userRepo port.UserRepository,
-	db *sql.DB,
+	tx port.Transactor,
+	clock port.Clock,
+	ids port.IDGenerator,
 ) *TransferService {
 	return &TransferService{
 		transferRepo: transferRepo,
 		ledgerRepo:   ledgerRepo,
 		userRepo:     userRepo,
-		db:           db,
+		tx:           tx,
+		clock:        clock,
+		ids:          ids,
 	}
 }
 
-func (s *TransferService) CreateTransfer(fromUserID, toUserID, amount int, note *string) (*domain.Transfer, error) {
+func (s *TransferService) CreateTransfer(ctx context.Context, fromUserID, toUserID, amount int, note *string) (*domain.Transfer, error) {
 	// Validate input
 	if fromUserID == toUserID {
 		return nil, ErrSelfTransfer
 	}
 
 	if amount <= 0 {
-		return nil, errors.New("amount must be greater than 0")
+		return nil, ErrInvalidAmount
 	}
 
 	// Check if users exist
@@ -61,41 +67,170 @@ func (s *TransferService) CreateTransfer(fromUserID, toUserID, amount int, note
 		return nil, ErrUserNotFound
 	}
 
-	// Get current balance
-	currentBalance, err := s.ledgerRepo.GetUserBalance(fromUserID)
+	var transfer *domain.Transfer
+	err = s.tx.WithinTransaction(func(repos port.Repositories) error {
+		transfer, err = s.post(ctx, repos, fromUserID, toUserID, amount, note)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user balance: %w", err)
+		return nil, err
 	}
 
-	if currentBalance < amount {
+	return transfer, nil
+}
+
+// PreviewTransfer resolves the recipient by phone, email or member ID and
+// stores a short-lived confirmation. Only the recipient's masked name is
+// returned; the transfer is made by ConfirmTransfer.
+func (s *TransferService) PreviewTransfer(ctx context.Context, fromUserID int, recipient domain.RecipientIdentifier, amount int, note *string) (*domain.TransferConfirmation, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	normalized, err := recipient.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	fromUser, err := s.userRepo.GetByID(fromUserID)
+	if err != nil || fromUser == nil {
+		return nil, ErrUserNotFound
+	}
+
+	toUser, err := s.findRecipient(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find recipient: %w", err)
+	}
+	if toUser == nil {
+		return nil, ErrRecipientNotFound
+	}
+	if toUser.ID == fromUserID {
+		return nil, ErrSelfTransfer
+	}
+	for _, user := range []*domain.User{fromUser, toUser} {
+		if err := checkActive(user); err != nil {
+			return nil, err
+		}
+	}
+
+	balance, err := s.ledgerRepo.GetUserBalance(fromUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user balance: %w", err)
+	}
+	if balance < amount {
 		return nil, ErrInsufficientBalance
 	}
 
-	// Generate idempotency key
-	idemKey := uuid.New().String()
+	now := s.clock.Now()
+	confirmation := &domain.TransferConfirmation{
+		ID:            s.ids.NewID(),
+		FromUserID:    fromUserID,
+		ToUserID:      toUser.ID,
+		Recipient:     recipient,
+		RecipientName: domain.MaskName(toUser.Name),
+		Amount:        amount,
+		Note:          note,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(domain.TransferConfirmationTTL),
+	}
+	err = s.tx.WithinTransaction(func(repos port.Repositories) error {
+		return repos.Confirmations.Create(confirmation)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer confirmation: %w", err)
+	}
+	return confirmation, nil
+}
+
+// ConfirmTransfer makes the transfer previewed by confirmationID. A
+// confirmation can be used once, by the sender that created it, before it
+// expires. The recipient is returned by masked name only, as in the preview.
+func (s *TransferService) ConfirmTransfer(ctx context.Context, fromUserID int, confirmationID string) (*domain.ConfirmedTransfer, error) {
+	var confirmed *domain.ConfirmedTransfer
+	err := s.tx.WithinTransaction(func(repos port.Repositories) error {
+		confirmation, err := repos.Confirmations.GetByID(confirmationID)
+		if err != nil {
+			return fmt.Errorf("failed to get transfer confirmation: %w", err)
+		}
+		if confirmation == nil || confirmation.FromUserID != fromUserID {
+			return ErrConfirmationNotFound
+		}
 
-	// Start transaction
-	tx, err := s.db.Begin()
+		now := s.clock.Now()
+		if confirmation.Expired(now) {
+			return ErrConfirmationExpired
+		}
+		if err := repos.Confirmations.MarkConsumed(confirmation.ID, now); err != nil {
+			// A concurrent confirm consumed it after GetByID
+			if errors.Is(err, port.ErrConfirmationConsumed) {
+				return ErrConfirmationExpired
+			}
+			return fmt.Errorf("failed to consume transfer confirmation: %w", err)
+		}
+
+		transfer, err := s.post(ctx, repos, confirmation.FromUserID, confirmation.ToUserID, confirmation.Amount, confirmation.Note)
+		if err != nil {
+			return err
+		}
+		confirmed = domain.NewConfirmedTransfer(transfer, confirmation)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to start transaction: %w", err)
+		return nil, err
+	}
+
+	return confirmed, nil
+}
+
+func (s *TransferService) findRecipient(recipient domain.RecipientIdentifier) (*domain.User, error) {
+	switch recipient.Type {
+	case domain.RecipientTypePhone:
+		return s.userRepo.GetByPhone(recipient.Value)
+	case domain.RecipientTypeEmail:
+		return s.userRepo.GetByEmail(recipient.Value)
+	default:
+		return s.userRepo.GetByMemberID(recipient.Value)
+	}
+}
+
+// post moves amount from the sender to the recipient within repos' transaction:
+// the transfer record, both ledger rows, lots, the journal entry and the
+// audit entry.
+func (s *TransferService) post(ctx context.Context, repos port.Repositories, fromUserID, toUserID, amount int, note *string) (*domain.Transfer, error) {
+	// Check both accounts in ID order: stores that lock the rows they read
+	// then lock them in one order, so opposite transfers cannot deadlock
+	for _, userID := range []int{min(fromUserID, toUserID), max(fromUserID, toUserID)} {
+		if _, err := getActiveAccount(repos, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the balance inside the transaction so concurrent transfers
+	// cannot both spend it
+	currentBalance, err := repos.Ledger.GetUserBalance(fromUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user balance: %w", err)
+	}
+
+	if currentBalance < amount {
+		return nil, ErrInsufficientBalance
 	}
-	defer tx.Rollback()
 
 	// Create transfer record
-	now := time.Now()
+	now := s.clock.Now()
 	transfer := &domain.Transfer{
 		FromUserID:     fromUserID,
 		ToUserID:       toUserID,
 		Amount:         amount,
 		Status:         domain.TransferStatusCompleted, // For now, assume immediate completion
 		Note:           note,
-		IdempotencyKey: idemKey,
+		IdempotencyKey: s.ids.NewID(),
 		CreatedAt:      now,
 		UpdatedAt:      now,
 		CompletedAt:    &now,
 	}
 
-	if err := s.transferRepo.Create(transfer); err != nil {
+	if err := repos.Transfers.Create(transfer); err != nil {
 		return nil, fmt.Errorf("failed to create transfer: %w", err)
 	}
 
@@ -107,17 +242,21 @@ func (s *TransferService) CreateTransfer(fromUserID, toUserID, amount int, note
 		BalanceAfter: currentBalance - amount,
 		EventType:    domain.EventTypeTransferOut,
 		TransferID:   &transfer.ID,
-		Reference:    nil,
-		Metadata:     nil,
 		CreatedAt:    now,
 	}
 
-	if err := s.ledgerRepo.Create(debitEntry); err != nil {
-		return nil, fmt.Errorf("failed to create debit ledger entry: %w", err)
+	if err := postLedgerEntry(repos, debitEntry); err != nil {
+		return nil, err
+	}
+
+	// Spend the sender's oldest points first
+	consumed, err := consumeLots(repos, fromUserID, amount)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get recipient balance
-	recipientBalance, err := s.ledgerRepo.GetUserBalance(toUserID)
+	recipientBalance, err := repos.Ledger.GetUserBalance(toUserID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recipient balance: %w", err)
 	}
@@ -129,20 +268,45 @@ func (s *TransferService) CreateTransfer(fromUserID, toUserID, amount int, note
 		BalanceAfter: recipientBalance + amount,
 		EventType:    domain.EventTypeTransferIn,
 		TransferID:   &transfer.ID,
-		Reference:    nil,
-		Metadata:     nil,
 		CreatedAt:    now,
 	}
 
-	if err := s.ledgerRepo.Create(creditEntry); err != nil {
-		return nil, fmt.Errorf("failed to create credit ledger entry: %w", err)
+	if err := postLedgerEntry(repos, creditEntry); err != nil {
+		return nil, err
+	}
+
+	// Transferred points keep their original expiry
+	for _, c := range consumed {
+		if err := creditLot(repos, toUserID, c.Amount, &creditEntry.ID, now, c.ExpiresAt); err != nil {
+			return nil, err
+		}
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	// Move the points between the two member accounts
+	journalEntry := domain.NewJournalEntry(domain.JournalEntryTransfer,
+		domain.MemberAccount(fromUserID), domain.MemberAccount(toUserID), amount, now)
+	journalEntry.TransferID = &transfer.ID
+	if err := postJournalEntry(repos, journalEntry); err != nil {
+		return nil, err
 	}
 
+	err = recordEvent(repos, domain.EventTransferCompleted, domain.AuditEntityTransfer, transfer.ID, domain.TransferCompletedEvent{
+		TransferID:       transfer.ID,
+		FromUserID:       fromUserID,
+		ToUserID:         toUserID,
+		Amount:           amount,
+		Note:             note,
+		FromBalanceAfter: debitEntry.BalanceAfter,
+		ToBalanceAfter:   creditEntry.BalanceAfter,
+		CompletedAt:      now,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	err = recordAudit(ctx, repos, domain.AuditActionTransferCreate, domain.AuditEntityTransfer, transfer.IdempotencyKey, nil, transfer, now)
+	if err != nil {
+		return nil, err
+	}
 	return transfer, nil
 }
 
@@ -1,13 +1,19 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"workshop4-backend/internal/adapter/memory"
 	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
 )
 
 // Mock repositories for transfer service tests
@@ -33,7 +39,7 @@ func (m *MockTransferRepository) GetByUserID(userID int, page, pageSize int) ([]
 	return args.Get(0).([]domain.Transfer), args.Get(1).(int), args.Error(2)
 }
 
-func (m *MockTransferRepository) UpdateStatus(id int, status domain.TransferStatus, completedAt *string, failReason *string) error {
+func (m *MockTransferRepository) UpdateStatus(id int, status domain.TransferStatus, completedAt *time.Time, failReason *string) error {
 	args := m.Called(id, status, completedAt, failReason)
 	return args.Error(0)
 }
@@ -52,28 +58,175 @@ func (m *MockPointLedgerRepository) GetByUserID(userID int) ([]domain.PointLedge
 	return args.Get(0).([]domain.PointLedger), args.Error(1)
 }
 
+func (m *MockPointLedgerRepository) GetChain(userID int) ([]domain.PointLedger, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]domain.PointLedger), args.Error(1)
+}
+
+func (m *MockPointLedgerRepository) GetUserIDs() ([]int, error) {
+	args := m.Called()
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockPointLedgerRepository) GetEarnedSince(userID int, since time.Time) (int, error) {
+	args := m.Called(userID, since)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockPointLedgerRepository) GetUserBalance(userID int) (int, error) {
 	args := m.Called(userID)
 	return args.Get(0).(int), args.Error(1)
 }
 
+type MockJournalRepository struct {
+	mock.Mock
+}
+
+func (m *MockJournalRepository) Create(entry *domain.JournalEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockJournalRepository) GetByTransferID(transferID int) ([]domain.JournalEntry, error) {
+	args := m.Called(transferID)
+	return args.Get(0).([]domain.JournalEntry), args.Error(1)
+}
+
+func (m *MockJournalRepository) HasPostings(account string) (bool, error) {
+	args := m.Called(account)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockJournalRepository) GetAccountBalances() ([]domain.AccountBalance, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.AccountBalance), args.Error(1)
+}
+
+type MockLotRepository struct {
+	mock.Mock
+}
+
+func (m *MockLotRepository) Create(lot *domain.PointLot) error {
+	args := m.Called(lot)
+	return args.Error(0)
+}
+
+func (m *MockLotRepository) GetOpenByUserID(userID int) ([]domain.PointLot, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]domain.PointLot), args.Error(1)
+}
+
+func (m *MockLotRepository) GetExpired(asOf time.Time) ([]domain.PointLot, error) {
+	args := m.Called(asOf)
+	return args.Get(0).([]domain.PointLot), args.Error(1)
+}
+
+func (m *MockLotRepository) UpdateRemaining(id int, remaining int) error {
+	args := m.Called(id, remaining)
+	return args.Error(0)
+}
+
+type MockTransferConfirmationRepository struct {
+	mock.Mock
+}
+
+func (m *MockTransferConfirmationRepository) Create(confirmation *domain.TransferConfirmation) error {
+	args := m.Called(confirmation)
+	return args.Error(0)
+}
+
+func (m *MockTransferConfirmationRepository) GetByID(id string) (*domain.TransferConfirmation, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TransferConfirmation), args.Error(1)
+}
+
+func (m *MockTransferConfirmationRepository) MarkConsumed(id string, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) Create(event *domain.OutboxEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) GetByID(id int) (*domain.OutboxEvent, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepository) List(filter port.OutboxFilter) ([]domain.OutboxEvent, int, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]domain.OutboxEvent), args.Get(1).(int), args.Error(2)
+}
+
+func (m *MockOutboxRepository) ClaimDue(asOf, claimUntil time.Time, limit int) ([]domain.OutboxEvent, error) {
+	args := m.Called(asOf, claimUntil, limit)
+	return args.Get(0).([]domain.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkPublished(id int, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) MarkFailed(id int, lastError string, nextAttemptAt time.Time, publishedTo []string) error {
+	args := m.Called(id, lastError, nextAttemptAt, publishedTo)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) MarkDead(id int, lastError string, publishedTo []string) error {
+	args := m.Called(id, lastError, publishedTo)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) Requeue(id int, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+// eventNamed matches an outbox event by name.
+func eventNamed(name domain.EventName) interface{} {
+	return mock.MatchedBy(func(event *domain.OutboxEvent) bool { return event.Name == name })
+}
+
+// stubTransactor runs the callback directly against the mock repositories.
+type stubTransactor struct {
+	repos port.Repositories
+}
+
+func (s *stubTransactor) WithinTransaction(fn func(repos port.Repositories) error) error {
+	return fn(s.repos)
+}
+
 // Focus on validation logic tests only for unit tests
 // Integration tests with real database transactions would be separate
 func TestTransferService_CreateTransfer_ValidationTests(t *testing.T) {
 	transferRepo := new(MockTransferRepository)
 	ledgerRepo := new(MockPointLedgerRepository)
 	userRepo := new(MockUserRepository)
-	service := NewTransferService(transferRepo, ledgerRepo, userRepo, nil)
+	tx := &stubTransactor{repos: port.Repositories{Users: userRepo, Transfers: transferRepo, Ledger: ledgerRepo, Audit: newTestAuditRepository()}}
+	service := NewTransferService(transferRepo, ledgerRepo, userRepo, tx, testutil.NewClock(testNow), testutil.NewIDs())
 
 	t.Run("same user transfer", func(t *testing.T) {
-		result, err := service.CreateTransfer(1, 1, 500, nil)
+		result, err := service.CreateTransfer(context.Background(), 1, 1, 500, nil)
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Equal(t, ErrSelfTransfer, err)
 	})
 
 	t.Run("invalid amount", func(t *testing.T) {
-		result, err := service.CreateTransfer(1, 2, 0, nil)
+		result, err := service.CreateTransfer(context.Background(), 1, 2, 0, nil)
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "amount must be greater than 0")
@@ -81,7 +234,7 @@ func TestTransferService_CreateTransfer_ValidationTests(t *testing.T) {
 
 	t.Run("user not found", func(t *testing.T) {
 		userRepo.On("GetByID", 999).Return(nil, errors.New("user not found"))
-		result, err := service.CreateTransfer(999, 2, 500, nil)
+		result, err := service.CreateTransfer(context.Background(), 999, 2, 500, nil)
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Equal(t, ErrUserNotFound, err)
@@ -94,9 +247,318 @@ func TestTransferService_CreateTransfer_ValidationTests(t *testing.T) {
 		userRepo.On("GetByID", 2).Return(toUser, nil)
 		ledgerRepo.On("GetUserBalance", 1).Return(100, nil)
 
-		result, err := service.CreateTransfer(1, 2, 500, nil)
+		result, err := service.CreateTransfer(context.Background(), 1, 2, 500, nil)
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Equal(t, ErrInsufficientBalance, err)
 	})
 }
+
+func TestTransferService_CreateTransfer_PostsBalancedEntries(t *testing.T) {
+	transferRepo := new(MockTransferRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	userRepo := new(MockUserRepository)
+	journalRepo := new(MockJournalRepository)
+	lotRepo := new(MockLotRepository)
+	outboxRepo := new(MockOutboxRepository)
+	tx := &stubTransactor{repos: port.Repositories{
+		Users: userRepo, Transfers: transferRepo, Ledger: ledgerRepo, Journal: journalRepo, Lots: lotRepo, Outbox: outboxRepo,
+		Audit: newTestAuditRepository(),
+	}}
+	service := NewTransferService(transferRepo, ledgerRepo, userRepo, tx, testutil.NewClock(testNow), testutil.NewIDs())
+
+	soon := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	later := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	userRepo.On("GetByID", 2).Return(&domain.User{ID: 2}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(1000, nil)
+	ledgerRepo.On("GetUserBalance", 2).Return(200, nil)
+	transferRepo.On("Create", mock.AnythingOfType("*domain.Transfer")).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.Transfer).ID = 42
+	})
+	ledgerRepo.On("Create", mock.AnythingOfType("*domain.PointLedger")).Return(nil)
+	userRepo.On("UpdatePoints", 1, 700).Return(nil)
+	userRepo.On("UpdatePoints", 2, 500).Return(nil)
+	journalRepo.On("Create", mock.AnythingOfType("*domain.JournalEntry")).Return(nil)
+	lotRepo.On("GetOpenByUserID", 1).Return([]domain.PointLot{
+		{ID: 10, UserID: 1, Amount: 200, Remaining: 200, ExpiresAt: soon},
+		{ID: 11, UserID: 1, Amount: 800, Remaining: 800, ExpiresAt: later},
+	}, nil)
+	lotRepo.On("UpdateRemaining", 10, 0).Return(nil)
+	lotRepo.On("UpdateRemaining", 11, 700).Return(nil)
+	lotRepo.On("Create", mock.AnythingOfType("*domain.PointLot")).Return(nil)
+	outboxRepo.On("Create", eventNamed(domain.EventTransferCompleted)).Return(nil)
+
+	transfer, err := service.CreateTransfer(context.Background(), 1, 2, 300, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, transfer.ID)
+	userRepo.AssertExpectations(t)
+	lotRepo.AssertExpectations(t)
+
+	// The recipient's lots keep the sender's expiry dates
+	var received []domain.PointLot
+	for _, call := range lotRepo.Calls {
+		if call.Method == "Create" {
+			received = append(received, *call.Arguments.Get(0).(*domain.PointLot))
+		}
+	}
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, 2, received[0].UserID)
+		assert.Equal(t, 200, received[0].Amount)
+		assert.Equal(t, soon, received[0].ExpiresAt)
+		assert.Equal(t, 100, received[1].Amount)
+		assert.Equal(t, later, received[1].ExpiresAt)
+	}
+
+	entry := journalRepo.Calls[0].Arguments.Get(0).(*domain.JournalEntry)
+	assert.NoError(t, entry.Validate())
+	assert.Equal(t, domain.JournalEntryTransfer, entry.EntryType)
+	assert.Equal(t, 42, *entry.TransferID)
+	assert.Equal(t, []domain.Posting{
+		{Account: "member:1", Amount: -300},
+		{Account: "member:2", Amount: 300},
+	}, entry.Postings)
+
+	// The event is written in the same transaction as the transfer
+	event := outboxRepo.Calls[0].Arguments.Get(0).(*domain.OutboxEvent)
+	assert.Equal(t, domain.AuditEntityTransfer, event.AggregateType)
+	assert.Equal(t, "42", event.AggregateID)
+	assert.Equal(t, testNow, event.NextAttemptAt)
+}
+
+func TestTransferService_PreviewTransfer_ByPhone(t *testing.T) {
+	ledgerRepo := new(MockPointLedgerRepository)
+	userRepo := new(MockUserRepository)
+	confirmRepo := new(MockTransferConfirmationRepository)
+	tx := &stubTransactor{repos: port.Repositories{Confirmations: confirmRepo}}
+	service := NewTransferService(new(MockTransferRepository), ledgerRepo, userRepo, tx, testutil.NewClock(testNow), testutil.NewIDs())
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	userRepo.On("GetByPhone", "+66815678901").Return(&domain.User{ID: 2, Name: "สมหญิง ดีใจ"}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(1000, nil)
+	confirmRepo.On("Create", mock.AnythingOfType("*domain.TransferConfirmation")).Return(nil)
+
+	recipient := domain.RecipientIdentifier{Type: domain.RecipientTypePhone, Value: "+66 81-567-8901"}
+	confirmation, err := service.PreviewTransfer(context.Background(), 1, recipient, 300, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, confirmation.ID)
+	assert.Equal(t, 2, confirmation.ToUserID)
+	assert.Equal(t, "สม*** ดี***", confirmation.RecipientName)
+	assert.Equal(t, domain.TransferConfirmationTTL, confirmation.ExpiresAt.Sub(confirmation.CreatedAt))
+	confirmRepo.AssertExpectations(t)
+}
+
+func TestTransferService_PreviewTransfer_Errors(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := NewTransferService(new(MockTransferRepository), new(MockPointLedgerRepository), userRepo, &stubTransactor{}, testutil.NewClock(testNow), testutil.NewIDs())
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	userRepo.On("GetByEmail", "nobody@example.com").Return(nil, nil)
+	userRepo.On("GetByMemberID", "LBK001234").Return(&domain.User{ID: 1}, nil)
+
+	_, err := service.PreviewTransfer(context.Background(), 1, domain.RecipientIdentifier{Type: "nickname", Value: "x"}, 100, nil)
+	var verr *domain.ValidationError
+	assert.ErrorAs(t, err, &verr)
+
+	_, err = service.PreviewTransfer(context.Background(), 1, domain.RecipientIdentifier{Type: domain.RecipientTypeEmail, Value: " Nobody@Example.com"}, 100, nil)
+	assert.Equal(t, ErrRecipientNotFound, err)
+
+	_, err = service.PreviewTransfer(context.Background(), 1, domain.RecipientIdentifier{Type: domain.RecipientTypeMemberID, Value: "lbk001234"}, 100, nil)
+	assert.Equal(t, ErrSelfTransfer, err)
+}
+
+func TestTransferService_ConfirmTransfer_Rejects(t *testing.T) {
+	confirmRepo := new(MockTransferConfirmationRepository)
+	tx := &stubTransactor{repos: port.Repositories{Confirmations: confirmRepo}}
+	service := NewTransferService(new(MockTransferRepository), new(MockPointLedgerRepository), new(MockUserRepository), tx, testutil.NewClock(testNow), testutil.NewIDs())
+
+	now := testNow
+	consumed := now.Add(-time.Minute)
+	confirmRepo.On("GetByID", "missing").Return(nil, nil)
+	confirmRepo.On("GetByID", "other-sender").Return(&domain.TransferConfirmation{ID: "other-sender", FromUserID: 9, ExpiresAt: now.Add(time.Minute)}, nil)
+	confirmRepo.On("GetByID", "expired").Return(&domain.TransferConfirmation{ID: "expired", FromUserID: 1, ExpiresAt: now.Add(-time.Second)}, nil)
+	confirmRepo.On("GetByID", "used").Return(&domain.TransferConfirmation{ID: "used", FromUserID: 1, ExpiresAt: now.Add(time.Minute), ConsumedAt: &consumed}, nil)
+
+	tests := []struct {
+		id   string
+		want error
+	}{
+		{"missing", ErrConfirmationNotFound},
+		{"other-sender", ErrConfirmationNotFound},
+		{"expired", ErrConfirmationExpired},
+		{"used", ErrConfirmationExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			transfer, err := service.ConfirmTransfer(context.Background(), 1, tt.id)
+			assert.Nil(t, transfer)
+			assert.Equal(t, tt.want, err)
+		})
+	}
+	confirmRepo.AssertNotCalled(t, "MarkConsumed", mock.Anything, mock.Anything)
+}
+
+func TestTransferService_ConfirmTransfer_ConsumedConcurrently(t *testing.T) {
+	confirmRepo := new(MockTransferConfirmationRepository)
+	tx := &stubTransactor{repos: port.Repositories{Confirmations: confirmRepo}}
+	service := NewTransferService(new(MockTransferRepository), new(MockPointLedgerRepository), new(MockUserRepository), tx, testutil.NewClock(testNow), testutil.NewIDs())
+
+	// Another confirm consumed it between GetByID and MarkConsumed
+	confirmRepo.On("GetByID", "c-1").Return(&domain.TransferConfirmation{ID: "c-1", FromUserID: 1, ExpiresAt: testNow.Add(time.Minute)}, nil)
+	confirmRepo.On("MarkConsumed", "c-1", testNow).Return(port.ErrConfirmationConsumed)
+
+	transfer, err := service.ConfirmTransfer(context.Background(), 1, "c-1")
+	assert.Nil(t, transfer)
+	assert.Equal(t, ErrConfirmationExpired, err)
+}
+
+func TestTransferService_CreateTransfer_ClosedRecipient(t *testing.T) {
+	transferRepo := new(MockTransferRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	userRepo := new(MockUserRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: userRepo, Transfers: transferRepo, Ledger: ledgerRepo, Audit: newTestAuditRepository()}}
+	service := NewTransferService(transferRepo, ledgerRepo, userRepo, tx, testutil.NewClock(testNow), testutil.NewIDs())
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusActive}, nil)
+	userRepo.On("GetByID", 2).Return(&domain.User{ID: 2, Status: domain.AccountStatusClosed}, nil)
+
+	_, err := service.CreateTransfer(context.Background(), 1, 2, 100, nil)
+	assert.Equal(t, ErrAccountClosed, err)
+	transferRepo.AssertNotCalled(t, "Create", mock.Anything)
+	ledgerRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestTransferService_CreateTransfer_FrozenSender(t *testing.T) {
+	transferRepo := new(MockTransferRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	userRepo := new(MockUserRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: userRepo, Transfers: transferRepo, Ledger: ledgerRepo, Audit: newTestAuditRepository()}}
+	service := NewTransferService(transferRepo, ledgerRepo, userRepo, tx, testutil.NewClock(testNow), testutil.NewIDs())
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusSuspended}, nil)
+	userRepo.On("GetByID", 2).Return(&domain.User{ID: 2, Status: domain.AccountStatusActive}, nil)
+
+	_, err := service.CreateTransfer(context.Background(), 1, 2, 100, nil)
+	assert.Equal(t, ErrAccountFrozen, err)
+	assert.Equal(t, KindForbidden, KindOf(err))
+	transferRepo.AssertNotCalled(t, "Create", mock.Anything)
+	ledgerRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+// previewRepos wires mocks that store a preview's confirmation and hand it
+// back by ID, so a test can preview and then confirm.
+func previewRepos(t *testing.T) (*MockUserRepository, *MockPointLedgerRepository, port.Repositories) {
+	t.Helper()
+	ledgerRepo := new(MockPointLedgerRepository)
+	userRepo := new(MockUserRepository)
+	confirmRepo := new(MockTransferConfirmationRepository)
+
+	saved := &domain.TransferConfirmation{}
+	confirmRepo.On("Create", mock.AnythingOfType("*domain.TransferConfirmation")).Return(nil).Run(func(args mock.Arguments) {
+		*saved = *args.Get(0).(*domain.TransferConfirmation)
+	})
+	confirmRepo.On("GetByID", "00000000-0000-0000-0000-000000000001").Return(saved, nil)
+	confirmRepo.On("MarkConsumed", mock.Anything, mock.Anything).Return(nil)
+
+	userRepo.On("GetByID", 1).Return(&domain.User{ID: 1, Name: "สมชาย ใจดี"}, nil)
+	userRepo.On("GetByID", 2).Return(&domain.User{ID: 2, Name: "สมหญิง ดีใจ"}, nil)
+	userRepo.On("GetByMemberID", "LBK001235").Return(&domain.User{ID: 2, Name: "สมหญิง ดีใจ"}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(1000, nil)
+	ledgerRepo.On("GetUserBalance", 2).Return(200, nil)
+
+	return userRepo, ledgerRepo, port.Repositories{Users: userRepo, Ledger: ledgerRepo, Confirmations: confirmRepo, Audit: newTestAuditRepository()}
+}
+
+// Responses are compared with testdata/*.golden.json; run
+// go test ./internal/service -run Golden -update after an intended change.
+func TestTransferService_PreviewAndConfirm_Golden(t *testing.T) {
+	userRepo, ledgerRepo, repos := previewRepos(t)
+	transferRepo := new(MockTransferRepository)
+	journalRepo := new(MockJournalRepository)
+	lotRepo := new(MockLotRepository)
+	outboxRepo := new(MockOutboxRepository)
+	repos.Transfers, repos.Journal, repos.Lots, repos.Outbox = transferRepo, journalRepo, lotRepo, outboxRepo
+
+	transferRepo.On("Create", mock.AnythingOfType("*domain.Transfer")).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.Transfer).ID = 7
+	})
+	ledgerRepo.On("Create", mock.AnythingOfType("*domain.PointLedger")).Return(nil)
+	userRepo.On("UpdatePoints", 1, 700).Return(nil)
+	userRepo.On("UpdatePoints", 2, 500).Return(nil)
+	journalRepo.On("Create", mock.AnythingOfType("*domain.JournalEntry")).Return(nil)
+	lotRepo.On("GetOpenByUserID", 1).Return([]domain.PointLot{
+		{ID: 10, UserID: 1, Amount: 1000, Remaining: 1000, ExpiresAt: testNow.AddDate(1, 0, 0)},
+	}, nil)
+	lotRepo.On("UpdateRemaining", 10, 700).Return(nil)
+	lotRepo.On("Create", mock.AnythingOfType("*domain.PointLot")).Return(nil)
+	outboxRepo.On("Create", eventNamed(domain.EventTransferCompleted)).Return(nil)
+
+	clock := testutil.NewClock(testNow)
+	service := NewTransferService(transferRepo, ledgerRepo, userRepo, &stubTransactor{repos: repos}, clock, testutil.NewIDs())
+
+	note := "ค่าข้าว"
+	recipient := domain.RecipientIdentifier{Type: domain.RecipientTypeMemberID, Value: "LBK001235"}
+	confirmation, err := service.PreviewTransfer(context.Background(), 1, recipient, 300, &note)
+	require.NoError(t, err)
+	testutil.AssertGoldenJSON(t, "transfer_preview.golden.json", confirmation)
+
+	clock.Advance(30 * time.Second)
+	transfer, err := service.ConfirmTransfer(context.Background(), 1, confirmation.ID)
+	require.NoError(t, err)
+	testutil.AssertGoldenJSON(t, "transfer_confirm.golden.json", transfer)
+	testutil.AssertGoldenJSON(t, "transfer_completed_event.golden.json", outboxRepo.Calls[0].Arguments.Get(0))
+}
+
+func TestTransferService_ConfirmTransfer_ExpiresWithClock(t *testing.T) {
+	userRepo, ledgerRepo, repos := previewRepos(t)
+	clock := testutil.NewClock(testNow)
+	service := NewTransferService(new(MockTransferRepository), ledgerRepo, userRepo, &stubTransactor{repos: repos}, clock, testutil.NewIDs())
+
+	recipient := domain.RecipientIdentifier{Type: domain.RecipientTypeMemberID, Value: "LBK001235"}
+	confirmation, err := service.PreviewTransfer(context.Background(), 1, recipient, 300, nil)
+	require.NoError(t, err)
+
+	clock.Advance(domain.TransferConfirmationTTL)
+	transfer, err := service.ConfirmTransfer(context.Background(), 1, confirmation.ID)
+	assert.Nil(t, transfer)
+	assert.Equal(t, ErrConfirmationExpired, err)
+}
+
+// TestTransferService_MemoryAdapters runs a transfer end to end against the
+// in-memory repositories instead of mocks.
+func TestTransferService_MemoryAdapters(t *testing.T) {
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	ledgerRepo := memory.NewPointLedgerRepository(store)
+	tx := memory.NewTransactor(store)
+	clock := testutil.NewClock(testNow)
+
+	users := NewUserService(userRepo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, clock)
+	sender := &domain.User{Name: "สมชาย ใจดี", Phone: "0812345678", Email: "somchai@example.com", Points: 1000}
+	recipient := &domain.User{Name: "สมหญิง ดีใจ", Phone: "0815678901", Email: "somying@example.com"}
+	require.NoError(t, users.CreateUser(context.Background(), sender))
+	require.NoError(t, users.CreateUser(context.Background(), recipient))
+
+	transfers := NewTransferService(memory.NewTransferRepository(store), ledgerRepo, userRepo, tx, clock, testutil.NewIDs())
+	transfer, err := transfers.CreateTransfer(context.Background(), sender.ID, recipient.ID, 300, nil)
+	require.NoError(t, err)
+
+	got, err := transfers.GetTransferByIdempotencyKey(transfer.IdempotencyKey)
+	require.NoError(t, err)
+	assert.Equal(t, transfer, got)
+
+	for userID, want := range map[int]int{sender.ID: 700, recipient.ID: 300} {
+		balance, err := userRepo.GetUserBalance(userID)
+		require.NoError(t, err)
+		assert.Equal(t, want, balance)
+	}
+
+	ledger := NewLedgerService(ledgerRepo, memory.NewJournalRepository(store))
+	verification, err := ledger.VerifyChain(nil)
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+	trialBalance, err := ledger.TrialBalance()
+	require.NoError(t, err)
+	assert.True(t, trialBalance.Balanced)
+}
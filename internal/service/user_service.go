@@ -1,7 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,53 +12,377 @@ import (
 	"workshop4-backend/internal/port"
 )
 
+// UserManager is the user API consumed by handlers. It is implemented by
+// UserService.
+type UserManager interface {
+	ListUsers(filter port.UserFilter) ([]domain.User, int, error)
+	SearchUsers(query string, limit int) ([]domain.User, error)
+	GetUserByID(id int) (*domain.User, error)
+	GetUserByMemberID(memberID string) (*domain.User, error)
+	CreateUser(ctx context.Context, user *domain.User) error
+	UpdateUser(ctx context.Context, user *domain.User) error
+	PatchUser(ctx context.Context, id, version int, patch domain.UserPatch) (*domain.User, error)
+	CloseUser(ctx context.Context, id, version int, forfeit bool) (*domain.User, error)
+}
+
 type UserService struct {
-	repo port.UserRepository
+	repo      port.UserRepository
+	tx        port.Transactor
+	expiry    domain.ExpiryPolicy
+	tiers     domain.TierPolicy
+	memberIDs domain.MemberIDFormat
+	clock     port.Clock
 }
 
-func NewUserService(repo port.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo port.UserRepository, tx port.Transactor, expiry domain.ExpiryPolicy, tiers domain.TierPolicy, memberIDs domain.MemberIDFormat, clock port.Clock) *UserService {
+	return &UserService{repo: repo, tx: tx, expiry: expiry, tiers: tiers, memberIDs: memberIDs, clock: clock}
 }
 
-func (s *UserService) GetAllUsers() ([]domain.User, error) {
-	return s.repo.GetAll()
+// ListUsers returns one page of the open accounts matching filter and the
+// total number of matches.
+func (s *UserService) ListUsers(filter port.UserFilter) ([]domain.User, int, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 || filter.PageSize > 200 {
+		filter.PageSize = 20
+	}
+
+	verr := &domain.ValidationError{}
+	if filter.MinPoints != nil && filter.MaxPoints != nil && *filter.MinPoints > *filter.MaxPoints {
+		verr.Add("minPoints", domain.FieldCodeInvalid, "must not exceed maxPoints")
+	}
+	if filter.MemberSinceFrom != nil && filter.MemberSinceTo != nil && filter.MemberSinceFrom.After(*filter.MemberSinceTo) {
+		verr.Add("memberSinceFrom", domain.FieldCodeInvalid, "must not be after memberSinceTo")
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, 0, err
+	}
+
+	users, total, err := s.repo.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// SearchUsers finds open accounts by fragments of their name, email, phone
+// or member ID. Every whitespace-separated term must match; phone fragments
+// may be typed in local form, e.g. "081-234".
+func (s *UserService) SearchUsers(query string, limit int) ([]domain.User, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		verr := &domain.ValidationError{}
+		verr.Add("q", domain.FieldCodeRequired, "is required")
+		return nil, verr
+	}
+	for i, term := range terms {
+		if fragment, ok := domain.PhoneSearchTerm(term); ok {
+			terms[i] = fragment
+		}
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	users, err := s.repo.Search(terms, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	return users, nil
 }
 
 func (s *UserService) GetUserByID(id int) (*domain.User, error) {
-	return s.repo.GetByID(id)
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
 }
 
-func (s *UserService) CreateUser(user *domain.User) error {
+// GetUserByMemberID looks a user up by member ID as typed by staff, ignoring
+// case, spaces and dashes.
+func (s *UserService) GetUserByMemberID(memberID string) (*domain.User, error) {
+	memberID = domain.NormalizeMemberID(memberID)
+	if !s.memberIDs.CheckDigitValid(memberID) {
+		return nil, ErrInvalidMemberID
+	}
+	user, err := s.repo.GetByMemberID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// CreateUser registers a new, active member in the tier policy's starting
+// tier and allocates their member ID. The HTTP API never sets user.Points;
+// points given by other callers are booked as an opening balance.
+func (s *UserService) CreateUser(ctx context.Context, user *domain.User) error {
 	// Validate user input
-	if err := s.validateUser(user); err != nil {
+	user.Normalize()
+	if err := user.Validate(); err != nil {
 		return err
 	}
 
+	// New accounts always start open, whatever the caller passed
+	user.ID, user.Version = 0, 0
+	user.Status, user.DeletedAt = domain.AccountStatusActive, nil
+	// Tiers are managed by the tier engine; nothing has been earned yet
+	user.MembershipLevel = s.tiers.StartingLevel()
+
 	// Set timestamps
-	now := time.Now()
+	now := s.clock.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
+	if user.MemberSince == "" {
+		user.MemberSince = now.Format(domain.DateLayout)
+	}
 
-	return s.repo.Create(user)
+	return s.tx.WithinTransaction(func(repos port.Repositories) error {
+		// Member IDs are always allocated by the server
+		seq, err := repos.Sequences.Next(domain.SequenceMemberID)
+		if err != nil {
+			return fmt.Errorf("failed to allocate member ID: %w", err)
+		}
+		user.MemberID = s.memberIDs.Generate(seq)
+
+		if err := checkContactsAvailable(repos.Users, user); err != nil {
+			return err
+		}
+		if err := repos.Users.Create(user); err != nil {
+			return contactTaken(err)
+		}
+		err = recordEvent(repos, domain.EventUserCreated, domain.AuditEntityUser, user.ID, domain.UserCreatedEvent{
+			UserID:          user.ID,
+			MemberID:        user.MemberID,
+			MembershipLevel: user.MembershipLevel,
+			Points:          user.Points,
+		}, now)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, repos, domain.AuditActionUserCreate, domain.AuditEntityUser, strconv.Itoa(user.ID), nil, user, now)
+		if err != nil {
+			return err
+		}
+		if user.Points == 0 {
+			return nil
+		}
+
+		// Starting points are issued by the system, so book them in the
+		// journal together with the new user
+		if err := creditLot(repos, user.ID, user.Points, nil, now, s.expiry.ExpiresAt(now)); err != nil {
+			return err
+		}
+		return postJournalEntry(repos, domain.NewJournalEntry(domain.JournalEntryOpening,
+			domain.AccountIssuance, domain.MemberAccount(user.ID), user.Points, now))
+	})
 }
 
-func (s *UserService) validateUser(user *domain.User) error {
-	if strings.TrimSpace(user.Name) == "" {
-		return errors.New("validation error: name is required")
+// checkContactsAvailable rejects phone numbers and email addresses already
+// registered to another user. The store's unique indexes catch a concurrent
+// request that claims the same contact; see contactTaken.
+func checkContactsAvailable(users port.UserRepository, user *domain.User) error {
+	verr := &domain.ValidationError{}
+
+	existing, err := users.GetByPhone(user.Phone)
+	if err != nil {
+		return fmt.Errorf("failed to check phone: %w", err)
+	}
+	if existing != nil && existing.ID != user.ID {
+		verr.Add("phone", domain.FieldCodeTaken, "is already registered")
 	}
-	if strings.TrimSpace(user.Email) == "" {
-		return errors.New("validation error: email is required")
+
+	existing, err = users.GetByEmail(user.Email)
+	if err != nil {
+		return fmt.Errorf("failed to check email: %w", err)
 	}
-	if strings.TrimSpace(user.Phone) == "" {
-		return errors.New("validation error: phone is required")
+	if existing != nil && existing.ID != user.ID {
+		verr.Add("email", domain.FieldCodeTaken, "is already registered")
+	}
+
+	return verr.OrNil()
+}
+
+// contactTaken reports a contact the store refused as taken, the same way
+// checkContactsAvailable does.
+func contactTaken(err error) error {
+	verr := &domain.ValidationError{}
+	switch {
+	case errors.Is(err, port.ErrPhoneTaken):
+		verr.Add("phone", domain.FieldCodeTaken, "is already registered")
+	case errors.Is(err, port.ErrEmailTaken):
+		verr.Add("email", domain.FieldCodeTaken, "is already registered")
+	default:
+		return err
+	}
+	return verr
+}
+
+// UpdateUser replaces the user's name, phone and email. Points, tier and
+// member ID are managed by the server and are kept; user is refreshed with
+// the stored record. user.Version must match the stored version unless it
+// is zero.
+func (s *UserService) UpdateUser(ctx context.Context, user *domain.User) error {
+	return s.tx.WithinTransaction(func(repos port.Repositories) error {
+		existing, err := getForWrite(repos, user.ID, user.Version)
+		if err != nil {
+			return err
+		}
+
+		now := s.clock.Now()
+		updated := *existing
+		updated.Name, updated.Phone, updated.Email = user.Name, user.Phone, user.Email
+		if err := saveProfile(repos, &updated, now); err != nil {
+			return err
+		}
+		err = recordAudit(ctx, repos, domain.AuditActionUserUpdate, domain.AuditEntityUser, strconv.Itoa(updated.ID), existing, &updated, now)
+		if err != nil {
+			return err
+		}
+		*user = updated
+		return nil
+	})
+}
+
+// PatchUser applies a JSON Merge Patch to the user's profile and returns the
+// updated user. The merged result is validated as a whole.
+func (s *UserService) PatchUser(ctx context.Context, id, version int, patch domain.UserPatch) (*domain.User, error) {
+	var updated *domain.User
+	err := s.tx.WithinTransaction(func(repos port.Repositories) error {
+		existing, err := getForWrite(repos, id, version)
+		if err != nil {
+			return err
+		}
+
+		now := s.clock.Now()
+		user := *existing
+		if err := patch.Apply(&user); err != nil {
+			return err
+		}
+		if err := saveProfile(repos, &user, now); err != nil {
+			return err
+		}
+		updated = &user
+		return recordAudit(ctx, repos, domain.AuditActionUserUpdate, domain.AuditEntityUser, strconv.Itoa(id), existing, updated, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// saveProfile normalizes and validates user and stores it within repos'
+// transaction, stamped as updated at now.
+func saveProfile(repos port.Repositories, user *domain.User, now time.Time) error {
+	user.Normalize()
+	if err := user.Validate(); err != nil {
+		return err
+	}
+	if err := checkContactsAvailable(repos.Users, user); err != nil {
+		return err
+	}
+	user.UpdatedAt = now
+	if err := repos.Users.Update(user); err != nil {
+		if errors.Is(err, port.ErrVersionConflict) {
+			return ErrVersionMismatch
+		}
+		return contactTaken(err)
 	}
 	return nil
 }
 
-func (s *UserService) UpdateUser(user *domain.User) error {
-	return s.repo.Update(user)
+// getForWrite loads the user to be changed and checks that the caller saw
+// its current version and that the account is not closed. A zero version
+// matches any version.
+func getForWrite(repos port.Repositories, id, version int) (*domain.User, error) {
+	existing, err := repos.Users.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrUserNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return nil, ErrVersionMismatch
+	}
+	if existing.Closed() {
+		return nil, ErrAccountClosed
+	}
+	return existing, nil
+}
+
+// CloseUser closes the account if version matches the stored version or is
+// zero. An account holding points is only closed when forfeit is set, in
+// which case the balance is written off to system:breakage. The user row is
+// kept for the account's transfer and ledger history.
+func (s *UserService) CloseUser(ctx context.Context, id, version int, forfeit bool) (*domain.User, error) {
+	var closed *domain.User
+	now := s.clock.Now()
+	err := s.tx.WithinTransaction(func(repos port.Repositories) error {
+		user, err := getForWrite(repos, id, version)
+		if err != nil {
+			return err
+		}
+
+		balance, err := repos.Ledger.GetUserBalance(id)
+		if err != nil {
+			return fmt.Errorf("failed to get user balance: %w", err)
+		}
+		if balance > 0 {
+			if !forfeit {
+				return ErrBalanceNotZero
+			}
+			if err := forfeitBalance(repos, id, balance, now); err != nil {
+				return err
+			}
+		}
+
+		if err := changeStatus(ctx, repos, user, domain.AccountStatusClosed, "account closed", now); err != nil {
+			return err
+		}
+		if closed, err = repos.Users.GetByID(id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos, domain.AuditActionUserClose, domain.AuditEntityUser, strconv.Itoa(id), user, closed, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return closed, nil
 }
 
-func (s *UserService) DeleteUser(id int) error {
-	return s.repo.Delete(id)
+// forfeitBalance writes off the member's whole balance with a forfeit
+// ledger row, empties their lots and moves the points to system:breakage.
+func forfeitBalance(repos port.Repositories, userID, balance int, now time.Time) error {
+	entry := &domain.PointLedger{
+		UserID:       userID,
+		Change:       -balance,
+		BalanceAfter: 0,
+		EventType:    domain.EventTypeForfeit,
+		CreatedAt:    now,
+	}
+	if err := postLedgerEntry(repos, entry); err != nil {
+		return err
+	}
+	if _, err := consumeLots(repos, userID, balance); err != nil {
+		return err
+	}
+	err := postJournalEntry(repos, domain.NewJournalEntry(domain.JournalEntryForfeit,
+		domain.MemberAccount(userID), domain.AccountBreakage, balance, now))
+	if err != nil {
+		return err
+	}
+	return recordEvent(repos, domain.EventPointsForfeited, domain.AuditEntityUser, userID, domain.PointsChangedEvent{
+		UserID:        userID,
+		LedgerEntryID: entry.ID,
+		Change:        entry.Change,
+		BalanceAfter:  entry.BalanceAfter,
+	}, now)
 }
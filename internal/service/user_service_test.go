@@ -1,13 +1,18 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
 )
 
 type MockUserRepository struct {
@@ -19,6 +24,16 @@ func (m *MockUserRepository) GetAll() ([]domain.User, error) {
 	return args.Get(0).([]domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) List(filter port.UserFilter) ([]domain.User, int, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]domain.User), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserRepository) Search(terms []string, limit int) ([]domain.User, error) {
+	args := m.Called(terms, limit)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
 func (m *MockUserRepository) GetByID(id int) (*domain.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -27,6 +42,30 @@ func (m *MockUserRepository) GetByID(id int) (*domain.User, error) {
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByMemberID(memberID string) (*domain.User, error) {
+	args := m.Called(memberID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByPhone(phone string) (*domain.User, error) {
+	args := m.Called(phone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(email string) (*domain.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
 func (m *MockUserRepository) Create(user *domain.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -37,22 +76,66 @@ func (m *MockUserRepository) Update(user *domain.User) error {
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Delete(id int) error {
-	args := m.Called(id)
+func (m *MockUserRepository) UpdateStatus(id int, status domain.AccountStatus) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePoints(userID int, newBalance int) error {
+	args := m.Called(userID, newBalance)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateMembershipLevel(userID int, level string) error {
+	args := m.Called(userID, level)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) GetUserBalance(userID int) (int, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int), args.Error(1)
+}
+
+// stubSequence hands out consecutive numbers starting at 1.
+type stubSequence struct {
+	value int
+}
+
+func (s *stubSequence) Next(name string) (int, error) {
+	s.value++
+	return s.value, nil
+}
+
+var testMemberIDs = domain.MemberIDFormat{Prefix: "LBK", Digits: 6}
+
+var testTiers = domain.NewTierPolicy(12, []domain.TierRule{{Name: "Bronze", MinPoints: 0}, {Name: "Silver", MinPoints: 5000}})
+
+// testNow is the time service tests run at.
+var testNow = time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+// newTestUserService returns a UserService whose phone and email uniqueness
+// checks find no existing users and whose events are accepted unchecked.
+func newTestUserService(repo *MockUserRepository) *UserService {
+	repo.On("GetByPhone", mock.Anything).Return(nil, nil).Maybe()
+	repo.On("GetByEmail", mock.Anything).Return(nil, nil).Maybe()
+	outboxRepo := new(MockOutboxRepository)
+	outboxRepo.On("Create", mock.Anything).Return(nil).Maybe()
+	tx := &stubTransactor{repos: port.Repositories{Users: repo, Sequences: &stubSequence{}, Outbox: outboxRepo, Audit: newTestAuditRepository()}}
+	return NewUserService(repo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, testutil.NewClock(testNow))
+}
+
 func TestUserService_Create_Success(t *testing.T) {
 	repo := new(MockUserRepository)
-	service := NewUserService(repo)
+	service := newTestUserService(repo)
 	user := &domain.User{
 		Name:  "Test User",
 		Email: "test@example.com",
 		Phone: "081-234-5678",
 	}
 	repo.On("Create", user).Return(nil)
-	err := service.CreateUser(user)
+	err := service.CreateUser(context.Background(), user)
 	assert.NoError(t, err)
+	assert.Equal(t, "LBK0000018", user.MemberID)
 	assert.NotZero(t, user.CreatedAt)
 	assert.NotZero(t, user.UpdatedAt)
 	repo.AssertExpectations(t)
@@ -60,45 +143,82 @@ func TestUserService_Create_Success(t *testing.T) {
 
 func TestUserService_Create_ValidationError(t *testing.T) {
 	repo := new(MockUserRepository)
-	service := NewUserService(repo)
+	service := newTestUserService(repo)
 	user := &domain.User{Name: ""} // Invalid name
 	// No repo.On expectation, since validation should fail before repo.Create is called
-	err := service.CreateUser(user)
+	err := service.CreateUser(context.Background(), user)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "validation")
 }
 
 func TestUserService_Create_RepoError(t *testing.T) {
 	repo := new(MockUserRepository)
-	service := NewUserService(repo)
+	service := newTestUserService(repo)
 	user := &domain.User{
 		Name:  "Test User",
 		Email: "test@example.com",
 		Phone: "081-234-5678",
 	}
 	repo.On("Create", user).Return(errors.New("db error"))
-	err := service.CreateUser(user)
+	err := service.CreateUser(context.Background(), user)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "db error")
 }
 
-func TestUserService_GetAllUsers_Success(t *testing.T) {
+func TestUserService_ListUsers_Success(t *testing.T) {
 	repo := new(MockUserRepository)
-	service := NewUserService(repo)
+	service := newTestUserService(repo)
 	expectedUsers := []domain.User{
 		{ID: 1, Name: "User 1", Email: "user1@example.com", Phone: "081-111-1111"},
 		{ID: 2, Name: "User 2", Email: "user2@example.com", Phone: "081-222-2222"},
 	}
-	repo.On("GetAll").Return(expectedUsers, nil)
-	users, err := service.GetAllUsers()
+	repo.On("List", port.UserFilter{NamePrefix: "User", Page: 1, PageSize: 20}).Return(expectedUsers, 7, nil)
+	users, total, err := service.ListUsers(port.UserFilter{NamePrefix: "User"})
 	assert.NoError(t, err)
 	assert.Equal(t, expectedUsers, users)
+	assert.Equal(t, 7, total)
 	repo.AssertExpectations(t)
 }
 
+func TestUserService_ListUsers_InvalidRange(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	minPoints, maxPoints := 500, 100
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, -1, 0)
+
+	_, _, err := service.ListUsers(port.UserFilter{MinPoints: &minPoints, MaxPoints: &maxPoints, MemberSinceFrom: &from, MemberSinceTo: &to})
+	var verr *domain.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Len(t, verr.Fields, 2)
+	}
+	repo.AssertNotCalled(t, "List", mock.Anything)
+}
+
+func TestUserService_SearchUsers(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	expected := []domain.User{{ID: 1, Name: "สมชาย ใจดี"}}
+	repo.On("Search", []string{"สมชาย", "6681234"}, 20).Return(expected, nil)
+
+	users, err := service.SearchUsers("  สมชาย 081-234 ", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, users)
+	repo.AssertExpectations(t)
+}
+
+func TestUserService_SearchUsers_EmptyQuery(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+
+	_, err := service.SearchUsers("   ", 10)
+	assert.Equal(t, KindValidation, KindOf(err))
+	repo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+}
+
 func TestUserService_GetUserByID_Success(t *testing.T) {
 	repo := new(MockUserRepository)
-	service := NewUserService(repo)
+	service := newTestUserService(repo)
 	expectedUser := &domain.User{ID: 1, Name: "Test User", Email: "test@example.com", Phone: "081-234-5678"}
 	repo.On("GetByID", 1).Return(expectedUser, nil)
 	user, err := service.GetUserByID(1)
@@ -109,7 +229,7 @@ func TestUserService_GetUserByID_Success(t *testing.T) {
 
 func TestUserService_GetUserByID_NotFound(t *testing.T) {
 	repo := new(MockUserRepository)
-	service := NewUserService(repo)
+	service := newTestUserService(repo)
 	repo.On("GetByID", 999).Return(nil, errors.New("user not found"))
 	user, err := service.GetUserByID(999)
 	assert.Error(t, err)
@@ -118,11 +238,392 @@ func TestUserService_GetUserByID_NotFound(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
-func TestUserService_DeleteUser_Success(t *testing.T) {
+func TestUserService_GetUserByID_Missing(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	repo.On("GetByID", 999).Return(nil, nil)
+	user, err := service.GetUserByID(999)
+	assert.Nil(t, user)
+	assert.Equal(t, ErrUserNotFound, err)
+}
+
+func TestUserService_CloseUser_ZeroBalance(t *testing.T) {
+	repo := new(MockUserRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	historyRepo := new(MockAccountStatusRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: repo, Ledger: ledgerRepo, StatusHistory: historyRepo, Audit: newTestAuditRepository()}}
+	service := NewUserService(repo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, testutil.NewClock(testNow))
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusActive}, nil).Once()
+	ledgerRepo.On("GetUserBalance", 1).Return(0, nil)
+	repo.On("UpdateStatus", 1, domain.AccountStatusClosed).Return(nil)
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusClosed}, nil)
+	historyRepo.On("CreateHistory", mock.MatchedBy(func(c *domain.AccountStatusChange) bool {
+		return c.FromStatus == domain.AccountStatusActive && c.ToStatus == domain.AccountStatusClosed
+	})).Return(nil)
+
+	user, err := service.CloseUser(context.Background(), 1, 0, false)
+	assert.NoError(t, err)
+	assert.True(t, user.Closed())
+	repo.AssertExpectations(t)
+	historyRepo.AssertExpectations(t)
+	ledgerRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUserService_CloseUser_BalanceRequiresForfeit(t *testing.T) {
+	repo := new(MockUserRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: repo, Ledger: ledgerRepo, Audit: newTestAuditRepository()}}
+	service := NewUserService(repo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, testutil.NewClock(testNow))
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(250, nil)
+
+	_, err := service.CloseUser(context.Background(), 1, 0, false)
+	assert.Equal(t, ErrBalanceNotZero, err)
+	repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+func TestUserService_CloseUser_ForfeitsBalance(t *testing.T) {
+	repo := new(MockUserRepository)
+	ledgerRepo := new(MockPointLedgerRepository)
+	lotRepo := new(MockLotRepository)
+	journalRepo := new(MockJournalRepository)
+	historyRepo := new(MockAccountStatusRepository)
+	outboxRepo := new(MockOutboxRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: repo, Ledger: ledgerRepo, Lots: lotRepo, Journal: journalRepo, StatusHistory: historyRepo, Outbox: outboxRepo, Audit: newTestAuditRepository()}}
+	service := NewUserService(repo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, testutil.NewClock(testNow))
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1}, nil)
+	ledgerRepo.On("GetUserBalance", 1).Return(250, nil)
+	ledgerRepo.On("Create", mock.MatchedBy(func(e *domain.PointLedger) bool {
+		return e.EventType == domain.EventTypeForfeit && e.Change == -250 && e.BalanceAfter == 0
+	})).Return(nil)
+	repo.On("UpdatePoints", 1, 0).Return(nil)
+	lotRepo.On("GetOpenByUserID", 1).Return([]domain.PointLot{{ID: 7, UserID: 1, Amount: 250, Remaining: 250}}, nil)
+	lotRepo.On("UpdateRemaining", 7, 0).Return(nil)
+	journalRepo.On("Create", mock.MatchedBy(func(e *domain.JournalEntry) bool {
+		return e.EntryType == domain.JournalEntryForfeit && e.Validate() == nil
+	})).Return(nil)
+	repo.On("UpdateStatus", 1, domain.AccountStatusClosed).Return(nil)
+	historyRepo.On("CreateHistory", mock.AnythingOfType("*domain.AccountStatusChange")).Return(nil)
+	outboxRepo.On("Create", eventNamed(domain.EventPointsForfeited)).Return(nil)
+
+	_, err := service.CloseUser(context.Background(), 1, 0, true)
+	assert.NoError(t, err)
+	ledgerRepo.AssertExpectations(t)
+	lotRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+	repo.AssertExpectations(t)
+	outboxRepo.AssertExpectations(t)
+}
+
+func TestUserService_CloseUser_NotFound(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	repo.On("GetByID", 999).Return(nil, nil)
+	_, err := service.CloseUser(context.Background(), 999, 0, false)
+	assert.Equal(t, ErrUserNotFound, err)
+	assert.Equal(t, KindNotFound, KindOf(err))
+}
+
+func TestUserService_CloseUser_AlreadyClosed(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1, Status: domain.AccountStatusClosed}, nil)
+	_, err := service.CloseUser(context.Background(), 1, 0, true)
+	assert.Equal(t, ErrAccountClosed, err)
+}
+
+func TestUserService_Create_WithPoints_BooksOpeningEntry(t *testing.T) {
+	repo := new(MockUserRepository)
+	journalRepo := new(MockJournalRepository)
+	lotRepo := new(MockLotRepository)
+	outboxRepo := new(MockOutboxRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: repo, Journal: journalRepo, Lots: lotRepo, Sequences: &stubSequence{}, Outbox: outboxRepo, Audit: newTestAuditRepository()}}
+	repo.On("GetByPhone", "+66812345678").Return(nil, nil)
+	repo.On("GetByEmail", "test@example.com").Return(nil, nil)
+	service := NewUserService(repo, tx, domain.ExpiryPolicy{Months: 12}, testTiers, testMemberIDs, testutil.NewClock(testNow))
+	user := &domain.User{
+		Name:   "Test User",
+		Email:  "test@example.com",
+		Phone:  "081-234-5678",
+		Points: 500,
+	}
+	repo.On("Create", user).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.User).ID = 3
+	})
+	journalRepo.On("Create", mock.AnythingOfType("*domain.JournalEntry")).Return(nil)
+	lotRepo.On("Create", mock.AnythingOfType("*domain.PointLot")).Return(nil)
+	outboxRepo.On("Create", eventNamed(domain.EventUserCreated)).Return(nil)
+
+	err := service.CreateUser(context.Background(), user)
+	assert.NoError(t, err)
+
+	lot := lotRepo.Calls[0].Arguments.Get(0).(*domain.PointLot)
+	assert.Equal(t, 3, lot.UserID)
+	assert.Equal(t, 500, lot.Remaining)
+	assert.Equal(t, user.CreatedAt.AddDate(1, 0, 0), lot.ExpiresAt)
+
+	entry := journalRepo.Calls[0].Arguments.Get(0).(*domain.JournalEntry)
+	assert.Equal(t, domain.JournalEntryOpening, entry.EntryType)
+	assert.Equal(t, []domain.Posting{
+		{Account: domain.AccountIssuance, Amount: -500},
+		{Account: "member:3", Amount: 500},
+	}, entry.Postings)
+
+	event := outboxRepo.Calls[0].Arguments.Get(0).(*domain.OutboxEvent)
+	assert.Equal(t, "3", event.AggregateID)
+	assert.JSONEq(t, `{"userId":3,"memberId":"LBK0000018","membershipLevel":"Bronze","points":500}`, string(event.Payload))
+}
+
+func TestUserService_Create_NegativePoints(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	user := &domain.User{
+		Name:   "Test User",
+		Email:  "test@example.com",
+		Phone:  "081-234-5678",
+		Points: -1,
+	}
+	err := service.CreateUser(context.Background(), user)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "points must not be negative")
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUserService_Create_IgnoresClientMemberID(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	user := &domain.User{
+		Name:     "Test User",
+		Email:    "test@example.com",
+		Phone:    "081-234-5678",
+		MemberID: "LBK001234",
+	}
+	repo.On("Create", user).Return(nil)
+	err := service.CreateUser(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, "LBK0000018", user.MemberID)
+}
+
+func TestUserService_Create_StartsActive(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	closedAt := testNow
+	user := &domain.User{
+		Name:      "Test User",
+		Email:     "test@example.com",
+		Phone:     "081-234-5678",
+		Status:    domain.AccountStatusClosed,
+		Version:   9,
+		DeletedAt: &closedAt,
+	}
+	repo.On("Create", user).Return(nil)
+	err := service.CreateUser(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.AccountStatusActive, user.Status)
+	assert.Nil(t, user.DeletedAt)
+}
+
+func TestUserService_Create_StartsInLowestTier(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	user := &domain.User{
+		Name:            "Test User",
+		Email:           "test@example.com",
+		Phone:           "081-234-5678",
+		MembershipLevel: "Diamond",
+	}
+	repo.On("Create", user).Return(nil)
+	err := service.CreateUser(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bronze", user.MembershipLevel)
+}
+
+func TestUserService_GetUserByMemberID(t *testing.T) {
 	repo := new(MockUserRepository)
-	service := NewUserService(repo)
-	repo.On("Delete", 1).Return(nil)
-	err := service.DeleteUser(1)
+	service := newTestUserService(repo)
+	expectedUser := &domain.User{ID: 1, MemberID: "LBK0000018"}
+	repo.On("GetByMemberID", "LBK0000018").Return(expectedUser, nil)
+
+	user, err := service.GetUserByMemberID(" lbk-000001-8 ")
 	assert.NoError(t, err)
+	assert.Equal(t, expectedUser, user)
+
+	user, err = service.GetUserByMemberID("LBK0000017")
+	assert.Equal(t, ErrInvalidMemberID, err)
+	assert.Nil(t, user)
+}
+
+func TestUserService_Create_NormalizesContacts(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	user := &domain.User{
+		Name:  " Test User ",
+		Email: "Test@Example.com",
+		Phone: "+66 81 234 5678",
+	}
+	repo.On("Create", user).Return(nil)
+	err := service.CreateUser(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test User", user.Name)
+	assert.Equal(t, "test@example.com", user.Email)
+	assert.Equal(t, "+66812345678", user.Phone)
+}
+
+func TestUserService_Create_InvalidContacts(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	user := &domain.User{Name: "Test User", Email: "test@", Phone: "abc"}
+
+	err := service.CreateUser(context.Background(), user)
+	var verr *domain.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Len(t, verr.Fields, 2)
+		assert.Equal(t, "email", verr.Fields[0].Field)
+		assert.Equal(t, "phone", verr.Fields[1].Field)
+	}
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUserService_Create_DuplicateContacts(t *testing.T) {
+	repo := new(MockUserRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: repo, Sequences: &stubSequence{}, Audit: newTestAuditRepository()}}
+	service := NewUserService(repo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, testutil.NewClock(testNow))
+	user := &domain.User{Name: "Test User", Email: "test@example.com", Phone: "081-234-5678"}
+	repo.On("GetByPhone", "+66812345678").Return(&domain.User{ID: 1}, nil)
+	repo.On("GetByEmail", "test@example.com").Return(nil, nil)
+
+	err := service.CreateUser(context.Background(), user)
+	var verr *domain.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.True(t, verr.Conflict())
+		assert.Equal(t, []domain.FieldError{
+			{Field: "phone", Code: domain.FieldCodeTaken, Message: "is already registered"},
+		}, verr.Fields)
+	}
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUserService_Create_ContactClaimedConcurrently(t *testing.T) {
+	repo := new(MockUserRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: repo, Sequences: &stubSequence{}, Audit: newTestAuditRepository()}}
+	service := NewUserService(repo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, testutil.NewClock(testNow))
+	user := &domain.User{Name: "Test User", Email: "test@example.com", Phone: "081-234-5678"}
+	repo.On("GetByPhone", "+66812345678").Return(nil, nil)
+	repo.On("GetByEmail", "test@example.com").Return(nil, nil)
+	// Another request registered the email after the check
+	repo.On("Create", mock.AnythingOfType("*domain.User")).Return(port.ErrEmailTaken)
+
+	err := service.CreateUser(context.Background(), user)
+	var verr *domain.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.True(t, verr.Conflict())
+		assert.Equal(t, []domain.FieldError{
+			{Field: "email", Code: domain.FieldCodeTaken, Message: "is already registered"},
+		}, verr.Fields)
+	}
+}
+
+func TestUserService_UpdateUser_AllowsOwnContacts(t *testing.T) {
+	repo := new(MockUserRepository)
+	tx := &stubTransactor{repos: port.Repositories{Users: repo, Audit: newTestAuditRepository()}}
+	service := NewUserService(repo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, testutil.NewClock(testNow))
+	user := &domain.User{ID: 1, Name: "Test User", Email: "test@example.com", Phone: "081-234-5678"}
+	stored := &domain.User{ID: 1, MemberID: "LBK0000018"}
+	repo.On("GetByID", 1).Return(stored, nil)
+	repo.On("GetByPhone", "+66812345678").Return(stored, nil)
+	repo.On("GetByEmail", "test@example.com").Return(stored, nil)
+	repo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+
+	err := service.UpdateUser(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, "LBK0000018", user.MemberID)
+	assert.Equal(t, "+66812345678", user.Phone)
 	repo.AssertExpectations(t)
 }
+
+func TestUserService_PatchUser_MergesPresentFields(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	stored := &domain.User{ID: 1, Name: "Old Name", Email: "old@example.com", Phone: "+66812345678", Points: 500, MembershipLevel: "Gold", MemberID: "LBK0000018"}
+	repo.On("GetByID", 1).Return(stored, nil)
+	repo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+
+	user, err := service.PatchUser(context.Background(), 1, 0, domain.UserPatch{
+		"name":  json.RawMessage(`"New Name"`),
+		"email": json.RawMessage(`"New@Example.com"`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "New Name", user.Name)
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Equal(t, "+66812345678", user.Phone)
+	assert.Equal(t, 500, user.Points)
+	assert.Equal(t, "Gold", user.MembershipLevel)
+	assert.Equal(t, "LBK0000018", user.MemberID)
+	assert.Equal(t, "Old Name", stored.Name)
+}
+
+func TestUserService_PatchUser_RejectsReadOnlyFields(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1, Name: "Name", Email: "a@example.com", Phone: "+66812345678"}, nil)
+
+	_, err := service.PatchUser(context.Background(), 1, 0, domain.UserPatch{"points": json.RawMessage(`99999`)})
+	var verr *domain.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, []domain.FieldError{{Field: "points", Code: domain.FieldCodeNotAllowed, Message: "cannot be changed"}}, verr.Fields)
+	}
+	repo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestUserService_PatchUser_RevalidatesMergedUser(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1, Name: "Name", Email: "a@example.com", Phone: "+66812345678"}, nil)
+
+	_, err := service.PatchUser(context.Background(), 1, 0, domain.UserPatch{"name": json.RawMessage(`null`)})
+	var verr *domain.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, "name", verr.Fields[0].Field)
+	}
+	repo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestUserService_PatchUser_NotFound(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	repo.On("GetByID", 999).Return(nil, nil)
+
+	_, err := service.PatchUser(context.Background(), 999, 0, domain.UserPatch{"name": json.RawMessage(`"x"`)})
+	assert.Equal(t, ErrUserNotFound, err)
+}
+
+func TestUserService_PatchUser_StaleVersion(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1, Name: "Name", Email: "a@example.com", Phone: "+66812345678", Version: 3}, nil)
+
+	_, err := service.PatchUser(context.Background(), 1, 2, domain.UserPatch{"name": json.RawMessage(`"x"`)})
+	assert.Equal(t, ErrVersionMismatch, err)
+	assert.Equal(t, KindPreconditionFailed, KindOf(err))
+	repo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestUserService_UpdateUser_ConcurrentWrite(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1, Name: "Name", Email: "a@example.com", Phone: "+66812345678", Version: 3}, nil)
+	repo.On("Update", mock.AnythingOfType("*domain.User")).Return(port.ErrVersionConflict)
+
+	err := service.UpdateUser(context.Background(), &domain.User{ID: 1, Name: "New", Email: "a@example.com", Phone: "+66812345678", Version: 3})
+	assert.Equal(t, ErrVersionMismatch, err)
+}
+
+func TestUserService_CloseUser_StaleVersion(t *testing.T) {
+	repo := new(MockUserRepository)
+	service := newTestUserService(repo)
+	repo.On("GetByID", 1).Return(&domain.User{ID: 1, Version: 5}, nil)
+
+	_, err := service.CloseUser(context.Background(), 1, 4, true)
+	assert.Equal(t, ErrVersionMismatch, err)
+	repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// UserStreamService opens live event streams of a user's balance changes
+// and incoming transfers. Changes made by requests are published by
+// StreamingTransferService and StreamingPointsService as soon as they
+// commit. As an EventSink it publishes the rest from the outbox: expiries,
+// balances forfeited on close and opening balances.
+type UserStreamService struct {
+	userRepo   port.UserRepository
+	ledgerRepo port.PointLedgerRepository
+	bus        port.UserEventBus
+	clock      port.Clock
+}
+
+func NewUserStreamService(userRepo port.UserRepository, ledgerRepo port.PointLedgerRepository, bus port.UserEventBus, clock port.Clock) *UserStreamService {
+	return &UserStreamService{userRepo: userRepo, ledgerRepo: ledgerRepo, bus: bus, clock: clock}
+}
+
+// Subscribe opens the user's stream, resuming after lastEventID when given.
+// A new stream, or one that cannot be resumed without a gap, gets the
+// current balance after the replayed events, so the client ends up current
+// either way.
+func (s *UserStreamService) Subscribe(ctx context.Context, userID int, lastEventID string) (*port.UserEventSubscription, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// Subscribe before reading the balance so no change falls in between
+	subscription := s.bus.Subscribe(userID, lastEventID)
+	if lastEventID != "" && !subscription.Missed {
+		return subscription, nil
+	}
+
+	balance, err := s.ledgerRepo.GetUserBalance(userID)
+	if err != nil {
+		subscription.Close()
+		return nil, err
+	}
+	subscription.Replay = append(subscription.Replay, domain.UserEvent{
+		UserID: userID,
+		Type:   domain.UserEventBalance,
+		Data:   domain.BalanceUpdate{UserID: userID, Balance: balance, At: s.clock.Now()},
+	})
+	return subscription, nil
+}
+
+// Publish turns balance changes made outside requests into balance updates
+// on the member's stream.
+func (s *UserStreamService) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	var update domain.BalanceUpdate
+	switch event.Name {
+	case domain.EventPointsExpired, domain.EventPointsForfeited:
+		var payload domain.PointsChangedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to decode event %d: %w", event.ID, err)
+		}
+		reason := domain.EventTypeExpire
+		if event.Name == domain.EventPointsForfeited {
+			reason = domain.EventTypeForfeit
+		}
+		update = domain.BalanceUpdate{UserID: payload.UserID, Balance: payload.BalanceAfter, Change: payload.Change, Reason: reason}
+
+	case domain.EventUserCreated:
+		var payload domain.UserCreatedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to decode event %d: %w", event.ID, err)
+		}
+		if payload.Points == 0 {
+			return nil
+		}
+		update = domain.BalanceUpdate{UserID: payload.UserID, Balance: payload.Points, Change: payload.Points, Reason: domain.BalanceReasonOpening}
+
+	default:
+		return nil
+	}
+
+	update.At = event.CreatedAt
+	s.bus.Publish(domain.UserEvent{UserID: update.UserID, Type: domain.UserEventBalance, Data: update})
+	return nil
+}
+
+// StreamingTransferService pushes the new balances of both users and the
+// transfer itself to the recipient after every successful transfer. The
+// balances are read after commit, so a later change may already be included.
+type StreamingTransferService struct {
+	TransferManager
+	ledgerRepo port.PointLedgerRepository
+	bus        port.UserEventBus
+}
+
+func NewStreamingTransferService(inner TransferManager, ledgerRepo port.PointLedgerRepository, bus port.UserEventBus) *StreamingTransferService {
+	return &StreamingTransferService{TransferManager: inner, ledgerRepo: ledgerRepo, bus: bus}
+}
+
+func (s *StreamingTransferService) CreateTransfer(ctx context.Context, fromUserID, toUserID, amount int, note *string) (*domain.Transfer, error) {
+	transfer, err := s.TransferManager.CreateTransfer(ctx, fromUserID, toUserID, amount, note)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(transfer)
+	return transfer, nil
+}
+
+func (s *StreamingTransferService) ConfirmTransfer(ctx context.Context, fromUserID int, confirmationID string) (*domain.ConfirmedTransfer, error) {
+	confirmed, err := s.TransferManager.ConfirmTransfer(ctx, fromUserID, confirmationID)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(confirmed.Transfer())
+	return confirmed, nil
+}
+
+func (s *StreamingTransferService) publish(transfer *domain.Transfer) {
+	s.publishBalance(transfer.FromUserID, -transfer.Amount, domain.EventTypeTransferOut, transfer)
+	s.publishBalance(transfer.ToUserID, transfer.Amount, domain.EventTypeTransferIn, transfer)
+	s.bus.Publish(domain.UserEvent{UserID: transfer.ToUserID, Type: domain.UserEventTransfer, Data: transfer})
+}
+
+func (s *StreamingTransferService) publishBalance(userID, change int, reason domain.EventType, transfer *domain.Transfer) {
+	balance, err := s.ledgerRepo.GetUserBalance(userID)
+	if err != nil {
+		log.Printf("stream: balance of user %d after transfer %d: %v", userID, transfer.ID, err)
+		return
+	}
+	s.bus.Publish(domain.UserEvent{
+		UserID: userID,
+		Type:   domain.UserEventBalance,
+		Data:   domain.BalanceUpdate{UserID: userID, Balance: balance, Change: change, Reason: reason, At: transfer.UpdatedAt},
+	})
+}
+
+// StreamingPointsService pushes the new balance after every successful earn
+// and redeem.
+type StreamingPointsService struct {
+	PointsManager
+	bus port.UserEventBus
+}
+
+func NewStreamingPointsService(inner PointsManager, bus port.UserEventBus) *StreamingPointsService {
+	return &StreamingPointsService{PointsManager: inner, bus: bus}
+}
+
+func (s *StreamingPointsService) Earn(ctx context.Context, userID, amount int, reference *string) (*domain.PointLedger, error) {
+	entry, err := s.PointsManager.Earn(ctx, userID, amount, reference)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(entry)
+	return entry, nil
+}
+
+func (s *StreamingPointsService) Redeem(ctx context.Context, userID, amount int, reference *string) (*domain.PointLedger, error) {
+	entry, err := s.PointsManager.Redeem(ctx, userID, amount, reference)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(entry)
+	return entry, nil
+}
+
+func (s *StreamingPointsService) publish(entry *domain.PointLedger) {
+	s.bus.Publish(domain.UserEvent{
+		UserID: entry.UserID,
+		Type:   domain.UserEventBalance,
+		Data: domain.BalanceUpdate{
+			UserID:  entry.UserID,
+			Balance: entry.BalanceAfter,
+			Change:  entry.Change,
+			Reason:  entry.EventType,
+			At:      entry.CreatedAt,
+		},
+	})
+}
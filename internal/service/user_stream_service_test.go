@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workshop4-backend/internal/adapter"
+	"workshop4-backend/internal/adapter/memory"
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
+)
+
+type streamFixture struct {
+	streams    *UserStreamService
+	transfers  TransferManager
+	points     PointsManager
+	users      *UserService
+	expiry     *ExpiryService
+	dispatcher *EventDispatcher
+	clock      *testutil.Clock
+	sender     *domain.User
+	recipient  *domain.User
+}
+
+func newStreamFixture(t *testing.T, capacity int) *streamFixture {
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	ledgerRepo := memory.NewPointLedgerRepository(store)
+	tx := memory.NewTransactor(store)
+	clock := testutil.NewClock(testNow)
+	bus := adapter.NewUserEventBroker(capacity, time.Hour, clock)
+
+	users := NewUserService(userRepo, tx, domain.ExpiryPolicy{Months: 24}, testTiers, testMemberIDs, clock)
+	sender := &domain.User{Name: "สมชาย ใจดี", Phone: "0812345678", Email: "somchai@example.com", Points: 1000}
+	recipient := &domain.User{Name: "สมหญิง ดีใจ", Phone: "0815678901", Email: "somying@example.com", Points: 100}
+	require.NoError(t, users.CreateUser(context.Background(), sender))
+	require.NoError(t, users.CreateUser(context.Background(), recipient))
+
+	streams := NewUserStreamService(userRepo, ledgerRepo, bus, clock)
+	return &streamFixture{
+		streams: streams,
+		transfers: NewStreamingTransferService(
+			NewTransferService(memory.NewTransferRepository(store), ledgerRepo, userRepo, tx, clock, testutil.NewIDs()), ledgerRepo, bus),
+		points:     NewStreamingPointsService(NewPointsService(tx, domain.ExpiryPolicy{Months: 24}, clock), bus),
+		users:      users,
+		expiry:     NewExpiryService(memory.NewLotRepository(store), userRepo, tx, clock),
+		dispatcher: NewEventDispatcher(memory.NewOutboxRepository(store), map[string]port.EventSink{"streams": streams}, clock, testDispatchPolicy, 100),
+		clock:      clock,
+		sender:     sender,
+		recipient:  recipient,
+	}
+}
+
+func (f *streamFixture) dispatch(t *testing.T) {
+	t.Helper()
+	_, err := f.dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+}
+
+func (f *streamFixture) subscribe(t *testing.T, userID int, lastEventID string) *port.UserEventSubscription {
+	t.Helper()
+	subscription, err := f.streams.Subscribe(context.Background(), userID, lastEventID)
+	require.NoError(t, err)
+	t.Cleanup(subscription.Close)
+	return subscription
+}
+
+func (f *streamFixture) earn(t *testing.T, userID, amount int) {
+	t.Helper()
+	_, err := f.points.Earn(context.Background(), userID, amount, nil)
+	require.NoError(t, err)
+}
+
+// received drains the events already delivered to subscription.
+func received(subscription *port.UserEventSubscription) []domain.UserEvent {
+	var events []domain.UserEvent
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func updates(events []domain.UserEvent) []domain.BalanceUpdate {
+	var result []domain.BalanceUpdate
+	for _, event := range events {
+		if update, ok := event.Data.(domain.BalanceUpdate); ok {
+			result = append(result, update)
+		}
+	}
+	return result
+}
+
+func balances(events []domain.UserEvent) []int {
+	var result []int
+	for _, event := range events {
+		if update, ok := event.Data.(domain.BalanceUpdate); ok {
+			result = append(result, update.Balance)
+		}
+	}
+	return result
+}
+
+func TestUserStreamService_PushesTransfers(t *testing.T) {
+	f := newStreamFixture(t, 10)
+	sender := f.subscribe(t, f.sender.ID, "")
+	recipient := f.subscribe(t, f.recipient.ID, "")
+
+	// A new stream starts from the current balance
+	require.Len(t, recipient.Replay, 1)
+	assert.Empty(t, recipient.Replay[0].ID)
+	assert.Equal(t, domain.BalanceUpdate{UserID: f.recipient.ID, Balance: 100, At: testNow}, recipient.Replay[0].Data)
+
+	transfer, err := f.transfers.CreateTransfer(context.Background(), f.sender.ID, f.recipient.ID, 300, nil)
+	require.NoError(t, err)
+
+	events := received(sender)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, domain.BalanceUpdate{UserID: f.sender.ID, Balance: 700, Change: -300, Reason: domain.EventTypeTransferOut, At: testNow}, events[0].Data)
+	}
+	events = received(recipient)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, domain.UserEventBalance, events[0].Type)
+		assert.Equal(t, domain.BalanceUpdate{UserID: f.recipient.ID, Balance: 400, Change: 300, Reason: domain.EventTypeTransferIn, At: testNow}, events[0].Data)
+		assert.Equal(t, domain.UserEventTransfer, events[1].Type)
+		assert.Equal(t, transfer, events[1].Data)
+		assert.NotEqual(t, events[0].ID, events[1].ID)
+	}
+
+	// Failed transfers publish nothing
+	_, err = f.transfers.CreateTransfer(context.Background(), f.sender.ID, f.recipient.ID, 5000, nil)
+	require.Equal(t, ErrInsufficientBalance, err)
+	assert.Empty(t, received(sender))
+	assert.Empty(t, received(recipient))
+}
+
+func TestUserStreamService_ResumesAfterLastEventID(t *testing.T) {
+	f := newStreamFixture(t, 10)
+	first := f.subscribe(t, f.sender.ID, "")
+	f.earn(t, f.sender.ID, 10)
+	seen := received(first)
+	require.Len(t, seen, 1)
+	first.Close()
+
+	// Events published while the client was away are replayed in order
+	f.earn(t, f.sender.ID, 20)
+	f.earn(t, f.recipient.ID, 5)
+	f.earn(t, f.sender.ID, 30)
+	resumed := f.subscribe(t, f.sender.ID, seen[0].ID)
+	assert.False(t, resumed.Missed)
+	assert.Equal(t, []int{1030, 1060}, balances(resumed.Replay))
+
+	f.earn(t, f.sender.ID, 40)
+	assert.Equal(t, []int{1100}, balances(received(resumed)))
+}
+
+func TestUserStreamService_SnapshotWhenResumeHasGap(t *testing.T) {
+	f := newStreamFixture(t, 2)
+	f.earn(t, f.sender.ID, 10)
+	subscription := f.subscribe(t, f.sender.ID, "")
+	f.earn(t, f.sender.ID, 20)
+	lastSeen := received(subscription)[0].ID
+	subscription.Close()
+
+	for _, amount := range []int{30, 40, 50} {
+		f.earn(t, f.sender.ID, amount)
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID string
+	}{
+		{"evicted from the buffer", lastSeen},
+		{"from another process", "other-1"},
+		{"malformed", "not-an-id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resumed := f.subscribe(t, f.sender.ID, tt.lastEventID)
+			assert.True(t, resumed.Missed)
+			// The buffered events, then the current balance
+			assert.Equal(t, []int{1100, 1150, 1150}, balances(resumed.Replay))
+			assert.Empty(t, resumed.Replay[2].ID)
+		})
+	}
+}
+
+func TestUserStreamService_DropsSlowSubscribers(t *testing.T) {
+	f := newStreamFixture(t, 100)
+	subscription := f.subscribe(t, f.sender.ID, "")
+	for i := 0; i < 40; i++ {
+		f.earn(t, f.sender.ID, 1)
+	}
+
+	events := received(subscription)
+	assert.Less(t, len(events), 40)
+	_, open := <-subscription.Events
+	assert.False(t, open, "the stream ends so the client reconnects and resumes")
+
+	resumed := f.subscribe(t, f.sender.ID, events[len(events)-1].ID)
+	assert.False(t, resumed.Missed)
+	assert.Len(t, resumed.Replay, 40-len(events))
+}
+
+func TestUserStreamService_UnknownUser(t *testing.T) {
+	f := newStreamFixture(t, 10)
+	_, err := f.streams.Subscribe(context.Background(), 999, "")
+	assert.Equal(t, ErrUserNotFound, err)
+}
+
+func TestUserStreamService_ClosedSubscriptionGetsNothing(t *testing.T) {
+	f := newStreamFixture(t, 10)
+	subscription := f.subscribe(t, f.sender.ID, "")
+	subscription.Close()
+	subscription.Close()
+
+	f.earn(t, f.sender.ID, 10)
+	select {
+	case _, open := <-subscription.Events:
+		assert.False(t, open)
+	case <-time.After(time.Second):
+		t.Fatal("closed subscription's channel is still open")
+	}
+}
+
+func TestUserStreamService_PublishesBalanceChangesFromOutbox(t *testing.T) {
+	f := newStreamFixture(t, 10)
+	sender := f.subscribe(t, f.sender.ID, "")
+	recipient := f.subscribe(t, f.recipient.ID, "")
+
+	// Opening balances, then a forfeit on close
+	_, err := f.users.CloseUser(context.Background(), f.recipient.ID, 0, true)
+	require.NoError(t, err)
+	f.dispatch(t)
+	assert.Equal(t, []domain.BalanceUpdate{
+		{UserID: f.sender.ID, Balance: 1000, Change: 1000, Reason: domain.BalanceReasonOpening, At: testNow},
+	}, updates(received(sender)))
+	assert.Equal(t, []domain.BalanceUpdate{
+		{UserID: f.recipient.ID, Balance: 100, Change: 100, Reason: domain.BalanceReasonOpening, At: testNow},
+		{UserID: f.recipient.ID, Balance: 0, Change: -100, Reason: domain.EventTypeForfeit, At: testNow},
+	}, updates(received(recipient)))
+
+	// Expiry runs as a job, outside any request
+	expiredAt := testNow.AddDate(2, 0, 1)
+	f.clock.Set(expiredAt)
+	_, err = f.expiry.ExpireDue()
+	require.NoError(t, err)
+	f.dispatch(t)
+	assert.Equal(t, []domain.BalanceUpdate{
+		{UserID: f.sender.ID, Balance: 0, Change: -1000, Reason: domain.EventTypeExpire, At: expiredAt},
+	}, updates(received(sender)))
+
+	// Changes made by requests were already published as they committed
+	f.earn(t, f.sender.ID, 10)
+	f.dispatch(t)
+	assert.Equal(t, []int{10}, balances(received(sender)))
+}
+
+func TestUserStreamService_ForgetsIdleUsers(t *testing.T) {
+	f := newStreamFixture(t, 10)
+	subscription := f.subscribe(t, f.sender.ID, "")
+	f.earn(t, f.sender.ID, 10)
+	lastSeen := received(subscription)[0].ID
+	subscription.Close()
+
+	// The broker forgets the sender an hour after their stream closed; a
+	// later resume cannot be replayed and starts from the balance instead
+	f.clock.Advance(time.Hour)
+	f.earn(t, f.recipient.ID, 5)
+	resumed := f.subscribe(t, f.sender.ID, lastSeen)
+	assert.True(t, resumed.Missed)
+	assert.Equal(t, []int{1010}, balances(resumed.Replay))
+}
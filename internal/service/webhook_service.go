@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+)
+
+// WebhookService manages webhook subscriptions and delivers events to them.
+// As an EventSink it turns each published event into one delivery per
+// subscription to it that covers the event's members; DeliverDue then POSTs due deliveries, retrying with
+// backoff until the policy's attempts run out and the delivery is dead.
+type WebhookService struct {
+	repo      port.WebhookRepository
+	sender    port.WebhookSender
+	clock     port.Clock
+	policy    domain.WebhookPolicy
+	batchSize int
+}
+
+func NewWebhookService(repo port.WebhookRepository, sender port.WebhookSender, clock port.Clock, policy domain.WebhookPolicy, batchSize int) *WebhookService {
+	return &WebhookService{repo: repo, sender: sender, clock: clock, policy: policy, batchSize: batchSize}
+}
+
+func (s *WebhookService) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+	subscription.CreatedAt = s.clock.Now()
+	if err := s.repo.CreateSubscription(subscription); err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (s *WebhookService) GetSubscription(id int) (*domain.WebhookSubscription, error) {
+	subscription, err := s.repo.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return subscription, nil
+}
+
+func (s *WebhookService) ListSubscriptions() ([]domain.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions()
+}
+
+// DeleteSubscription stops deliveries to the subscription and drops its
+// delivery log.
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int) error {
+	if _, err := s.GetSubscription(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(id)
+}
+
+// ListDeliveries returns one page of the subscription's delivery log,
+// newest first, and the total number of matching deliveries.
+func (s *WebhookService) ListDeliveries(filter port.WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error) {
+	if _, err := s.GetSubscription(filter.SubscriptionID); err != nil {
+		return nil, 0, err
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 || filter.PageSize > 200 {
+		filter.PageSize = 20
+	}
+	return s.repo.ListDeliveries(filter)
+}
+
+// Redeliver puts a dead delivery back in the queue with a fresh set of
+// attempts.
+func (s *WebhookService) Redeliver(ctx context.Context, id int) (*domain.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	if delivery.Status != domain.WebhookDeliveryDead {
+		return nil, ErrDeliveryNotDead
+	}
+
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = s.clock.Now()
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		return nil, fmt.Errorf("failed to requeue delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// Publish queues a delivery of event for every subscription to it that
+// covers the members the event is about. The outbox may publish an event
+// again; the repository keeps one delivery per event and subscription.
+func (s *WebhookService) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	subscriptions, err := s.repo.ListSubscriptions()
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	userIDs, err := event.UserIDs()
+	if err != nil {
+		return fmt.Errorf("failed to decode event %d: %w", event.ID, err)
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	now := s.clock.Now()
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(event.Name) || !subscription.Covers(userIDs) {
+			continue
+		}
+		delivery := &domain.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventName:      event.Name,
+			Body:           body,
+			Status:         domain.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+		if err := s.repo.CreateDelivery(delivery); err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeliverDue attempts up to one batch of due deliveries, oldest first.
+func (s *WebhookService) DeliverDue(ctx context.Context) (*domain.WebhookRun, error) {
+	deliveries, err := s.repo.GetDueDeliveries(s.clock.Now(), s.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due deliveries: %w", err)
+	}
+
+	run := &domain.WebhookRun{}
+	subscriptions := map[int]*domain.WebhookSubscription{}
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			if subscription, err = s.repo.GetSubscription(delivery.SubscriptionID); err != nil {
+				return run, err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		// Deleted since the batch was read
+		if subscription == nil {
+			continue
+		}
+
+		s.attempt(ctx, subscription, delivery)
+		if err := s.repo.UpdateDelivery(delivery); err != nil {
+			return run, fmt.Errorf("failed to update delivery %d: %w", delivery.ID, err)
+		}
+		switch delivery.Status {
+		case domain.WebhookDeliveryDelivered:
+			run.Delivered++
+		case domain.WebhookDeliveryDead:
+			run.Dead++
+		default:
+			run.Retrying++
+		}
+	}
+	return run, nil
+}
+
+// attempt sends the delivery once and records the outcome on it. Any 2xx
+// response counts as delivered.
+func (s *WebhookService) attempt(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) {
+	now := s.clock.Now()
+	headers := map[string]string{
+		domain.WebhookHeaderID:        strconv.Itoa(delivery.ID),
+		domain.WebhookHeaderEvent:     string(delivery.EventName),
+		domain.WebhookHeaderTimestamp: strconv.FormatInt(now.Unix(), 10),
+		domain.WebhookHeaderSignature: domain.WebhookSignature(subscription.Secret, now, delivery.Body),
+	}
+	status, err := s.sender.Send(ctx, subscription.URL, headers, delivery.Body)
+
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	if err == nil {
+		delivery.ResponseStatus = &status
+		if status >= 200 && status < 300 {
+			delivery.Status = domain.WebhookDeliveryDelivered
+			delivery.DeliveredAt = &now
+			delivery.LastError = nil
+			return
+		}
+		err = fmt.Errorf("receiver responded with status %d", status)
+	}
+
+	lastError := err.Error()
+	delivery.LastError = &lastError
+	if delivery.Attempts >= s.policy.MaxAttempts {
+		delivery.Status = domain.WebhookDeliveryDead
+		return
+	}
+	delivery.NextAttemptAt = now.Add(s.policy.Backoff.Delay(delivery.Attempts))
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workshop4-backend/internal/adapter"
+	"workshop4-backend/internal/adapter/memory"
+	"workshop4-backend/internal/domain"
+	"workshop4-backend/internal/port"
+	"workshop4-backend/internal/testutil"
+)
+
+const testWebhookSecret = "whsec-0123456789abcdef"
+
+// webhookReceiver is a local HTTP endpoint that records the webhooks it is
+// sent and answers with status.
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	r := &webhookReceiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, receivedWebhook{header: req.Header.Clone(), body: body})
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) respondWith(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *webhookReceiver) received() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.requests...)
+}
+
+func newTestWebhookService(maxAttempts int) (*WebhookService, port.WebhookRepository, *testutil.Clock) {
+	repo := memory.NewWebhookRepository(memory.NewStore())
+	clock := testutil.NewClock(testNow)
+	policy := domain.WebhookPolicy{Backoff: testBackoff, MaxAttempts: maxAttempts}
+	return NewWebhookService(repo, adapter.NewHTTPWebhookSender(time.Second), clock, policy, 10), repo, clock
+}
+
+func subscribe(t *testing.T, s *WebhookService, url string, eventTypes ...domain.EventName) *domain.WebhookSubscription {
+	t.Helper()
+	subscription := &domain.WebhookSubscription{URL: url, EventTypes: eventTypes, Secret: testWebhookSecret}
+	require.NoError(t, s.CreateSubscription(context.Background(), subscription))
+	return subscription
+}
+
+func outboxEvent(t *testing.T, id int, name domain.EventName) domain.OutboxEvent {
+	t.Helper()
+	event, err := domain.NewOutboxEvent(name, domain.AuditEntityUser, 1, domain.PointsChangedEvent{UserID: 1, Change: 50, BalanceAfter: 150}, testNow)
+	require.NoError(t, err)
+	event.ID = id
+	return *event
+}
+
+func TestWebhookService_CreateSubscription_Validation(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription domain.WebhookSubscription
+		wantFields   []string
+	}{
+		{"valid", domain.WebhookSubscription{URL: "https://partner.example.com/hooks", EventTypes: []domain.EventName{domain.EventPointsEarned}, Secret: testWebhookSecret}, nil},
+		{"relative URL", domain.WebhookSubscription{URL: "/hooks", EventTypes: []domain.EventName{domain.EventPointsEarned}, Secret: testWebhookSecret}, []string{"url"}},
+		{"other scheme", domain.WebhookSubscription{URL: "ftp://partner.example.com", EventTypes: []domain.EventName{domain.EventPointsEarned}, Secret: testWebhookSecret}, []string{"url"}},
+		{"unknown event", domain.WebhookSubscription{URL: "https://partner.example.com/hooks", EventTypes: []domain.EventName{domain.EventPointsEarned, "points.gifted"}, Secret: testWebhookSecret}, []string{"eventTypes[1]"}},
+		{"invalid member", domain.WebhookSubscription{URL: "https://partner.example.com/hooks", EventTypes: []domain.EventName{domain.EventPointsEarned}, UserIDs: []int{1, 0}, Secret: testWebhookSecret}, []string{"userIds[1]"}},
+		{"no events and short secret", domain.WebhookSubscription{URL: "https://partner.example.com/hooks", Secret: "short"}, []string{"eventTypes", "secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, _ := newTestWebhookService(3)
+			err := service.CreateSubscription(context.Background(), &tt.subscription)
+
+			subscriptions, listErr := repo.ListSubscriptions()
+			require.NoError(t, listErr)
+			if tt.wantFields == nil {
+				require.NoError(t, err)
+				assert.Equal(t, testNow, tt.subscription.CreatedAt)
+				assert.Len(t, subscriptions, 1)
+				return
+			}
+			assert.Equal(t, KindValidation, KindOf(err))
+			var fields []string
+			for _, f := range err.(*domain.ValidationError).Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+			assert.Empty(t, subscriptions)
+		})
+	}
+}
+
+func TestWebhookService_DeliversSignedEvents(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	service, _, _ := newTestWebhookService(3)
+	subscription := subscribe(t, service, receiver.URL+"/hooks", domain.EventPointsEarned, domain.EventPointsRedeemed)
+
+	earned := outboxEvent(t, 7, domain.EventPointsEarned)
+	require.NoError(t, service.Publish(context.Background(), earned))
+	require.NoError(t, service.Publish(context.Background(), outboxEvent(t, 8, domain.EventTransferCompleted)))
+	// The outbox delivers at least once; a repeat is not queued again
+	require.NoError(t, service.Publish(context.Background(), earned))
+
+	run, err := service.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &domain.WebhookRun{Delivered: 1}, run)
+
+	requests := receiver.received()
+	require.Len(t, requests, 1)
+	got := requests[0]
+	want, err := json.Marshal(earned)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got.body))
+	assert.Equal(t, "application/json", got.header.Get("Content-Type"))
+	assert.Equal(t, "points.earned", got.header.Get(domain.WebhookHeaderEvent))
+	assert.Equal(t, strconv.FormatInt(testNow.Unix(), 10), got.header.Get(domain.WebhookHeaderTimestamp))
+
+	// Receivers verify the HMAC-SHA256 of "<timestamp>.<body>" with their secret
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(got.header.Get(domain.WebhookHeaderTimestamp) + "."))
+	mac.Write(got.body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), got.header.Get(domain.WebhookHeaderSignature))
+
+	deliveries, total, err := service.ListDeliveries(port.WebhookDeliveryFilter{SubscriptionID: subscription.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	if assert.Len(t, deliveries, 1) {
+		delivery := deliveries[0]
+		assert.Equal(t, got.header.Get(domain.WebhookHeaderID), strconv.Itoa(delivery.ID))
+		assert.Equal(t, domain.WebhookDeliveryDelivered, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusOK, *delivery.ResponseStatus)
+		assert.Equal(t, testNow, *delivery.DeliveredAt)
+	}
+
+	run, err = service.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &domain.WebhookRun{}, run)
+}
+
+func TestWebhookService_ScopedSubscription(t *testing.T) {
+	service, repo, _ := newTestWebhookService(3)
+	all := subscribe(t, service, "https://loyalty.example.com/hooks", domain.EventPointsEarned, domain.EventTransferCompleted)
+	partner := &domain.WebhookSubscription{URL: "https://partner.example.com/hooks", Secret: testWebhookSecret,
+		EventTypes: []domain.EventName{domain.EventPointsEarned, domain.EventTransferCompleted}, UserIDs: []int{2}}
+	require.NoError(t, service.CreateSubscription(context.Background(), partner))
+
+	// Member 1 earns, then sends points to member 2
+	require.NoError(t, service.Publish(context.Background(), outboxEvent(t, 1, domain.EventPointsEarned)))
+	transfer, err := domain.NewOutboxEvent(domain.EventTransferCompleted, "transfer", 9,
+		domain.TransferCompletedEvent{TransferID: 9, FromUserID: 1, ToUserID: 2, Amount: 50}, testNow)
+	require.NoError(t, err)
+	transfer.ID = 2
+	require.NoError(t, service.Publish(context.Background(), *transfer))
+
+	eventIDs := func(subscription *domain.WebhookSubscription) []int {
+		deliveries, _, err := repo.ListDeliveries(port.WebhookDeliveryFilter{SubscriptionID: subscription.ID, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		var ids []int
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.EventID)
+		}
+		return ids
+	}
+	assert.ElementsMatch(t, []int{1, 2}, eventIDs(all))
+	assert.Equal(t, []int{2}, eventIDs(partner))
+}
+
+// flakyWebhookRepository fails the first delivery queued for subscription
+// failSubscription.
+type flakyWebhookRepository struct {
+	port.WebhookRepository
+	failSubscription int
+	failed           bool
+}
+
+func (r *flakyWebhookRepository) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	if delivery.SubscriptionID == r.failSubscription && !r.failed {
+		r.failed = true
+		return errors.New("disk I/O error")
+	}
+	return r.WebhookRepository.CreateDelivery(delivery)
+}
+
+func TestWebhookService_RetriedEventIsQueuedOnce(t *testing.T) {
+	repo := &flakyWebhookRepository{WebhookRepository: memory.NewWebhookRepository(memory.NewStore())}
+	policy := domain.WebhookPolicy{Backoff: testBackoff, MaxAttempts: 3}
+	service := NewWebhookService(repo, adapter.NewHTTPWebhookSender(time.Second), testutil.NewClock(testNow), policy, 10)
+	first := subscribe(t, service, "https://partner.example.com/hooks", domain.EventPointsEarned)
+	second := subscribe(t, service, "https://other.example.com/hooks", domain.EventPointsEarned)
+	repo.failSubscription = second.ID
+
+	// The first subscription is queued before the second fails, then the
+	// outbox publishes the whole event again
+	event := outboxEvent(t, 1, domain.EventPointsEarned)
+	assert.Error(t, service.Publish(context.Background(), event))
+	require.NoError(t, service.Publish(context.Background(), event))
+
+	for _, subscription := range []*domain.WebhookSubscription{first, second} {
+		_, total, err := service.ListDeliveries(port.WebhookDeliveryFilter{SubscriptionID: subscription.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total, subscription.URL)
+	}
+}
+
+func TestWebhookService_RetriesThenDeadLetters(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	receiver.respondWith(http.StatusServiceUnavailable)
+	service, _, clock := newTestWebhookService(3)
+	subscription := subscribe(t, service, receiver.URL, domain.EventPointsEarned)
+	require.NoError(t, service.Publish(context.Background(), outboxEvent(t, 1, domain.EventPointsEarned)))
+
+	deliver := func() *domain.WebhookRun {
+		t.Helper()
+		run, err := service.DeliverDue(context.Background())
+		require.NoError(t, err)
+		return run
+	}
+
+	assert.Equal(t, &domain.WebhookRun{Retrying: 1}, deliver())
+	// Retries back off: 1s, then 2s
+	assert.Equal(t, &domain.WebhookRun{}, deliver())
+	clock.Advance(time.Second)
+	assert.Equal(t, &domain.WebhookRun{Retrying: 1}, deliver())
+	clock.Advance(time.Second)
+	assert.Equal(t, &domain.WebhookRun{}, deliver())
+	clock.Advance(time.Second)
+	assert.Equal(t, &domain.WebhookRun{Dead: 1}, deliver())
+
+	// Dead deliveries are not retried on their own
+	clock.Advance(time.Hour)
+	assert.Equal(t, &domain.WebhookRun{}, deliver())
+	assert.Len(t, receiver.received(), 3)
+
+	dead, _, err := service.ListDeliveries(port.WebhookDeliveryFilter{SubscriptionID: subscription.ID, Status: domain.WebhookDeliveryDead})
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, *dead[0].ResponseStatus)
+	assert.Equal(t, "receiver responded with status 503", *dead[0].LastError)
+
+	// Once the receiver recovers, an operator redelivers it
+	receiver.respondWith(http.StatusNoContent)
+	requeued, err := service.Redeliver(context.Background(), dead[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.WebhookDeliveryPending, requeued.Status)
+	assert.Equal(t, 0, requeued.Attempts)
+	assert.Equal(t, &domain.WebhookRun{Delivered: 1}, deliver())
+
+	_, err = service.Redeliver(context.Background(), dead[0].ID)
+	assert.Equal(t, ErrDeliveryNotDead, err)
+	_, err = service.Redeliver(context.Background(), 999)
+	assert.Equal(t, ErrDeliveryNotFound, err)
+}
+
+func TestWebhookService_UnreachableReceiver(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	url := receiver.URL
+	receiver.Close()
+
+	service, _, _ := newTestWebhookService(1)
+	subscription := subscribe(t, service, url, domain.EventPointsEarned)
+	require.NoError(t, service.Publish(context.Background(), outboxEvent(t, 1, domain.EventPointsEarned)))
+
+	run, err := service.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &domain.WebhookRun{Dead: 1}, run)
+
+	deliveries, _, err := service.ListDeliveries(port.WebhookDeliveryFilter{SubscriptionID: subscription.ID})
+	require.NoError(t, err)
+	if assert.Len(t, deliveries, 1) {
+		assert.Nil(t, deliveries[0].ResponseStatus)
+		assert.NotNil(t, deliveries[0].LastError)
+	}
+}
+
+func TestWebhookService_DeleteSubscription(t *testing.T) {
+	service, _, _ := newTestWebhookService(3)
+	subscription := subscribe(t, service, "https://partner.example.com/hooks", domain.EventPointsEarned)
+
+	require.NoError(t, service.DeleteSubscription(context.Background(), subscription.ID))
+	assert.Equal(t, ErrWebhookNotFound, service.DeleteSubscription(context.Background(), subscription.ID))
+	_, _, err := service.ListDeliveries(port.WebhookDeliveryFilter{SubscriptionID: subscription.ID})
+	assert.Equal(t, ErrWebhookNotFound, err)
+}
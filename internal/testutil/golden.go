@@ -0,0 +1,37 @@
+package testutil
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata with the current output")
+
+// AssertGolden compares got with testdata/<name>. Run the tests with -update
+// to write got to the file instead.
+func AssertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "golden file missing; run the tests with -update to create it")
+	assert.Equal(t, string(want), string(got))
+}
+
+// AssertGoldenJSON compares v, as indented JSON, with testdata/<name>.
+func AssertGoldenJSON(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	require.NoError(t, err)
+	AssertGolden(t, name, append(got, '\n'))
+}
@@ -0,0 +1,27 @@
+package testutil
+
+import (
+	"fmt"
+	"sync"
+)
+
+// IDs is a port.IDGenerator that counts up from 1 and formats the count as a
+// UUID, so generated IDs look real but are the same on every run.
+type IDs struct {
+	mu   sync.Mutex
+	next uint64
+}
+
+// NewIDs returns an IDs whose first ID is
+// 00000000-0000-0000-0000-000000000001.
+func NewIDs() *IDs {
+	return &IDs{next: 1}
+}
+
+func (g *IDs) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := fmt.Sprintf("00000000-0000-0000-0000-%012x", g.next)
+	g.next++
+	return id
+}